@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/url"
 
@@ -40,9 +43,27 @@ func New(corpID, corpSecret, token, encodingAESKey string) *API {
 	return api
 }
 
+// SetTokenStore 方法用于设置令牌的共享存储，使多个实例共用同一份 access_token，
+// 同一企业下不同应用的 secret 对应不同的令牌，因此存储的 key 同时包含 corpid 和 secret 摘要
+func (a *API) SetTokenStore(store base.TokenStore) {
+	sum := sha256.Sum256([]byte(a.corpSecret))
+	a.Tokener.SetStore(store, "access_token:"+a.CorpID+":"+hex.EncodeToString(sum[:8]))
+}
+
+// SetRateLimiter 方法用于设置频率限制器，企业级配额按 corpid 统计，应用级配额按 secret 区分，
+// 同一个 RateLimiter 可以在多个 API 实例间共享
+func (a *API) SetRateLimiter(limiter *base.RateLimiter) {
+	sum := sha256.Sum256([]byte(a.corpSecret))
+	a.Client.SetRateLimiter(limiter, a.CorpID, hex.EncodeToString(sum[:8]))
+}
 
 // Retriable 方法实现了 API 在发起请求遇到 token 错误时，先刷新 token 然后再次发起请求的逻辑
 func (a *API) Retriable(reqURL string, body []byte) (bool, string, error) {
+	return a.RetriableContext(context.Background(), reqURL, body)
+}
+
+// RetriableContext 为 Retriable 的 context 版本，刷新 token 时沿用请求的 ctx
+func (a *API) RetriableContext(ctx context.Context, reqURL string, body []byte) (bool, string, error) {
 	u, err := url.Parse(reqURL)
 	if err != nil {
 		return false, "", nil
@@ -62,11 +83,11 @@ func (a *API) Retriable(reqURL string, body []byte) (bool, string, error) {
 	case base.ErrCodeOk:
 		return false, "", nil
 	case base.ErrCodeTokenInvalid, base.ErrCodeTokenTimeout:
-		if err := a.Tokener.RefreshToken(); err != nil {
+		if err := a.Tokener.RefreshTokenContext(ctx); err != nil {
 			return false, "", err
 		}
 
-		token, err := a.Tokener.Token()
+		token, err := a.Tokener.TokenContext(ctx)
 		if err != nil {
 			return false, "", err
 		}
@@ -81,13 +102,18 @@ func (a *API) Retriable(reqURL string, body []byte) (bool, string, error) {
 
 // FetchToken 方法使用企业管理组的密钥向 API 服务器获取企业号的令牌信息
 func (a *API) FetchToken() (token string, expiresIn int64, err error) {
+	return a.FetchTokenContext(context.Background())
+}
+
+// FetchTokenContext 为 FetchToken 的 context 版本
+func (a *API) FetchTokenContext(ctx context.Context) (token string, expiresIn int64, err error) {
 	qs := make(url.Values)
 	qs.Add("corpid", a.CorpID)
 	qs.Add("corpsecret", a.corpSecret)
 
 	url := fetchTokenURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(url)
+	body, err := a.Client.GetJSONContext(ctx, url)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	createAppChatURI = "https://qyapi.weixin.qq.com/cgi-bin/appchat/create"
+	updateAppChatURI = "https://qyapi.weixin.qq.com/cgi-bin/appchat/update"
+	getAppChatURI    = "https://qyapi.weixin.qq.com/cgi-bin/appchat/get"
+	sendAppChatURI   = "https://qyapi.weixin.qq.com/cgi-bin/appchat/send"
+)
+
+// 群聊成员数量及群聊 id 的限制
+const (
+	MinAppChatMembers = 2
+	MaxAppChatMembers = 2000
+	maxAppChatIDChars = 32
+)
+
+var appChatIDPattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// appChatMessageTypes 为群聊支持的消息类型，其余类型（如模板卡片、小程序通知）仅可通过 SendMessage 发送
+var appChatMessageTypes = map[MessageType]bool{
+	TextMsg:     true,
+	ImageMsg:    true,
+	VoiceMsg:    true,
+	VideoMsg:    true,
+	FileMsg:     true,
+	TextCardMsg: true,
+	NewsMsg:     true,
+	MpNewsMsg:   true,
+	MarkdownMsg: true,
+}
+
+// 发送群聊消息时需从应用消息中去除的字段
+var appChatExcludedFields = []string{
+	"touser", "toparty", "totag", "agentid",
+	"enable_id_trans", "enable_duplicate_check", "duplicate_check_interval",
+}
+
+// AppChat 为应用创建的群聊会话，Owner 为空时由企业微信从成员中随机选取群主；
+// ChatType 为 0 时为普通群，为 1 时为家校群
+type AppChat struct {
+	ChatID   string   `json:"chatid,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+	UserList []string `json:"userlist"`
+	ChatType int      `json:"chat_type,omitempty"`
+}
+
+// Validate 方法用于校验创建群聊的参数，返回 *AppChatValidationError
+func (c *AppChat) Validate() error {
+	verr := &AppChatValidationError{}
+	if c.ChatID != "" {
+		verr.checkChatID(c.ChatID)
+	}
+
+	members := make(map[string]bool, len(c.UserList))
+	for _, userID := range c.UserList {
+		if members[userID] {
+			verr.add("userlist", "duplicate userid %q", userID)
+		}
+		members[userID] = true
+	}
+	if n := len(members); n < MinAppChatMembers || n > MaxAppChatMembers {
+		verr.add("userlist", "must have %d to %d members", MinAppChatMembers, MaxAppChatMembers)
+	}
+	if c.Owner != "" && !members[c.Owner] {
+		verr.add("owner", "owner %q must be a member", c.Owner)
+	}
+	return verr.err()
+}
+
+// AppChatUpdate 为修改群聊会话的参数，未设置的字段不做修改
+type AppChatUpdate struct {
+	ChatID      string   `json:"chatid"`
+	Name        string   `json:"name,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	AddUserList []string `json:"add_user_list,omitempty"`
+	DelUserList []string `json:"del_user_list,omitempty"`
+}
+
+// Validate 方法用于校验修改群聊的参数，返回 *AppChatValidationError；
+// 新群主须为群成员，无法在本地校验，仅检查其未被同时移出群聊
+func (u *AppChatUpdate) Validate() error {
+	verr := &AppChatValidationError{}
+	verr.checkChatID(u.ChatID)
+
+	deleted := make(map[string]bool, len(u.DelUserList))
+	for _, userID := range u.DelUserList {
+		deleted[userID] = true
+	}
+	for _, userID := range u.AddUserList {
+		if deleted[userID] {
+			verr.add("add_user_list", "userid %q is both added and deleted", userID)
+		}
+	}
+	if u.Owner != "" && deleted[u.Owner] {
+		verr.add("owner", "owner %q can not be deleted", u.Owner)
+	}
+	if len(u.AddUserList) > MaxAppChatMembers {
+		verr.add("add_user_list", "exceeds %d members", MaxAppChatMembers)
+	}
+	return verr.err()
+}
+
+// AppChatValidationError 为群聊参数校验失败的错误，包含所有不合法的字段
+type AppChatValidationError struct {
+	Fields []*MessageFieldError
+}
+
+func (e *AppChatValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		msgs = append(msgs, field.Error())
+	}
+	return "invalid appchat: " + strings.Join(msgs, "; ")
+}
+
+func (e *AppChatValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, &MessageFieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (e *AppChatValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *AppChatValidationError) checkChatID(chatID string) {
+	if chatID == "" {
+		e.add("chatid", "is required")
+	} else if len(chatID) > maxAppChatIDChars || !appChatIDPattern.MatchString(chatID) {
+		e.add("chatid", "must be at most %d letters or digits", maxAppChatIDChars)
+	}
+}
+
+// CreateAppChat 方法用于创建群聊会话，未指定 ChatID 时由企业微信生成并回填到 chat 中
+func (a *API) CreateAppChat(chat *AppChat) (string, error) {
+	return a.CreateAppChatContext(context.Background(), chat)
+}
+
+// CreateAppChatContext 为 CreateAppChat 的 context 版本
+func (a *API) CreateAppChatContext(ctx context.Context, chat *AppChat) (string, error) {
+	if err := chat.Validate(); err != nil {
+		return "", err
+	}
+
+	result := &struct {
+		ChatID string `json:"chatid"`
+	}{}
+	if err := a.PostJSONContext(ctx, createAppChatURI, nil, chat, result); err != nil {
+		return "", err
+	}
+
+	chat.ChatID = result.ChatID
+	return result.ChatID, nil
+}
+
+// UpdateAppChat 方法用于修改群聊会话的名称、群主及成员
+func (a *API) UpdateAppChat(update *AppChatUpdate) error {
+	return a.UpdateAppChatContext(context.Background(), update)
+}
+
+// UpdateAppChatContext 为 UpdateAppChat 的 context 版本
+func (a *API) UpdateAppChatContext(ctx context.Context, update *AppChatUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	return a.PostJSONContext(ctx, updateAppChatURI, nil, update, nil)
+}
+
+// GetAppChat 方法用于获取群聊会话
+func (a *API) GetAppChat(chatID string) (*AppChat, error) {
+	return a.GetAppChatContext(context.Background(), chatID)
+}
+
+// GetAppChatContext 为 GetAppChat 的 context 版本
+func (a *API) GetAppChatContext(ctx context.Context, chatID string) (*AppChat, error) {
+	qs := make(url.Values)
+	qs.Add("chatid", chatID)
+
+	result := &struct {
+		ChatInfo *AppChat `json:"chat_info"`
+	}{}
+	if err := a.GetJSONContext(ctx, getAppChatURI, qs, result); err != nil {
+		return nil, err
+	}
+	return result.ChatInfo, nil
+}
+
+// SendAppChatMessage 方法用于向群聊发送消息，message 为 TextMessage、MarkdownMessage 等应用消息，
+// 其中的接收人、agentid 及重复消息检查等字段会被忽略
+func (a *API) SendAppChatMessage(chatID string, message interface{}) error {
+	return a.SendAppChatMessageContext(context.Background(), chatID, message)
+}
+
+// SendAppChatMessageContext 为 SendAppChatMessage 的 context 版本
+func (a *API) SendAppChatMessageContext(ctx context.Context, chatID string, message interface{}) error {
+	data, err := encodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var msgType MessageType
+	_ = json.Unmarshal(fields["msgtype"], &msgType)
+
+	v := newMessageValidator(msgType)
+	v.require("chatid", chatID)
+	if !appChatMessageTypes[msgType] {
+		v.add("msgtype", "not supported in appchat")
+	}
+	if m, ok := message.(interface{ validateContent(*messageValidator) }); ok {
+		m.validateContent(v)
+	}
+	if err = v.err(); err != nil {
+		return err
+	}
+
+	for _, field := range appChatExcludedFields {
+		delete(fields, field)
+	}
+	if fields["chatid"], err = json.Marshal(chatID); err != nil {
+		return err
+	}
+
+	_, err = a.postMessageContext(ctx, sendAppChatURI, fields)
+	return err
+}
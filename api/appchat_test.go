@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func TestAppChat_Validate(t *testing.T) {
+	chat := &AppChat{ChatID: "oncall-01", Owner: "wangwu", UserList: []string{"zhangsan", "zhangsan"}}
+
+	var verr *AppChatValidationError
+	if err := chat.Validate(); !errors.As(err, &verr) || len(verr.Fields) != 4 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 群主须为群成员
+	chat = &AppChat{ChatID: "oncall01", Owner: "wangwu", UserList: []string{"zhangsan", "lisi"}}
+	if err := chat.Validate(); !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "owner" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	chat = &AppChat{ChatID: "oncall01", Owner: "zhangsan", UserList: []string{"zhangsan", "lisi"}}
+	if err := chat.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	update := &AppChatUpdate{ChatID: "oncall01", Owner: "lisi", AddUserList: []string{"wangwu"}, DelUserList: []string{"lisi", "wangwu"}}
+	if err := update.Validate(); err == nil || !strings.Contains(err.Error(), "add_user_list") || !strings.Contains(err.Error(), "owner") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestAPI_AppChat(t *testing.T) {
+	var sent []string
+	a := newMockAPI(func(req *http.Request, body string) string {
+		sent = append(sent, req.URL.Path+" "+strings.TrimSpace(body))
+		switch req.URL.Path {
+		case "/cgi-bin/appchat/create":
+			return `{"errcode":0,"errmsg":"ok","chatid":"chat01"}`
+		case "/cgi-bin/appchat/get":
+			if req.URL.Query().Get("chatid") != "chat01" {
+				return `{"errcode":86003,"errmsg":"chat not found"}`
+			}
+			return `{"errcode":0,"errmsg":"ok","chat_info":{"chatid":"chat01","name":"值班群","owner":"wangwu","userlist":["lisi","wangwu"]}}`
+		}
+		return `{"errcode":0,"errmsg":"ok"}`
+	})
+
+	chat := &AppChat{Name: "值班群", Owner: "zhangsan", UserList: []string{"zhangsan", "lisi"}}
+	chatID, err := a.CreateAppChat(chat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if chatID != "chat01" || chat.ChatID != chatID {
+		t.Fatalf("Unexpected chatid: %q", chatID)
+	}
+
+	err = a.UpdateAppChat(&AppChatUpdate{ChatID: chatID, Owner: "wangwu", AddUserList: []string{"wangwu"}, DelUserList: []string{"zhangsan"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := a.GetAppChat(chatID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Owner != "wangwu" || strings.Join(got.UserList, ",") != "lisi,wangwu" || got.Name != "值班群" {
+		t.Errorf("Unexpected chat: %+v", got)
+	}
+	if _, err := a.GetAppChat("unknown"); !errors.Is(err, base.ErrAppChatNotFound) {
+		t.Errorf("Expected ErrAppChatNotFound, got %v", err)
+	}
+
+	// 校验失败时不会发送
+	if _, err := a.CreateAppChat(&AppChat{Owner: "wangwu", UserList: []string{"zhangsan", "lisi"}}); err == nil {
+		t.Error("Expected validation error")
+	}
+	want := []string{
+		`/cgi-bin/appchat/create {"name":"值班群","owner":"zhangsan","userlist":["zhangsan","lisi"]}`,
+		`/cgi-bin/appchat/update {"chatid":"chat01","owner":"wangwu","add_user_list":["wangwu"],"del_user_list":["zhangsan"]}`,
+		`/cgi-bin/appchat/get `,
+		`/cgi-bin/appchat/get `,
+	}
+	if strings.Join(sent, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected requests: %v", sent)
+	}
+}
+
+func TestAPI_SendAppChatMessage(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	var sent []string
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if req.URL.Path == "/cgi-bin/appchat/send" {
+				data, _ := io.ReadAll(req.Body)
+				sent = append(sent, string(data))
+				respBody = `{"errcode":0,"errmsg":"ok"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	message := TextMessage{ToUser: "zhangsan", MsgType: TextMsg, AgentID: 1000002, Text: TextContent{Content: "db-01 <CPU 99%>"}, Safe: 1, EnableDuplicateCheck: 1}
+	if err := a.SendAppChatMessage("oncall01", message); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"chatid":"oncall01","msgtype":"text","safe":1,"text":{"content":"db-01 <CPU 99%>"}}`
+	if len(sent) != 1 || strings.TrimSpace(sent[0]) != want {
+		t.Errorf("Unexpected request: %v", sent)
+	}
+
+	// 群聊不支持模板卡片，且内容校验失败时不会发送
+	card := NewTemplateCardMessage(1000002, NewTextNoticeCard("告警", "", NewURLCardAction("https://example.com")))
+	var verr *MessageValidationError
+	if err := a.SendAppChatMessage("oncall01", card); !errors.As(err, &verr) || verr.Fields[0].Field != "msgtype" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := a.SendAppChatMessage("oncall01", NewMarkdownMessage(1000002, "")); !errors.As(err, &verr) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("Unexpected requests: %v", sent)
+	}
+}
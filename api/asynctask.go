@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 
@@ -23,6 +24,13 @@ const (
 	ReplaceDepartmentTask = "replace_party"
 )
 
+// 异步任务的状态
+const (
+	TaskStatusStarted    = 1
+	TaskStatusProcessing = 2
+	TaskStatusFinished   = 3
+)
+
 // AsyncTaskCallback 异步任务的回调信息
 type AsyncTaskCallback struct {
 	URL            string `json:"url"`
@@ -66,7 +74,8 @@ type UpdateDepartmentTaskResult struct {
 	DepartmentID int64 `json:"partyid"`
 }
 
-// AsyncTaskResultInfo 为异步任务完成结果信息
+// AsyncTaskResultInfo 为异步任务完成结果信息，Result 按任务类型为 []InviteUserTaskResult、
+// []UpdateUserTaskResult 或 []UpdateDepartmentTaskResult
 type AsyncTaskResultInfo struct {
 	Status     int         `json:"status"`
 	Type       string      `json:"type"`
@@ -78,9 +87,14 @@ type AsyncTaskResultInfo struct {
 
 // GetTaskResult 方法用于获取异步任务的完成结果
 func (a *API) GetTaskResult(taskID string) (AsyncTaskResultInfo, error) {
+	return a.GetTaskResultContext(context.Background(), taskID)
+}
+
+// GetTaskResultContext 为 GetTaskResult 的 context 版本
+func (a *API) GetTaskResultContext(ctx context.Context, taskID string) (AsyncTaskResultInfo, error) {
 	result := AsyncTaskResultInfo{}
 
-	token, err := a.Tokener.Token()
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return result, err
 	}
@@ -91,7 +105,7 @@ func (a *API) GetTaskResult(taskID string) (AsyncTaskResultInfo, error) {
 
 	uri := getTaskResultURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(uri)
+	body, err := a.Client.GetJSONContext(ctx, uri)
 	if err != nil {
 		return result, err
 	}
@@ -103,42 +117,74 @@ func (a *API) GetTaskResult(taskID string) (AsyncTaskResultInfo, error) {
 		return result, err
 	}
 
+	// Result 需指向具体类型的切片才能解析为对应的结构，解析后再取出切片本身
 	switch probeResult.Type {
 	case InviteUserTask:
-		result.Result = []InviteUserTaskResult{}
+		result.Result = &[]InviteUserTaskResult{}
 	case SyncUserTask, ReplaceUserTask:
-		result.Result = []UpdateUserTaskResult{}
+		result.Result = &[]UpdateUserTaskResult{}
 	case ReplaceDepartmentTask:
-		result.Result = []UpdateDepartmentTaskResult{}
+		result.Result = &[]UpdateDepartmentTaskResult{}
 	}
 
-	err = json.Unmarshal(body, &result)
+	if err = json.Unmarshal(body, &result); err != nil {
+		return result, err
+	}
+
+	switch rows := result.Result.(type) {
+	case *[]InviteUserTaskResult:
+		result.Result = *rows
+	case *[]UpdateUserTaskResult:
+		result.Result = *rows
+	case *[]UpdateDepartmentTaskResult:
+		result.Result = *rows
+	}
 
-	return result, err
+	return result, nil
 }
 
 // PerformInviteUsersTask 方法执行邀请成员关注的任务
 func (a *API) PerformInviteUsersTask(task InviteTask) (string, error) {
-	return a.performTask(inviteUsersTaskURI, task)
+	return a.PerformInviteUsersTaskContext(context.Background(), task)
+}
+
+// PerformInviteUsersTaskContext 为 PerformInviteUsersTask 的 context 版本
+func (a *API) PerformInviteUsersTaskContext(ctx context.Context, task InviteTask) (string, error) {
+	return a.performTask(ctx, inviteUsersTaskURI, task)
 }
 
 // PerformUpdateUsersTask 方法执行增量更新成员的任务
 func (a *API) PerformUpdateUsersTask(task UpdateContactTask) (string, error) {
-	return a.performTask(updateUsersTaskURI, task)
+	return a.PerformUpdateUsersTaskContext(context.Background(), task)
+}
+
+// PerformUpdateUsersTaskContext 为 PerformUpdateUsersTask 的 context 版本
+func (a *API) PerformUpdateUsersTaskContext(ctx context.Context, task UpdateContactTask) (string, error) {
+	return a.performTask(ctx, updateUsersTaskURI, task)
 }
 
 // PerformReplaceUsersTask 方法执行全量更新成员的任务
 func (a *API) PerformReplaceUsersTask(task UpdateContactTask) (string, error) {
-	return a.performTask(replaceUsersTaskURI, task)
+	return a.PerformReplaceUsersTaskContext(context.Background(), task)
+}
+
+// PerformReplaceUsersTaskContext 为 PerformReplaceUsersTask 的 context 版本
+func (a *API) PerformReplaceUsersTaskContext(ctx context.Context, task UpdateContactTask) (string, error) {
+	return a.performTask(ctx, replaceUsersTaskURI, task)
 }
 
 // PerformReplaceDepartmentTask 方法执行全量更新部门的任务
 func (a *API) PerformReplaceDepartmentTask(task UpdateContactTask) (string, error) {
-	return a.performTask(replaceDepartmentTaskURI, task)
+	return a.PerformReplaceDepartmentTaskContext(context.Background(), task)
+}
+
+// PerformReplaceDepartmentTaskContext 为 PerformReplaceDepartmentTask 的 context 版本
+func (a *API) PerformReplaceDepartmentTaskContext(ctx context.Context, task UpdateContactTask) (string, error) {
+	return a.performTask(ctx, replaceDepartmentTaskURI, task)
 }
 
-func (a *API) performTask(baseURI string, task interface{}) (string, error) {
-	token, err := a.Tokener.Token()
+func (a *API) performTask(ctx context.Context, baseURI string, task interface{}) (string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -153,7 +199,7 @@ func (a *API) performTask(baseURI string, task interface{}) (string, error) {
 		return "", err
 	}
 
-	body, err := a.Client.PostJSON(uri, data)
+	body, err := a.Client.PostJSONContext(ctx, uri, data)
 	if err != nil {
 		return "", err
 	}
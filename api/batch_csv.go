@@ -0,0 +1,384 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 批量导入成员与部门的 CSV 模板表头，与企业微信提供的 batch_user_sample.csv 与 batch_party_sample.csv 一致
+var (
+	UserCSVHeader       = []string{"姓名", "帐号", "手机号", "邮箱", "所在部门", "职位", "性别", "是否部门内领导", "排序", "别名", "地址", "座机", "禁用"}
+	DepartmentCSVHeader = []string{"部门名称", "部门ID", "父部门ID", "排序"}
+)
+
+// 上传 CSV 文件时使用的默认文件名
+const (
+	DefaultUserCSVName       = "batch_user.csv"
+	DefaultDepartmentCSVName = "batch_party.csv"
+)
+
+// CSV 文件使用带 BOM 的 UTF-8 编码，多值字段以分号分隔
+const (
+	csvBOM     = "\xEF\xBB\xBF"
+	csvListSep = ";"
+)
+
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9_\-.@]{1,64}$`)
+
+// UserCSVRow 为批量导入成员 CSV 中的一行，DepartmentPaths 为部门路径（如 总部/研发/后端），
+// 需通过 UserCSV.SetDepartmentTree 设置部门树后解析为部门 ID，与 DepartmentIDs 合并
+type UserCSVRow struct {
+	Name            string
+	UserID          string
+	Mobile          string
+	Email           string
+	DepartmentIDs   []int64
+	DepartmentPaths []string
+	Position        string
+	Gender          string
+	IsLeaderInDept  []int
+	Order           []int64
+	Alias           string
+	Address         string
+	Telephone       string
+	Disabled        bool
+}
+
+// NewUserCSVRow 方法用于由成员信息生成 CSV 行
+func NewUserCSVRow(user *User) *UserCSVRow {
+	return &UserCSVRow{
+		Name:           user.Name,
+		UserID:         user.UserID,
+		Mobile:         user.Mobile,
+		Email:          user.Email,
+		DepartmentIDs:  user.DepartmentIds,
+		Position:       user.Position,
+		Gender:         user.Gender,
+		IsLeaderInDept: user.IsLeaderInDept,
+		Order:          user.Order,
+		Alias:          user.Alias,
+		Address:        user.Address,
+		Telephone:      user.Telephone,
+		Disabled:       user.Enable != nil && *user.Enable == 0,
+	}
+}
+
+// CSVRowError 为 CSV 中某一行的校验错误，Line 为数据行的行号（表头为第 1 行）
+type CSVRowError struct {
+	Line int
+	Msg  string
+}
+
+func (e *CSVRowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// CSVValidationError 为 CSV 校验失败的错误，包含所有不合法的行
+type CSVValidationError struct {
+	Rows []*CSVRowError
+}
+
+func (e *CSVValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		msgs = append(msgs, row.Error())
+	}
+	return "invalid csv: " + strings.Join(msgs, "; ")
+}
+
+func (e *CSVValidationError) add(line int, format string, args ...interface{}) {
+	e.Rows = append(e.Rows, &CSVRowError{Line: line, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (e *CSVValidationError) err() error {
+	if len(e.Rows) == 0 {
+		return nil
+	}
+	return e
+}
+
+// UserCSV 用于生成增量更新成员（syncuser）及全量覆盖成员（replaceuser）任务所需的 CSV 文件
+type UserCSV struct {
+	rows []*UserCSVRow
+	tree *DepartmentTree
+}
+
+// NewUserCSV 方法用于创建 UserCSV 实例
+func NewUserCSV(rows ...*UserCSVRow) *UserCSV {
+	return &UserCSV{rows: rows}
+}
+
+// SetDepartmentTree 方法用于设置解析 DepartmentPaths 使用的部门树
+func (c *UserCSV) SetDepartmentTree(tree *DepartmentTree) {
+	c.tree = tree
+}
+
+// Add 方法用于添加成员行
+func (c *UserCSV) Add(rows ...*UserCSVRow) {
+	c.rows = append(c.rows, rows...)
+}
+
+// Len 方法返回成员行数
+func (c *UserCSV) Len() int {
+	return len(c.rows)
+}
+
+// Validate 方法用于校验所有成员行，返回 *CSVValidationError
+func (c *UserCSV) Validate() error {
+	_, err := c.records()
+	return err
+}
+
+// WriteTo 方法用于校验并将 CSV 内容写入 w
+func (c *UserCSV) WriteTo(w io.Writer) (int64, error) {
+	records, err := c.records()
+	if err != nil {
+		return 0, err
+	}
+	return writeCSV(w, UserCSVHeader, records)
+}
+
+func (c *UserCSV) records() ([][]string, error) {
+	verr := &CSVValidationError{}
+	seen := make(map[string]int)
+	records := make([][]string, 0, len(c.rows))
+
+	for i, row := range c.rows {
+		line := i + 2
+		if row.Name == "" {
+			verr.add(line, "name is required")
+		}
+		if !userIDPattern.MatchString(row.UserID) {
+			verr.add(line, "invalid userid %q", row.UserID)
+		} else if prev, ok := seen[row.UserID]; ok {
+			verr.add(line, "duplicate userid %q (line %d)", row.UserID, prev)
+		} else {
+			seen[row.UserID] = line
+		}
+		if row.Gender != "" && row.Gender != "1" && row.Gender != "2" {
+			verr.add(line, "invalid gender %q", row.Gender)
+		}
+
+		departmentIDs := append([]int64{}, row.DepartmentIDs...)
+		for _, path := range row.DepartmentPaths {
+			if c.tree == nil {
+				verr.add(line, "department path %q requires a department tree", path)
+				continue
+			}
+			node := c.tree.FindByPath(path)
+			if node == nil {
+				verr.add(line, "department path %q not found", path)
+				continue
+			}
+			departmentIDs = append(departmentIDs, node.ID)
+		}
+		if len(departmentIDs) == 0 && len(row.DepartmentPaths) == 0 {
+			verr.add(line, "at least one department is required")
+		}
+		for _, id := range departmentIDs {
+			if id <= 0 {
+				verr.add(line, "invalid department id %d", id)
+			}
+		}
+		if len(row.IsLeaderInDept) > 0 && len(row.IsLeaderInDept) != len(departmentIDs) {
+			verr.add(line, "is_leader_in_dept must match departments")
+		}
+		if len(row.Order) > 0 && len(row.Order) != len(departmentIDs) {
+			verr.add(line, "order must match departments")
+		}
+
+		disabled := "0"
+		if row.Disabled {
+			disabled = "1"
+		}
+		records = append(records, []string{
+			row.Name,
+			row.UserID,
+			row.Mobile,
+			row.Email,
+			joinInt64s(departmentIDs),
+			row.Position,
+			row.Gender,
+			joinInts(row.IsLeaderInDept),
+			joinInt64s(row.Order),
+			row.Alias,
+			row.Address,
+			row.Telephone,
+			disabled,
+		})
+	}
+	return records, verr.err()
+}
+
+// DepartmentCSV 用于生成全量覆盖部门（replaceparty）任务所需的 CSV 文件
+type DepartmentCSV struct {
+	departments []*Department
+}
+
+// NewDepartmentCSV 方法用于创建 DepartmentCSV 实例，部门需指定 ID 与 ParentID
+func NewDepartmentCSV(departments ...*Department) *DepartmentCSV {
+	return &DepartmentCSV{departments: departments}
+}
+
+// Add 方法用于添加部门行
+func (c *DepartmentCSV) Add(departments ...*Department) {
+	c.departments = append(c.departments, departments...)
+}
+
+// Len 方法返回部门行数
+func (c *DepartmentCSV) Len() int {
+	return len(c.departments)
+}
+
+// Validate 方法用于校验所有部门行，返回 *CSVValidationError，部门的上级关系形成环时返回 *DepartmentCycleError
+func (c *DepartmentCSV) Validate() error {
+	_, err := c.records()
+	return err
+}
+
+// WriteTo 方法用于校验并将 CSV 内容写入 w
+func (c *DepartmentCSV) WriteTo(w io.Writer) (int64, error) {
+	records, err := c.records()
+	if err != nil {
+		return 0, err
+	}
+	return writeCSV(w, DepartmentCSVHeader, records)
+}
+
+func (c *DepartmentCSV) records() ([][]string, error) {
+	verr := &CSVValidationError{}
+	seen := make(map[int64]int)
+	records := make([][]string, 0, len(c.departments))
+
+	for i, d := range c.departments {
+		line := i + 2
+		if d.Name == "" {
+			verr.add(line, "name is required")
+		}
+		if d.ID <= 0 {
+			verr.add(line, "invalid department id %d", d.ID)
+		} else if prev, ok := seen[d.ID]; ok {
+			verr.add(line, "duplicate department id %d (line %d)", d.ID, prev)
+		} else {
+			seen[d.ID] = line
+		}
+		if d.ID != 1 && d.ParentID <= 0 {
+			verr.add(line, "parent id is required")
+		}
+
+		parentID := ""
+		if d.ParentID > 0 {
+			parentID = strconv.FormatInt(d.ParentID, 10)
+		}
+		records = append(records, []string{
+			d.Name,
+			strconv.FormatInt(d.ID, 10),
+			parentID,
+			strconv.FormatInt(d.Order, 10),
+		})
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := NewDepartmentTree(c.departments); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func writeCSV(w io.Writer, header []string, records [][]string) (int64, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(csvBOM)
+
+	cw := csv.NewWriter(buf)
+	cw.Write(header)
+	cw.WriteAll(records)
+	if err := cw.Error(); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+func joinInt64s(values []int64) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.FormatInt(v, 10)
+	}
+	return strings.Join(strs, csvListSep)
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, csvListSep)
+}
+
+// UploadContactCSV 方法用于校验并上传成员或部门的 CSV 文件，返回用于异步任务的 media_id
+func (a *API) UploadContactCSV(filename string, c io.WriterTo) (string, error) {
+	return a.UploadContactCSVContext(context.Background(), filename, c)
+}
+
+// UploadContactCSVContext 为 UploadContactCSV 的 context 版本
+func (a *API) UploadContactCSVContext(ctx context.Context, filename string, c io.WriterTo) (string, error) {
+	buf := new(bytes.Buffer)
+	if _, err := c.WriteTo(buf); err != nil {
+		return "", err
+	}
+
+	media, err := a.UploadMediaContext(ctx, FileMedia, filename, buf)
+	if err != nil {
+		return "", err
+	}
+	return media.MediaID, nil
+}
+
+// PerformUpdateUsersTaskWithCSV 方法用于上传成员 CSV 并执行增量更新成员的任务，返回任务 ID
+func (a *API) PerformUpdateUsersTaskWithCSV(c *UserCSV, callback AsyncTaskCallback) (string, error) {
+	return a.PerformUpdateUsersTaskWithCSVContext(context.Background(), c, callback)
+}
+
+// PerformUpdateUsersTaskWithCSVContext 为 PerformUpdateUsersTaskWithCSV 的 context 版本
+func (a *API) PerformUpdateUsersTaskWithCSVContext(ctx context.Context, c *UserCSV, callback AsyncTaskCallback) (string, error) {
+	mediaID, err := a.UploadContactCSVContext(ctx, DefaultUserCSVName, c)
+	if err != nil {
+		return "", err
+	}
+	return a.PerformUpdateUsersTaskContext(ctx, UpdateContactTask{MediaID: mediaID, Callback: callback})
+}
+
+// PerformReplaceUsersTaskWithCSV 方法用于上传成员 CSV 并执行全量覆盖成员的任务，返回任务 ID
+func (a *API) PerformReplaceUsersTaskWithCSV(c *UserCSV, callback AsyncTaskCallback) (string, error) {
+	return a.PerformReplaceUsersTaskWithCSVContext(context.Background(), c, callback)
+}
+
+// PerformReplaceUsersTaskWithCSVContext 为 PerformReplaceUsersTaskWithCSV 的 context 版本
+func (a *API) PerformReplaceUsersTaskWithCSVContext(ctx context.Context, c *UserCSV, callback AsyncTaskCallback) (string, error) {
+	mediaID, err := a.UploadContactCSVContext(ctx, DefaultUserCSVName, c)
+	if err != nil {
+		return "", err
+	}
+	return a.PerformReplaceUsersTaskContext(ctx, UpdateContactTask{MediaID: mediaID, Callback: callback})
+}
+
+// PerformReplaceDepartmentTaskWithCSV 方法用于上传部门 CSV 并执行全量覆盖部门的任务，返回任务 ID
+func (a *API) PerformReplaceDepartmentTaskWithCSV(c *DepartmentCSV, callback AsyncTaskCallback) (string, error) {
+	return a.PerformReplaceDepartmentTaskWithCSVContext(context.Background(), c, callback)
+}
+
+// PerformReplaceDepartmentTaskWithCSVContext 为 PerformReplaceDepartmentTaskWithCSV 的 context 版本
+func (a *API) PerformReplaceDepartmentTaskWithCSVContext(ctx context.Context, c *DepartmentCSV, callback AsyncTaskCallback) (string, error) {
+	mediaID, err := a.UploadContactCSVContext(ctx, DefaultDepartmentCSVName, c)
+	if err != nil {
+		return "", err
+	}
+	return a.PerformReplaceDepartmentTaskContext(ctx, UpdateContactTask{MediaID: mediaID, Callback: callback})
+}
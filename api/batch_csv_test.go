@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUserCSV_WriteTo(t *testing.T) {
+	tree, _ := NewDepartmentTree([]*Department{
+		{ID: 1, Name: "总部"},
+		{ID: 2, Name: "研发", ParentID: 1},
+		{ID: 3, Name: "后端", ParentID: 2},
+	})
+
+	c := NewUserCSV(&UserCSVRow{
+		Name:           "张三",
+		UserID:         "zhangsan",
+		Mobile:         "13800000000",
+		DepartmentIDs:  []int64{1},
+		Position:       "工程师, 后端",
+		Gender:         "1",
+		IsLeaderInDept: []int{0, 1},
+		Order:          []int64{10, 20},
+	})
+	c.Add(&UserCSVRow{Name: "李四", UserID: "lisi", Email: "lisi@example.com", DepartmentPaths: []string{"总部/研发/后端"}, Disabled: true})
+	c.SetDepartmentTree(tree)
+
+	// 第一行的所在部门与排序数量不一致
+	if err := c.Validate(); err == nil {
+		t.Fatal("Expected validation error")
+	}
+	c.rows[0].DepartmentPaths = []string{"总部/研发"}
+
+	buf := new(bytes.Buffer)
+	if _, err := c.WriteTo(buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "\xEF\xBB\xBF" +
+		"姓名,帐号,手机号,邮箱,所在部门,职位,性别,是否部门内领导,排序,别名,地址,座机,禁用\n" +
+		"张三,zhangsan,13800000000,,1;2,\"工程师, 后端\",1,0;1,10;20,,,,0\n" +
+		"李四,lisi,,lisi@example.com,3,,,,,,,,1\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected csv:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestUserCSV_Validate(t *testing.T) {
+	c := NewUserCSV(
+		&UserCSVRow{Name: "张三", UserID: "zhangsan", DepartmentIDs: []int64{1}},
+		&UserCSVRow{Name: "", UserID: "zhang san", DepartmentIDs: []int64{1}},
+		&UserCSVRow{Name: "张三", UserID: "zhangsan", DepartmentPaths: []string{"总部"}},
+	)
+
+	var verr *CSVValidationError
+	if err := c.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("Expected *CSVValidationError, got %v", err)
+	}
+
+	var lines []int
+	for _, row := range verr.Rows {
+		lines = append(lines, row.Line)
+	}
+	// 第 3 行缺少姓名且帐号不合法，第 4 行帐号重复且未设置部门树
+	if len(lines) != 4 || lines[0] != 3 || lines[1] != 3 || lines[2] != 4 || lines[3] != 4 {
+		t.Errorf("Unexpected errors: %v", verr)
+	}
+}
+
+func TestDepartmentCSV_WriteTo(t *testing.T) {
+	c := NewDepartmentCSV(
+		&Department{ID: 1, Name: "总部"},
+		&Department{ID: 2, Name: "研发", ParentID: 1, Order: 100},
+	)
+
+	buf := new(bytes.Buffer)
+	if _, err := c.WriteTo(buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "\xEF\xBB\xBF部门名称,部门ID,父部门ID,排序\n总部,1,,0\n研发,2,1,100\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected csv: %q", buf.String())
+	}
+
+	c.Add(&Department{ID: 3, Name: "A", ParentID: 4}, &Department{ID: 4, Name: "B", ParentID: 3})
+	var cycleErr *DepartmentCycleError
+	if err := c.Validate(); !errors.As(err, &cycleErr) {
+		t.Errorf("Expected *DepartmentCycleError, got %v", err)
+	}
+}
+
+func TestAPI_PerformReplaceDepartmentTaskWithCSV(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	var uploaded, submitted string
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			switch req.URL.Path {
+			case "/cgi-bin/media/upload":
+				if err := req.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				file, header, _ := req.FormFile("media")
+				data, _ := io.ReadAll(file)
+				uploaded = header.Filename + ":" + string(data)
+				respBody = `{"errcode":0,"errmsg":"ok","type":"file","media_id":"media-1"}`
+			case "/cgi-bin/batch/replaceparty":
+				data, _ := io.ReadAll(req.Body)
+				submitted = string(data)
+				respBody = `{"errcode":0,"errmsg":"ok","jobid":"job-1"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	c := NewDepartmentCSV(&Department{ID: 1, Name: "总部"})
+	jobID, err := a.PerformReplaceDepartmentTaskWithCSV(c, AsyncTaskCallback{URL: "https://example.com/callback"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if jobID != "job-1" {
+		t.Errorf("Unexpected job id: %s", jobID)
+	}
+	if !strings.HasPrefix(uploaded, DefaultDepartmentCSVName+":\xEF\xBB\xBF部门名称") {
+		t.Errorf("Unexpected upload: %q", uploaded)
+	}
+	if !strings.Contains(submitted, `"media_id":"media-1"`) || !strings.Contains(submitted, `"url":"https://example.com/callback"`) {
+		t.Errorf("Unexpected task: %s", submitted)
+	}
+
+	// 校验失败时不会上传
+	uploaded = ""
+	if _, err := a.PerformReplaceDepartmentTaskWithCSV(NewDepartmentCSV(&Department{ID: 2}), AsyncTaskCallback{}); err == nil || uploaded != "" {
+		t.Errorf("Expected validation error without upload, got %v", err)
+	}
+}
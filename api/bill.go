@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 )
@@ -41,7 +41,12 @@ type AgentInfoResp struct {
 }
 
 func (a *API) GetAgent(agentid string) (*AgentInfoResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetAgentContext(context.Background(), agentid)
+}
+
+// GetAgentContext 为 GetAgent 的 context 版本
+func (a *API) GetAgentContext(ctx context.Context, agentid string) (*AgentInfoResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +54,7 @@ func (a *API) GetAgent(agentid string) (*AgentInfoResp, error) {
 	qs.Add("access_token", token)
 	qs.Add("agentid", agentid)
 	url := getAgentURI + "?" + qs.Encode()
-	body, err := a.Client.GetJSON(url)
+	body, err := a.Client.GetJSONContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -59,8 +64,8 @@ func (a *API) GetAgent(agentid string) (*AgentInfoResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
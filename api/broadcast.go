@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// 单次发送应用消息的接收人数量限制
+const (
+	MaxMessageUsers   = 1000
+	MaxMessageParties = 100
+	MaxMessageTags    = 100
+)
+
+// DefaultBroadcastConcurrency 为 Broadcaster 默认的并发发送数
+const DefaultBroadcastConcurrency = 4
+
+// DeliveryStatus 为接收人的投递状态
+type DeliveryStatus int
+
+// 接收人的投递状态值
+const (
+	DeliverySent       DeliveryStatus = iota // 已发送
+	DeliveryInvalid                          // 接收人无效或不在应用可见范围内
+	DeliveryUnlicensed                       // 成员没有基础接口许可
+	DeliveryFailed                           // 所在批次发送失败，可重试
+)
+
+func (s DeliveryStatus) String() string {
+	switch s {
+	case DeliverySent:
+		return "sent"
+	case DeliveryInvalid:
+		return "invalid"
+	case DeliveryUnlicensed:
+		return "unlicensed"
+	case DeliveryFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Recipients 为消息的接收人
+type Recipients struct {
+	UserIDs  []string
+	PartyIDs []int64
+	TagIDs   []int
+}
+
+// batches 方法用于去重并按单次发送的数量限制拆分接收人，各批次依次填充成员、部门与标签
+func (r Recipients) batches() []Recipients {
+	userIDs := dedupe(r.UserIDs)
+	partyIDs := dedupe(r.PartyIDs)
+	tagIDs := dedupe(r.TagIDs)
+
+	var batches []Recipients
+	for i := 0; ; i++ {
+		batch := Recipients{
+			UserIDs:  chunk(userIDs, i, MaxMessageUsers),
+			PartyIDs: chunk(partyIDs, i, MaxMessageParties),
+			TagIDs:   chunk(tagIDs, i, MaxMessageTags),
+		}
+		if len(batch.UserIDs) == 0 && len(batch.PartyIDs) == 0 && len(batch.TagIDs) == 0 {
+			return batches
+		}
+		batches = append(batches, batch)
+	}
+}
+
+func dedupe[T comparable](items []T) []T {
+	seen := make(map[T]bool, len(items))
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func chunk[T any](items []T, i, size int) []T {
+	start := i * size
+	if start >= len(items) {
+		return nil
+	}
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+func joinIDs[T int | int64](ids []T) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, strconv.FormatInt(int64(id), 10))
+	}
+	return strings.Join(strs, "|")
+}
+
+// BroadcastBatch 为一个批次的发送情况，Result 中包含用于撤回的 msgid 及更新模板卡片的 response_code
+type BroadcastBatch struct {
+	Index      int
+	Recipients Recipients
+	Result     *SendMessageResult
+	Err        error
+}
+
+// BroadcastBatchError 为某个批次发送失败的错误
+type BroadcastBatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BroadcastBatchError) Error() string {
+	return fmt.Sprintf("broadcast batch %d: %v", e.Index, e.Err)
+}
+
+// Unwrap 方法用于返回原始错误
+func (e *BroadcastBatchError) Unwrap() error {
+	return e.Err
+}
+
+// DeliveryReport 为群发消息的投递报告，汇总了所有批次中各接收人的投递状态
+type DeliveryReport struct {
+	Batches []*BroadcastBatch
+	Users   map[string]DeliveryStatus
+	Parties map[int64]DeliveryStatus
+	Tags    map[int]DeliveryStatus
+}
+
+// UsersWith 方法返回投递状态为 status 的成员，按 userid 排序
+func (r *DeliveryReport) UsersWith(status DeliveryStatus) []string {
+	var userIDs []string
+	for userID, s := range r.Users {
+		if s == status {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// PartiesWith 方法返回投递状态为 status 的部门，按 id 排序
+func (r *DeliveryReport) PartiesWith(status DeliveryStatus) []int64 {
+	var ids []int64
+	for id, s := range r.Parties {
+		if s == status {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// TagsWith 方法返回投递状态为 status 的标签，按 id 排序
+func (r *DeliveryReport) TagsWith(status DeliveryStatus) []int {
+	var ids []int
+	for id, s := range r.Tags {
+		if s == status {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// MsgIDs 方法返回所有发送成功批次的 msgid，可用于撤回消息
+func (r *DeliveryReport) MsgIDs() []string {
+	var msgIDs []string
+	for _, batch := range r.Batches {
+		if batch.Result != nil && batch.Result.MsgID != "" {
+			msgIDs = append(msgIDs, batch.Result.MsgID)
+		}
+	}
+	return msgIDs
+}
+
+// Failed 方法返回发送失败的批次，可使用其中的 Recipients 重新发送
+func (r *DeliveryReport) Failed() []*BroadcastBatch {
+	var failed []*BroadcastBatch
+	for _, batch := range r.Batches {
+		if batch.Err != nil {
+			failed = append(failed, batch)
+		}
+	}
+	return failed
+}
+
+// merge 方法用于将批次的发送结果合并到投递报告中
+func (r *DeliveryReport) merge(batch *BroadcastBatch) {
+	status := DeliverySent
+	switch {
+	case errors.Is(batch.Err, base.ErrInvalidRecipients):
+		// 批次中的接收人全部无效时不视为发送失败，无许可的成员等由下面响应中的列表区分
+		status = DeliveryInvalid
+		batch.Err = nil
+	case batch.Err != nil:
+		status = DeliveryFailed
+	}
+
+	for _, userID := range batch.Recipients.UserIDs {
+		r.Users[userID] = status
+	}
+	for _, id := range batch.Recipients.PartyIDs {
+		r.Parties[id] = status
+	}
+	for _, id := range batch.Recipients.TagIDs {
+		r.Tags[id] = status
+	}
+	if batch.Result == nil {
+		return
+	}
+
+	for _, userID := range batch.Result.InvalidUsers() {
+		r.Users[userID] = DeliveryInvalid
+	}
+	for _, userID := range batch.Result.UnlicensedUsers() {
+		r.Users[userID] = DeliveryUnlicensed
+	}
+	for _, id := range batch.Result.InvalidParties() {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			r.Parties[n] = DeliveryInvalid
+		}
+	}
+	for _, id := range batch.Result.InvalidTags() {
+		if n, err := strconv.Atoi(id); err == nil {
+			r.Tags[n] = DeliveryInvalid
+		}
+	}
+}
+
+// Broadcaster 用于向大量接收人群发应用消息：按单次发送的数量限制（成员 1000 个、部门与标签各 100 个）
+// 拆分接收人后并发发送，调用频率由 API 的限流器控制（通过 API 的 SetRateLimiter 设置），并汇总各批次的无效接收人生成投递报告
+type Broadcaster struct {
+	api         *API
+	concurrency int
+}
+
+// NewBroadcaster 方法用于创建 Broadcaster 实例
+func (a *API) NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		api:         a,
+		concurrency: DefaultBroadcastConcurrency,
+	}
+}
+
+// SetConcurrency 方法用于设置并发发送的批次数
+func (b *Broadcaster) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	b.concurrency = n
+}
+
+// Broadcast 方法用于向 recipients 群发消息，message 为 TextMessage 等应用消息，其中的接收人字段会被忽略。
+// 部分批次发送失败时仍返回完整的投递报告，错误为第一个失败批次的 *BroadcastBatchError
+func (b *Broadcaster) Broadcast(ctx context.Context, message interface{}, recipients Recipients) (*DeliveryReport, error) {
+	batches := recipients.batches()
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("broadcast: no recipients")
+	}
+
+	fields, err := broadcastFields(message)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeliveryReport{
+		Users:   make(map[string]DeliveryStatus),
+		Parties: make(map[int64]DeliveryStatus),
+		Tags:    make(map[int]DeliveryStatus),
+	}
+	for i, recipients := range batches {
+		report.Batches = append(report.Batches, &BroadcastBatch{Index: i, Recipients: recipients})
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	queue := make(chan *BroadcastBatch)
+	for i := 0; i < b.concurrency && i < len(report.Batches); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range queue {
+				batch.Result, batch.Err = b.send(ctx, fields, batch.Recipients)
+
+				mu.Lock()
+				report.merge(batch)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, batch := range report.Batches {
+		queue <- batch
+	}
+	close(queue)
+	wg.Wait()
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, &BroadcastBatchError{Index: failed[0].Index, Err: failed[0].Err}
+	}
+	return report, nil
+}
+
+func (b *Broadcaster) send(ctx context.Context, fields map[string]json.RawMessage, recipients Recipients) (*SendMessageResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	message := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		message[key] = value
+	}
+	if len(recipients.UserIDs) > 0 {
+		message["touser"] = strings.Join(recipients.UserIDs, "|")
+	}
+	if len(recipients.PartyIDs) > 0 {
+		message["toparty"] = joinIDs(recipients.PartyIDs)
+	}
+	if len(recipients.TagIDs) > 0 {
+		message["totag"] = joinIDs(recipients.TagIDs)
+	}
+	return b.api.SendMessageContext(ctx, message)
+}
+
+// broadcastFields 方法用于校验消息内容，并返回去除接收人后的消息字段
+func broadcastFields(message interface{}) (map[string]json.RawMessage, error) {
+	data, err := encodeMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	var msgType MessageType
+	_ = json.Unmarshal(fields["msgtype"], &msgType)
+
+	v := newMessageValidator(msgType)
+	if m, ok := message.(interface{ validateContent(*messageValidator) }); ok {
+		m.validateContent(v)
+	}
+	if err = v.err(); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "touser")
+	delete(fields, "toparty")
+	delete(fields, "totag")
+	return fields, nil
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func TestRecipients_Batches(t *testing.T) {
+	recipients := Recipients{PartyIDs: []int64{1, 2, 2}, TagIDs: []int{3}}
+	for i := 0; i < 2500; i++ {
+		recipients.UserIDs = append(recipients.UserIDs, fmt.Sprintf("user%d", i%2300))
+	}
+	for i := int64(10); i < 260; i++ {
+		recipients.PartyIDs = append(recipients.PartyIDs, i)
+	}
+
+	batches := recipients.batches()
+	if len(batches) != 3 {
+		t.Fatalf("Unexpected batches: %d", len(batches))
+	}
+	sizes := make([]string, 0, len(batches))
+	for _, batch := range batches {
+		sizes = append(sizes, fmt.Sprintf("%d/%d/%d", len(batch.UserIDs), len(batch.PartyIDs), len(batch.TagIDs)))
+	}
+	if got := strings.Join(sizes, ","); got != "1000/100/1,1000/100/0,300/52/0" {
+		t.Errorf("Unexpected batch sizes: %s", got)
+	}
+}
+
+func TestBroadcaster_Broadcast(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	var (
+		mu   sync.Mutex
+		sent []map[string]interface{}
+	)
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if req.URL.Path == "/cgi-bin/message/send" {
+				message := make(map[string]interface{})
+				_ = json.NewDecoder(req.Body).Decode(&message)
+				mu.Lock()
+				sent = append(sent, message)
+				mu.Unlock()
+
+				toUser, _ := message["touser"].(string)
+				toTag, _ := message["totag"].(string)
+				var invalid, unlicensed []string
+				for _, userID := range strings.Split(toUser, "|") {
+					switch {
+					case strings.HasPrefix(userID, "left"):
+						invalid = append(invalid, userID)
+					case strings.HasPrefix(userID, "trial"):
+						unlicensed = append(unlicensed, userID)
+					}
+				}
+				switch {
+				case strings.HasPrefix(toUser, "denied"):
+					respBody = `{"errcode":48002,"errmsg":"api forbidden"}`
+				case len(invalid)+len(unlicensed) == len(strings.Split(toUser, "|")):
+					respBody = fmt.Sprintf(`{"errcode":81013,"errmsg":"user & party & tag all invalid","invaliduser":%q,"invalidparty":"","invalidtag":"","unlicenseduser":%q}`,
+						strings.Join(invalid, "|"), strings.Join(unlicensed, "|"))
+				default:
+					respBody = fmt.Sprintf(`{"errcode":0,"errmsg":"ok","invaliduser":%q,"invalidparty":"","invalidtag":%q,"unlicenseduser":%q,"msgid":"msg-%s"}`,
+						strings.Join(invalid, "|"), toTag, strings.Join(unlicensed, "|"), strings.SplitN(toUser, "|", 2)[0])
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	var userIDs []string
+	for i := 0; i < 1000; i++ {
+		userIDs = append(userIDs, fmt.Sprintf("user%d", i))
+	}
+	// 第 2 批含无效与无许可成员，第 3 批没有有效成员，第 4 批无权限发送
+	userIDs = append(userIDs, "user2", "user1000", "trial1")
+	for i := 0; i < 1997; i++ {
+		userIDs = append(userIDs, fmt.Sprintf("left%d", i))
+	}
+	userIDs = append(userIDs, "trial2", "denied1")
+
+	message := NewMarkdownMessage(1000002, "**停电通知** <明天 9:00>")
+	message.ToUser = "ignored"
+
+	b := a.NewBroadcaster()
+	b.SetConcurrency(2)
+	report, err := b.Broadcast(context.Background(), message, Recipients{UserIDs: userIDs, TagIDs: []int{7}})
+
+	var batchErr *BroadcastBatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 3 || !errors.Is(err, base.ErrNoPrivilege) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sent) != 4 || len(report.Batches) != 4 {
+		t.Fatalf("Unexpected batches: sent %d, report %d", len(sent), len(report.Batches))
+	}
+	for _, message := range sent {
+		if message["touser"] == "ignored" || !strings.Contains(fmt.Sprint(message["markdown"]), "<明天 9:00>") {
+			t.Errorf("Unexpected message: %v", message)
+		}
+	}
+
+	if n := len(report.UsersWith(DeliverySent)); n != 1001 {
+		t.Errorf("Unexpected sent users: %d", n)
+	}
+	if got := report.UsersWith(DeliveryUnlicensed); len(got) != 2 || got[0] != "trial1" || got[1] != "trial2" {
+		t.Errorf("Unexpected unlicensed users: %v", got)
+	}
+	if n := len(report.UsersWith(DeliveryInvalid)); n != 1997 {
+		t.Errorf("Unexpected invalid users: %d", n)
+	}
+	if got := report.UsersWith(DeliveryFailed); len(got) != 1 || got[0] != "denied1" {
+		t.Errorf("Unexpected failed users: %v", got)
+	}
+	if got := report.TagsWith(DeliveryInvalid); len(got) != 1 || got[0] != 7 {
+		t.Errorf("Unexpected invalid tags: %v", got)
+	}
+	if got := report.MsgIDs(); len(got) != 2 || got[0] != "msg-user0" || got[1] != "msg-user1000" {
+		t.Errorf("Unexpected msgids: %v", got)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Recipients.UserIDs[0] != "denied1" {
+		t.Errorf("Unexpected failed batches: %v", failed)
+	}
+
+	// 消息内容校验失败时不会发送
+	sent = nil
+	var verr *MessageValidationError
+	if _, err := b.Broadcast(context.Background(), NewMarkdownMessage(1000002, ""), Recipients{UserIDs: userIDs}); !errors.As(err, &verr) {
+		t.Errorf("Expected *MessageValidationError, got %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("Unexpected requests: %d", len(sent))
+	}
+
+	// 没有接收人时返回错误
+	if report, err := b.Broadcast(context.Background(), message, Recipients{}); err == nil || report != nil {
+		t.Errorf("Expected error for empty recipients, got %v", report)
+	}
+}
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/url"
 )
 
@@ -32,7 +32,12 @@ type SyncCallProgramResp struct {
 
 // 应用同步调用专区程序
 func (a *API) SyncCallProgram(req *SyncCallProgramReq) (string, error) {
-	token, err := a.Tokener.Token()
+	return a.SyncCallProgramContext(context.Background(), req)
+}
+
+// SyncCallProgramContext 为 SyncCallProgram 的 context 版本
+func (a *API) SyncCallProgramContext(ctx context.Context, req *SyncCallProgramReq) (string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -44,7 +49,7 @@ func (a *API) SyncCallProgram(req *SyncCallProgramReq) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return "", err
 	}
@@ -53,15 +58,20 @@ func (a *API) SyncCallProgram(req *SyncCallProgramReq) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if result.Errcode != 0 {
-		return "", errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return "", err
 	}
 	return result.ResponseData, nil
 }
 
 // 创建专区程序调用任务
 func (a *API) AsyncProgramTask(req *SyncCallProgramReq) (string, error) {
-	token, err := a.Tokener.Token()
+	return a.AsyncProgramTaskContext(context.Background(), req)
+}
+
+// AsyncProgramTaskContext 为 AsyncProgramTask 的 context 版本
+func (a *API) AsyncProgramTaskContext(ctx context.Context, req *SyncCallProgramReq) (string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -73,7 +83,7 @@ func (a *API) AsyncProgramTask(req *SyncCallProgramReq) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return "", err
 	}
@@ -85,15 +95,20 @@ func (a *API) AsyncProgramTask(req *SyncCallProgramReq) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if result.Errcode != 0 {
-		return "", errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return "", err
 	}
 	return result.Jobid, nil
 }
 
 // 获取专区程序任务结果
 func (a *API) AsyncProgramResult(jobid string) (string, error) {
-	token, err := a.Tokener.Token()
+	return a.AsyncProgramResultContext(context.Background(), jobid)
+}
+
+// AsyncProgramResultContext 为 AsyncProgramResult 的 context 版本
+func (a *API) AsyncProgramResultContext(ctx context.Context, jobid string) (string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -105,7 +120,7 @@ func (a *API) AsyncProgramResult(jobid string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return "", err
 	}
@@ -118,15 +133,20 @@ func (a *API) AsyncProgramResult(jobid string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if result.Errcode != 0 {
-		return "", errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return "", err
 	}
 	return result.ResponseData, nil
 }
 
 // 设置公钥
 func (a *API) SetPublicKey(publicKey string, keyVer int) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.SetPublicKeyContext(context.Background(), publicKey, keyVer)
+}
+
+// SetPublicKeyContext 为 SetPublicKey 的 context 版本
+func (a *API) SetPublicKeyContext(ctx context.Context, publicKey string, keyVer int) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +160,7 @@ func (a *API) SetPublicKey(publicKey string, keyVer int) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -149,15 +169,20 @@ func (a *API) SetPublicKey(publicKey string, keyVer int) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 // 应用开启调试模式
 func (a *API) OpenDebugMode(programId, debugToken string) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.OpenDebugModeContext(context.Background(), programId, debugToken)
+}
+
+// OpenDebugModeContext 为 OpenDebugMode 的 context 版本
+func (a *API) OpenDebugModeContext(ctx context.Context, programId, debugToken string) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +196,7 @@ func (a *API) OpenDebugMode(programId, debugToken string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -180,15 +205,20 @@ func (a *API) OpenDebugMode(programId, debugToken string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 // 关闭专区调试模式
 func (a *API) CloseDebugMode(programId string) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.CloseDebugModeContext(context.Background(), programId)
+}
+
+// CloseDebugModeContext 为 CloseDebugMode 的 context 版本
+func (a *API) CloseDebugModeContext(ctx context.Context, programId string) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +229,7 @@ func (a *API) CloseDebugMode(programId string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -208,15 +238,20 @@ func (a *API) CloseDebugMode(programId string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 // 设置专区接收回调事件
 func (a *API) SetReceiveCallback(programId string) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.SetReceiveCallbackContext(context.Background(), programId)
+}
+
+// SetReceiveCallbackContext 为 SetReceiveCallback 的 context 版本
+func (a *API) SetReceiveCallbackContext(ctx context.Context, programId string) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +262,7 @@ func (a *API) SetReceiveCallback(programId string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -236,15 +271,20 @@ func (a *API) SetReceiveCallback(programId string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 // 获取数据与智能专区授权信息
 func (a *API) GetCorpAuthInfo() (*GetCorpAuthInfoResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetCorpAuthInfoContext(context.Background())
+}
+
+// GetCorpAuthInfoContext 为 GetCorpAuthInfo 的 context 版本
+func (a *API) GetCorpAuthInfoContext(ctx context.Context) (*GetCorpAuthInfoResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -255,7 +295,7 @@ func (a *API) GetCorpAuthInfo() (*GetCorpAuthInfoResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -264,15 +304,20 @@ func (a *API) GetCorpAuthInfo() (*GetCorpAuthInfoResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 // 获取授权存档的成员列表
 func (a *API) GetAuthUserList(cursor string) (*AuthUserListResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetAuthUserListContext(context.Background(), cursor)
+}
+
+// GetAuthUserListContext 为 GetAuthUserList 的 context 版本
+func (a *API) GetAuthUserListContext(ctx context.Context, cursor string) (*AuthUserListResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -287,7 +332,7 @@ func (a *API) GetAuthUserList(cursor string) (*AuthUserListResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -296,8 +341,8 @@ func (a *API) GetAuthUserList(cursor string) (*AuthUserListResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
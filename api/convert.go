@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 
@@ -12,7 +13,12 @@ const (
 )
 
 func (a *API) GetNewExternalUserid(externalUseridList []string) (*NewExternalUseridRes, error) {
-	token, err := a.Tokener.Token()
+	return a.GetNewExternalUseridContext(context.Background(), externalUseridList)
+}
+
+// GetNewExternalUseridContext 为 GetNewExternalUserid 的 context 版本
+func (a *API) GetNewExternalUseridContext(ctx context.Context, externalUseridList []string) (*NewExternalUseridRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +32,7 @@ func (a *API) GetNewExternalUserid(externalUseridList []string) (*NewExternalUse
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -40,13 +46,18 @@ const fromServiceExternalUserIDURI = "https://qyapi.weixin.qq.com/cgi-bin/extern
 
 // FromServiceExternalUserIDRes Represents the response for converting service external userid to selfbuilt external userid
 type FromServiceExternalUserIDRes struct {
-	BaseResp `json:",inline"`
+	BaseResp       `json:",inline"`
 	ExternalUserID string `json:"external_userid"`
 }
 
 // FromServiceExternalUserID converts external_userid from third-party/DK app to self-built app
 func (a *API) FromServiceExternalUserID(externalUserid string, sourceAgentID int) (*FromServiceExternalUserIDRes, error) {
-	token, err := a.Tokener.Token()
+	return a.FromServiceExternalUserIDContext(context.Background(), externalUserid, sourceAgentID)
+}
+
+// FromServiceExternalUserIDContext 为 FromServiceExternalUserID 的 context 版本
+func (a *API) FromServiceExternalUserIDContext(ctx context.Context, externalUserid string, sourceAgentID int) (*FromServiceExternalUserIDRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +71,7 @@ func (a *API) FromServiceExternalUserID(externalUserid string, sourceAgentID int
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -69,5 +80,3 @@ func (a *API) FromServiceExternalUserID(externalUserid string, sourceAgentID int
 	err = json.Unmarshal(body, result)
 	return result, err
 }
-
-
@@ -1,29 +1,39 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
 )
 
 const (
-	createDepartmentURI = "https://qyapi.weixin.qq.com/cgi-bin/department/create"
-	updateDepartmentURI = "https://qyapi.weixin.qq.com/cgi-bin/department/update"
-	deleteDepartmentURI = "https://qyapi.weixin.qq.com/cgi-bin/department/delete"
-	listDepartmentURI   = "https://qyapi.weixin.qq.com/cgi-bin/department/list"
+	createDepartmentURI     = "https://qyapi.weixin.qq.com/cgi-bin/department/create"
+	updateDepartmentURI     = "https://qyapi.weixin.qq.com/cgi-bin/department/update"
+	deleteDepartmentURI     = "https://qyapi.weixin.qq.com/cgi-bin/department/delete"
+	listDepartmentURI       = "https://qyapi.weixin.qq.com/cgi-bin/department/list"
+	listSimpleDepartmentURI = "https://qyapi.weixin.qq.com/cgi-bin/department/simplelist"
+	getDepartmentURI        = "https://qyapi.weixin.qq.com/cgi-bin/department/get"
 )
 
 // Department 表示部门信息
 type Department struct {
-	ID       int64  `json:"id,omitempty"`
-	Name     string `json:"name,omitempty"`
-	Order    int64  `json:"order,omitempty"`
-	ParentID int64  `json:"parentid,omitempty"`
+	ID               int64    `json:"id,omitempty"`
+	Name             string   `json:"name,omitempty"`
+	NameEn           string   `json:"name_en,omitempty"`
+	DepartmentLeader []string `json:"department_leader,omitempty"`
+	Order            int64    `json:"order,omitempty"`
+	ParentID         int64    `json:"parentid,omitempty"`
 }
 
 // CreateDepartment 方法用于创建部门
 func (a *API) CreateDepartment(department *Department) error {
-	token, err := a.Tokener.Token()
+	return a.CreateDepartmentContext(context.Background(), department)
+}
+
+// CreateDepartmentContext 为 CreateDepartment 的 context 版本
+func (a *API) CreateDepartmentContext(ctx context.Context, department *Department) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -37,7 +47,7 @@ func (a *API) CreateDepartment(department *Department) error {
 		return err
 	}
 
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return err
 	}
@@ -53,7 +63,12 @@ func (a *API) CreateDepartment(department *Department) error {
 
 // UpdateDepartment 方法用于更新部门信息
 func (a *API) UpdateDepartment(department *Department) error {
-	token, err := a.Tokener.Token()
+	return a.UpdateDepartmentContext(context.Background(), department)
+}
+
+// UpdateDepartmentContext 为 UpdateDepartment 的 context 版本
+func (a *API) UpdateDepartmentContext(ctx context.Context, department *Department) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -67,13 +82,18 @@ func (a *API) UpdateDepartment(department *Department) error {
 		return err
 	}
 
-	_, err = a.Client.PostJSON(url, data)
+	_, err = a.Client.PostJSONContext(ctx, url, data)
 	return err
 }
 
 // DeleteDepartment 方法用于删除部门
 func (a *API) DeleteDepartment(id int64) error {
-	token, err := a.Tokener.Token()
+	return a.DeleteDepartmentContext(context.Background(), id)
+}
+
+// DeleteDepartmentContext 为 DeleteDepartment 的 context 版本
+func (a *API) DeleteDepartmentContext(ctx context.Context, id int64) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -84,13 +104,18 @@ func (a *API) DeleteDepartment(id int64) error {
 
 	url := deleteDepartmentURI + "?" + qs.Encode()
 
-	_, err = a.Client.GetJSON(url)
+	_, err = a.Client.GetJSONContext(ctx, url)
 	return err
 }
 
 // ListDepartment 方法用于获取部门列表，获取根部门时 id 为 1
 func (a *API) ListDepartment(id int64) ([]*Department, error) {
-	token, err := a.Tokener.Token()
+	return a.ListDepartmentContext(context.Background(), id)
+}
+
+// ListDepartmentContext 为 ListDepartment 的 context 版本
+func (a *API) ListDepartmentContext(ctx context.Context, id int64) ([]*Department, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +126,7 @@ func (a *API) ListDepartment(id int64) ([]*Department, error) {
 
 	url := listDepartmentURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(url)
+	body, err := a.Client.GetJSONContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -116,3 +141,41 @@ func (a *API) ListDepartment(id int64) ([]*Department, error) {
 
 	return result.Departments, nil
 }
+
+// ListSimpleDepartment 方法用于获取子部门 ID 列表（仅包含 ID、ParentID 与 Order），id 为 0 时获取全量组织架构
+func (a *API) ListSimpleDepartment(id int64) ([]*Department, error) {
+	return a.ListSimpleDepartmentContext(context.Background(), id)
+}
+
+// ListSimpleDepartmentContext 为 ListSimpleDepartment 的 context 版本
+func (a *API) ListSimpleDepartmentContext(ctx context.Context, id int64) ([]*Department, error) {
+	qs := make(url.Values)
+	if id != 0 {
+		qs.Add("id", strconv.FormatInt(id, 10))
+	}
+
+	result := &struct {
+		Departments []*Department `json:"department_id"`
+	}{}
+	err := a.GetJSONContext(ctx, listSimpleDepartmentURI, qs, result)
+	return result.Departments, err
+}
+
+// GetDepartment 方法用于获取单个部门的详情
+func (a *API) GetDepartment(id int64) (*Department, error) {
+	return a.GetDepartmentContext(context.Background(), id)
+}
+
+// GetDepartmentContext 为 GetDepartment 的 context 版本
+func (a *API) GetDepartmentContext(ctx context.Context, id int64) (*Department, error) {
+	qs := make(url.Values)
+	qs.Add("id", strconv.FormatInt(id, 10))
+
+	result := &struct {
+		Department *Department `json:"department"`
+	}{}
+	if err := a.GetJSONContext(ctx, getDepartmentURI, qs, result); err != nil {
+		return nil, err
+	}
+	return result.Department, nil
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DepartmentPathSeparator 为部门路径中各级部门名称的分隔符，如 总部/研发/后端
+const DepartmentPathSeparator = "/"
+
+// DepartmentNode 为部门树中的节点，Children 按 Order 从大到小排列，Order 相同时按 ID 排列
+type DepartmentNode struct {
+	*Department
+	Parent   *DepartmentNode
+	Children []*DepartmentNode
+}
+
+// Path 方法返回从根部门到当前部门的名称路径，如 总部/研发/后端
+func (n *DepartmentNode) Path() string {
+	var names []string
+	for node := n; node != nil; node = node.Parent {
+		names = append([]string{node.Name}, names...)
+	}
+	return strings.Join(names, DepartmentPathSeparator)
+}
+
+// Depth 方法返回当前部门的层级，根部门为 0
+func (n *DepartmentNode) Depth() int {
+	depth := 0
+	for node := n.Parent; node != nil; node = node.Parent {
+		depth++
+	}
+	return depth
+}
+
+// Ancestors 方法返回当前部门的所有上级部门，由直接上级到根部门排列
+func (n *DepartmentNode) Ancestors() []*DepartmentNode {
+	var nodes []*DepartmentNode
+	for node := n.Parent; node != nil; node = node.Parent {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Descendants 方法按先序返回当前部门的所有下级部门，不包含当前部门
+func (n *DepartmentNode) Descendants() []*DepartmentNode {
+	var nodes []*DepartmentNode
+	for _, child := range n.Children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, child.Descendants()...)
+	}
+	return nodes
+}
+
+// DepartmentCycleError 为部门的上级关系形成环时返回的错误，IDs 为环上的部门 ID
+type DepartmentCycleError struct {
+	IDs []int64
+}
+
+func (e *DepartmentCycleError) Error() string {
+	return fmt.Sprintf("department cycle detected: %v", e.IDs)
+}
+
+// DepartmentTree 为由部门列表构建的部门树，上级部门不在列表中的部门视为根部门
+type DepartmentTree struct {
+	Roots []*DepartmentNode
+	nodes map[int64]*DepartmentNode
+}
+
+// NewDepartmentTree 方法用于由 ListDepartment 等返回的部门列表构建部门树，
+// 部门 ID 重复时返回错误，上级关系形成环时返回 *DepartmentCycleError
+func NewDepartmentTree(departments []*Department) (*DepartmentTree, error) {
+	t := &DepartmentTree{nodes: make(map[int64]*DepartmentNode, len(departments))}
+	for _, d := range departments {
+		if _, ok := t.nodes[d.ID]; ok {
+			return nil, fmt.Errorf("duplicate department id: %d", d.ID)
+		}
+		t.nodes[d.ID] = &DepartmentNode{Department: d}
+	}
+
+	for _, node := range t.nodes {
+		parent, ok := t.nodes[node.ParentID]
+		if !ok || node.ParentID == node.ID {
+			t.Roots = append(t.Roots, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	if err := t.checkCycle(); err != nil {
+		return nil, err
+	}
+
+	sortDepartmentNodes(t.Roots)
+	for _, node := range t.nodes {
+		sortDepartmentNodes(node.Children)
+	}
+	return t, nil
+}
+
+// checkCycle 方法用于检查上级关系是否成环，环上的部门无法由根部门到达
+func (t *DepartmentTree) checkCycle() error {
+	reached := 0
+	for _, root := range t.Roots {
+		reached += 1 + len(root.Descendants())
+	}
+	if reached == len(t.nodes) {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(t.nodes))
+	for id := range t.nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		visited := make(map[int64]bool)
+		node := t.nodes[id]
+		for node != nil && !visited[node.ID] {
+			visited[node.ID] = true
+			node = node.Parent
+		}
+		if node == nil {
+			continue
+		}
+
+		cycle := []int64{node.ID}
+		for n := node.Parent; n != node; n = n.Parent {
+			cycle = append(cycle, n.ID)
+		}
+		return &DepartmentCycleError{IDs: cycle}
+	}
+	return nil
+}
+
+func sortDepartmentNodes(nodes []*DepartmentNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Order != nodes[j].Order {
+			return nodes[i].Order > nodes[j].Order
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// Len 方法返回部门树中的部门数量
+func (t *DepartmentTree) Len() int {
+	return len(t.nodes)
+}
+
+// Node 方法返回指定 ID 的部门节点，不存在时返回 nil
+func (t *DepartmentTree) Node(id int64) *DepartmentNode {
+	return t.nodes[id]
+}
+
+// FindByPath 方法用于按名称路径查找部门，如 总部/研发/后端，路径需从根部门开始，不存在时返回 nil
+func (t *DepartmentTree) FindByPath(path string) *DepartmentNode {
+	names := strings.Split(strings.Trim(path, DepartmentPathSeparator), DepartmentPathSeparator)
+
+	candidates := t.Roots
+	var found *DepartmentNode
+	for _, name := range names {
+		found = nil
+		for _, node := range candidates {
+			if node.Name == name {
+				found = node
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		candidates = found.Children
+	}
+	return found
+}
+
+// Walk 方法按先序遍历部门树，fn 返回错误时停止遍历并返回该错误
+func (t *DepartmentTree) Walk(fn func(node *DepartmentNode) error) error {
+	for _, root := range t.Roots {
+		if err := fn(root); err != nil {
+			return err
+		}
+		for _, node := range root.Descendants() {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetDepartmentTree 方法用于获取以 id 为根的部门树，获取全部部门时 id 为 1
+func (a *API) GetDepartmentTree(id int64) (*DepartmentTree, error) {
+	return a.GetDepartmentTreeContext(context.Background(), id)
+}
+
+// GetDepartmentTreeContext 为 GetDepartmentTree 的 context 版本
+func (a *API) GetDepartmentTreeContext(ctx context.Context, id int64) (*DepartmentTree, error) {
+	departments, err := a.ListDepartmentContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return NewDepartmentTree(departments)
+}
+
+// ListSubtreeUsers 方法用于获取部门及其全部下级部门的成员（成员仅有简单信息），属于多个部门的成员只返回一次
+func (a *API) ListSubtreeUsers(node *DepartmentNode) ([]*User, error) {
+	return a.ListSubtreeUsersContext(context.Background(), node)
+}
+
+// ListSubtreeUsersContext 为 ListSubtreeUsers 的 context 版本
+func (a *API) ListSubtreeUsersContext(ctx context.Context, node *DepartmentNode) ([]*User, error) {
+	seen := make(map[string]bool)
+	var users []*User
+	for _, n := range append([]*DepartmentNode{node}, node.Descendants()...) {
+		list, err := a.ListSimpleUserContext(ctx, n.ID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range list {
+			if !seen[user.UserID] {
+				seen[user.UserID] = true
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
+}
+
+// DepartmentChangeType 为两次部门快照之间的变更类型
+type DepartmentChangeType string
+
+// 部门变更类型，同一部门可能同时有多种变更
+const (
+	DepartmentAdded     DepartmentChangeType = "added"
+	DepartmentRemoved   DepartmentChangeType = "removed"
+	DepartmentRenamed   DepartmentChangeType = "renamed"
+	DepartmentMoved     DepartmentChangeType = "moved"
+	DepartmentReordered DepartmentChangeType = "reordered"
+)
+
+// DepartmentChange 为部门的一项变更，新增时 Old 为 nil，删除时 New 为 nil
+type DepartmentChange struct {
+	Type DepartmentChangeType
+	ID   int64
+	Old  *Department
+	New  *Department
+}
+
+// DiffDepartmentTrees 方法用于比较两次部门快照 from 与 to，按部门 ID 排序返回新增、删除、重命名、移动及排序变化，
+// 可用于检测组织架构调整
+func DiffDepartmentTrees(from, to *DepartmentTree) []DepartmentChange {
+	ids := make(map[int64]bool)
+	for id := range from.nodes {
+		ids[id] = true
+	}
+	for id := range to.nodes {
+		ids[id] = true
+	}
+	sorted := make([]int64, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var changes []DepartmentChange
+	for _, id := range sorted {
+		o, n := from.nodes[id], to.nodes[id]
+		switch {
+		case o == nil:
+			changes = append(changes, DepartmentChange{Type: DepartmentAdded, ID: id, New: n.Department})
+		case n == nil:
+			changes = append(changes, DepartmentChange{Type: DepartmentRemoved, ID: id, Old: o.Department})
+		default:
+			change := DepartmentChange{ID: id, Old: o.Department, New: n.Department}
+			if o.Name != n.Name {
+				change.Type = DepartmentRenamed
+				changes = append(changes, change)
+			}
+			if o.ParentID != n.ParentID {
+				change.Type = DepartmentMoved
+				changes = append(changes, change)
+			}
+			if o.Order != n.Order {
+				change.Type = DepartmentReordered
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
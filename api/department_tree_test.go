@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func testDepartments() []*Department {
+	return []*Department{
+		{ID: 1, Name: "总部", ParentID: 0},
+		{ID: 2, Name: "研发", ParentID: 1, Order: 100},
+		{ID: 3, Name: "市场", ParentID: 1, Order: 200},
+		{ID: 4, Name: "后端", ParentID: 2},
+		{ID: 5, Name: "前端", ParentID: 2},
+	}
+}
+
+func TestDepartmentTree(t *testing.T) {
+	tree, err := NewDepartmentTree(testDepartments())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tree.Len() != 5 || len(tree.Roots) != 1 || tree.Roots[0].ID != 1 {
+		t.Fatalf("Unexpected tree: %+v", tree.Roots)
+	}
+
+	// Order 大的排在前面
+	root := tree.Roots[0]
+	if root.Children[0].Name != "市场" || root.Children[1].Name != "研发" {
+		t.Errorf("Unexpected children order: %s, %s", root.Children[0].Name, root.Children[1].Name)
+	}
+
+	node := tree.FindByPath("总部/研发/后端")
+	if node == nil || node.ID != 4 {
+		t.Fatalf("Unexpected node: %+v", node)
+	}
+	if node.Path() != "总部/研发/后端" || node.Depth() != 2 || len(node.Ancestors()) != 2 {
+		t.Errorf("Unexpected path %s, depth %d", node.Path(), node.Depth())
+	}
+	if tree.FindByPath("总部/研发/测试") != nil || tree.FindByPath("研发") != nil {
+		t.Error("Expected missing path to return nil")
+	}
+
+	var ids []int64
+	tree.Walk(func(n *DepartmentNode) error {
+		ids = append(ids, n.ID)
+		return nil
+	})
+	if !reflect.DeepEqual(ids, []int64{1, 3, 2, 4, 5}) {
+		t.Errorf("Unexpected walk order: %v", ids)
+	}
+}
+
+func TestDepartmentTree_Cycle(t *testing.T) {
+	departments := append(testDepartments(),
+		&Department{ID: 6, Name: "A", ParentID: 8},
+		&Department{ID: 7, Name: "B", ParentID: 6},
+		&Department{ID: 8, Name: "C", ParentID: 7},
+		&Department{ID: 9, Name: "D", ParentID: 7},
+	)
+
+	_, err := NewDepartmentTree(departments)
+	var cycleErr *DepartmentCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("Expected *DepartmentCycleError, got %v", err)
+	}
+	if !reflect.DeepEqual(cycleErr.IDs, []int64{6, 8, 7}) {
+		t.Errorf("Unexpected cycle: %v", cycleErr.IDs)
+	}
+}
+
+func TestDiffDepartmentTrees(t *testing.T) {
+	from, _ := NewDepartmentTree(testDepartments())
+
+	departments := testDepartments()[:4]
+	departments[3] = &Department{ID: 4, Name: "服务端", ParentID: 3}
+	departments[1].Order = 300
+	departments = append(departments, &Department{ID: 6, Name: "测试", ParentID: 2})
+	to, _ := NewDepartmentTree(departments)
+
+	var got []DepartmentChangeType
+	var gotIDs []int64
+	for _, change := range DiffDepartmentTrees(from, to) {
+		got = append(got, change.Type)
+		gotIDs = append(gotIDs, change.ID)
+	}
+
+	want := []DepartmentChangeType{DepartmentReordered, DepartmentRenamed, DepartmentMoved, DepartmentRemoved, DepartmentAdded}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(gotIDs, []int64{2, 4, 4, 5, 6}) {
+		t.Errorf("Unexpected changes: %v %v", got, gotIDs)
+	}
+}
+
+func TestAPI_DepartmentTree(t *testing.T) {
+	var requests []string
+	a := newMockAPI(func(req *http.Request, body string) string {
+		requests = append(requests, req.URL.Path+"?"+req.URL.Query().Get("id")+req.URL.Query().Get("department_id"))
+		switch req.URL.Path {
+		case "/cgi-bin/department/list":
+			return `{"errcode":0,"errmsg":"ok","department":[{"id":1,"name":"root","parentid":0},{"id":2,"name":"研发","parentid":1},{"id":3,"name":"后端","parentid":2}]}`
+		case "/cgi-bin/department/simplelist":
+			return `{"errcode":0,"errmsg":"ok","department_id":[{"id":2,"parentid":1,"order":10},{"id":3,"parentid":2,"order":40}]}`
+		case "/cgi-bin/department/get":
+			return `{"errcode":0,"errmsg":"ok","department":{"id":3,"name":"后端","parentid":2}}`
+		case "/cgi-bin/user/simplelist":
+			if req.URL.Query().Get("department_id") == "2" {
+				return `{"errcode":0,"errmsg":"ok","userlist":[{"userid":"zhangsan","name":"张三","department":[2,3]}]}`
+			}
+			return `{"errcode":0,"errmsg":"ok","userlist":[{"userid":"zhangsan","name":"张三","department":[2,3]},{"userid":"lisi","name":"李四","department":[3]}]}`
+		}
+		return `{"errcode":0,"errmsg":"ok"}`
+	})
+
+	tree, err := a.GetDepartmentTree(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	node := tree.FindByPath("root/研发")
+	if node == nil {
+		t.Fatal("Expected to find root/研发")
+	}
+
+	// 属于多个部门的成员只返回一次
+	users, err := a.ListSubtreeUsers(node)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[0].UserID != "zhangsan" || users[1].UserID != "lisi" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	simple, err := a.ListSimpleDepartment(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(simple) != 2 || simple[1].ParentID != 2 || simple[1].Order != 40 {
+		t.Errorf("Unexpected departments: %+v", simple)
+	}
+
+	department, err := a.GetDepartment(3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if department.Name != "后端" {
+		t.Errorf("Unexpected department: %+v", department)
+	}
+
+	want := []string{"/cgi-bin/department/list?1", "/cgi-bin/user/simplelist?2", "/cgi-bin/user/simplelist?3", "/cgi-bin/department/simplelist?2", "/cgi-bin/department/get?3"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("Unexpected requests: %v", requests)
+	}
+}
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 )
@@ -42,7 +42,12 @@ const (
 
 // GetExternalContact 获取客户详情
 func (a *API) GetExternalContact(externalUserId string) (*ExternalContactResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetExternalContactContext(context.Background(), externalUserId)
+}
+
+// GetExternalContactContext 为 GetExternalContact 的 context 版本
+func (a *API) GetExternalContactContext(ctx context.Context, externalUserId string) (*ExternalContactResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +58,7 @@ func (a *API) GetExternalContact(externalUserId string) (*ExternalContactResp, e
 
 	apiUrl := getExternalContactURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(apiUrl)
+	body, err := a.Client.GetJSONContext(ctx, apiUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +71,12 @@ func (a *API) GetExternalContact(externalUserId string) (*ExternalContactResp, e
 
 // BatchExternalContact 批量获取客户详情
 func (a *API) BatchExternalContact(req *BatchExternalContactReq) (*BatchExternalContactResp, error) {
-	token, err := a.Tokener.Token()
+	return a.BatchExternalContactContext(context.Background(), req)
+}
+
+// BatchExternalContactContext 为 BatchExternalContact 的 context 版本
+func (a *API) BatchExternalContactContext(ctx context.Context, req *BatchExternalContactReq) (*BatchExternalContactResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +87,7 @@ func (a *API) BatchExternalContact(req *BatchExternalContactReq) (*BatchExternal
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -86,15 +96,20 @@ func (a *API) BatchExternalContact(req *BatchExternalContactReq) (*BatchExternal
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result, err
 }
 
 // ListExternalContact 获取客户列表
 func (a *API) ListExternalContact(userid string) ([]string, error) {
-	token, err := a.Tokener.Token()
+	return a.ListExternalContactContext(context.Background(), userid)
+}
+
+// ListExternalContactContext 为 ListExternalContact 的 context 版本
+func (a *API) ListExternalContactContext(ctx context.Context, userid string) ([]string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +120,7 @@ func (a *API) ListExternalContact(userid string) ([]string, error) {
 
 	apiUrl := listExternalContactURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(apiUrl)
+	body, err := a.Client.GetJSONContext(ctx, apiUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +133,12 @@ func (a *API) ListExternalContact(userid string) ([]string, error) {
 
 // AddContactWay 配置客户联系「联系我」方式
 func (a *API) AddContactWay(way *AddContactWayReq) (*AddContactWayResp, error) {
-	token, err := a.Tokener.Token()
+	return a.AddContactWayContext(context.Background(), way)
+}
+
+// AddContactWayContext 为 AddContactWay 的 context 版本
+func (a *API) AddContactWayContext(ctx context.Context, way *AddContactWayReq) (*AddContactWayResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +149,7 @@ func (a *API) AddContactWay(way *AddContactWayReq) (*AddContactWayResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +160,12 @@ func (a *API) AddContactWay(way *AddContactWayReq) (*AddContactWayResp, error) {
 
 // UpdateContactWay 配置客户联系「联系我」方式
 func (a *API) UpdateContactWay(configID string, way *AddContactWayReq) (*AddContactWayResp, error) {
-	token, err := a.Tokener.Token()
+	return a.UpdateContactWayContext(context.Background(), configID, way)
+}
+
+// UpdateContactWayContext 为 UpdateContactWay 的 context 版本
+func (a *API) UpdateContactWayContext(ctx context.Context, configID string, way *AddContactWayReq) (*AddContactWayResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +182,7 @@ func (a *API) UpdateContactWay(configID string, way *AddContactWayReq) (*AddCont
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +193,12 @@ func (a *API) UpdateContactWay(configID string, way *AddContactWayReq) (*AddCont
 
 // GetUserBehaviorData 联系客户统计
 func (a *API) GetUserBehaviorData(req *UserBehaviorDataReq) ([]BehaviorData, error) {
-	token, err := a.Tokener.Token()
+	return a.GetUserBehaviorDataContext(context.Background(), req)
+}
+
+// GetUserBehaviorDataContext 为 GetUserBehaviorData 的 context 版本
+func (a *API) GetUserBehaviorDataContext(ctx context.Context, req *UserBehaviorDataReq) ([]BehaviorData, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +209,7 @@ func (a *API) GetUserBehaviorData(req *UserBehaviorDataReq) ([]BehaviorData, err
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -190,7 +220,12 @@ func (a *API) GetUserBehaviorData(req *UserBehaviorDataReq) ([]BehaviorData, err
 
 // GetGroupChatStatistic 群聊数据统计
 func (a *API) GetGroupChatStatistic(req *GroupChatStatisticReq) (*GroupChatStatisticResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetGroupChatStatisticContext(context.Background(), req)
+}
+
+// GetGroupChatStatisticContext 为 GetGroupChatStatistic 的 context 版本
+func (a *API) GetGroupChatStatisticContext(ctx context.Context, req *GroupChatStatisticReq) (*GroupChatStatisticResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -201,7 +236,7 @@ func (a *API) GetGroupChatStatistic(req *GroupChatStatisticReq) (*GroupChatStati
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +247,12 @@ func (a *API) GetGroupChatStatistic(req *GroupChatStatisticReq) (*GroupChatStati
 
 // ExternalContactRemark 修改客户备注信息
 func (a *API) ExternalContactRemark(req *ExternalContactRemark) error {
-	token, err := a.Tokener.Token()
+	return a.ExternalContactRemarkContext(context.Background(), req)
+}
+
+// ExternalContactRemarkContext 为 ExternalContactRemark 的 context 版本
+func (a *API) ExternalContactRemarkContext(ctx context.Context, req *ExternalContactRemark) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -223,7 +263,7 @@ func (a *API) ExternalContactRemark(req *ExternalContactRemark) error {
 	if err != nil {
 		return err
 	}
-	_, err = a.Client.PostJSON(apiUrl, data)
+	_, err = a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return err
 	}
@@ -232,7 +272,12 @@ func (a *API) ExternalContactRemark(req *ExternalContactRemark) error {
 
 // ExternalGroupChatList 获取客户群列表
 func (a *API) ExternalGroupChatList(req *GroupChatReq) (*GroupChatResp, error) {
-	token, err := a.Tokener.Token()
+	return a.ExternalGroupChatListContext(context.Background(), req)
+}
+
+// ExternalGroupChatListContext 为 ExternalGroupChatList 的 context 版本
+func (a *API) ExternalGroupChatListContext(ctx context.Context, req *GroupChatReq) (*GroupChatResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +288,7 @@ func (a *API) ExternalGroupChatList(req *GroupChatReq) (*GroupChatResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +299,12 @@ func (a *API) ExternalGroupChatList(req *GroupChatReq) (*GroupChatResp, error) {
 
 // GroupChatGetUGet 获取客户群详情
 func (a *API) GroupChatGetUGet(req *GroupChatGetReq) (*GroupChat, error) {
-	token, err := a.Tokener.Token()
+	return a.GroupChatGetUGetContext(context.Background(), req)
+}
+
+// GroupChatGetUGetContext 为 GroupChatGetUGet 的 context 版本
+func (a *API) GroupChatGetUGetContext(ctx context.Context, req *GroupChatGetReq) (*GroupChat, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +315,7 @@ func (a *API) GroupChatGetUGet(req *GroupChatGetReq) (*GroupChat, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -277,15 +327,20 @@ func (a *API) GroupChatGetUGet(req *GroupChatGetReq) (*GroupChat, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result.GroupChat, err
 }
 
 // GetMomentList 获取客户朋友圈发表记录
 func (a *API) GetMomentList(req *MomentListReq) ([]Moment, error) {
-	token, err := a.Tokener.Token()
+	return a.GetMomentListContext(context.Background(), req)
+}
+
+// GetMomentListContext 为 GetMomentList 的 context 版本
+func (a *API) GetMomentListContext(ctx context.Context, req *MomentListReq) ([]Moment, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +351,7 @@ func (a *API) GetMomentList(req *MomentListReq) ([]Moment, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -307,7 +362,12 @@ func (a *API) GetMomentList(req *MomentListReq) ([]Moment, error) {
 
 // GetCorpTagList 获取企业标签库
 func (a *API) GetCorpTagList(req interface{}) ([]TagGroup, error) {
-	token, err := a.Tokener.Token()
+	return a.GetCorpTagListContext(context.Background(), req)
+}
+
+// GetCorpTagListContext 为 GetCorpTagList 的 context 版本
+func (a *API) GetCorpTagListContext(ctx context.Context, req interface{}) ([]TagGroup, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -318,7 +378,7 @@ func (a *API) GetCorpTagList(req interface{}) ([]TagGroup, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -327,14 +387,19 @@ func (a *API) GetCorpTagList(req interface{}) ([]TagGroup, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result.TagGroup, err
 }
 
 func (a *API) AddCorpTag(req *AddTagReq) (*TagGroup, error) {
-	token, err := a.Tokener.Token()
+	return a.AddCorpTagContext(context.Background(), req)
+}
+
+// AddCorpTagContext 为 AddCorpTag 的 context 版本
+func (a *API) AddCorpTagContext(ctx context.Context, req *AddTagReq) (*TagGroup, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +410,7 @@ func (a *API) AddCorpTag(req *AddTagReq) (*TagGroup, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -356,7 +421,12 @@ func (a *API) AddCorpTag(req *AddTagReq) (*TagGroup, error) {
 
 // MarkTag 编辑客户企业标签
 func (a *API) MarkTag(req *MakeTagReq) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.MarkTagContext(context.Background(), req)
+}
+
+// MarkTagContext 为 MarkTag 的 context 版本
+func (a *API) MarkTagContext(ctx context.Context, req *MakeTagReq) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -367,7 +437,7 @@ func (a *API) MarkTag(req *MakeTagReq) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -378,7 +448,12 @@ func (a *API) MarkTag(req *MakeTagReq) (*BaseResp, error) {
 
 // GetGroupmsgList 获取群发记录列表
 func (a *API) GetGroupmsgList(req *GroupmsgListReq) (*GroupMsgListResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetGroupmsgListContext(context.Background(), req)
+}
+
+// GetGroupmsgListContext 为 GetGroupmsgList 的 context 版本
+func (a *API) GetGroupmsgListContext(ctx context.Context, req *GroupmsgListReq) (*GroupMsgListResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -389,7 +464,7 @@ func (a *API) GetGroupmsgList(req *GroupmsgListReq) (*GroupMsgListResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -399,7 +474,12 @@ func (a *API) GetGroupmsgList(req *GroupmsgListReq) (*GroupMsgListResp, error) {
 }
 
 func (a *API) SendWelcomeMsg(req *WelcomeMsg) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.SendWelcomeMsgContext(context.Background(), req)
+}
+
+// SendWelcomeMsgContext 为 SendWelcomeMsg 的 context 版本
+func (a *API) SendWelcomeMsgContext(ctx context.Context, req *WelcomeMsg) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -410,7 +490,7 @@ func (a *API) SendWelcomeMsg(req *WelcomeMsg) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -421,7 +501,12 @@ func (a *API) SendWelcomeMsg(req *WelcomeMsg) (*BaseResp, error) {
 
 // 获取企业已配置的「联系我」列表
 func (a *API) ListContactWay(limit int) (*ContactWayRes, error) {
-	token, err := a.Tokener.Token()
+	return a.ListContactWayContext(context.Background(), limit)
+}
+
+// ListContactWayContext 为 ListContactWay 的 context 版本
+func (a *API) ListContactWayContext(ctx context.Context, limit int) (*ContactWayRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -432,7 +517,7 @@ func (a *API) ListContactWay(limit int) (*ContactWayRes, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -443,7 +528,12 @@ func (a *API) ListContactWay(limit int) (*ContactWayRes, error) {
 
 // 获取企业已配置的「联系我」方式
 func (a *API) GetContactWay(configID string) (*ContactWay, error) {
-	token, err := a.Tokener.Token()
+	return a.GetContactWayContext(context.Background(), configID)
+}
+
+// GetContactWayContext 为 GetContactWay 的 context 版本
+func (a *API) GetContactWayContext(ctx context.Context, configID string) (*ContactWay, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -456,7 +546,7 @@ func (a *API) GetContactWay(configID string) (*ContactWay, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -467,7 +557,12 @@ func (a *API) GetContactWay(configID string) (*ContactWay, error) {
 }
 
 func (a *API) DelContactWay(configID string) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.DelContactWayContext(context.Background(), configID)
+}
+
+// DelContactWayContext 为 DelContactWay 的 context 版本
+func (a *API) DelContactWayContext(ctx context.Context, configID string) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -480,7 +575,7 @@ func (a *API) DelContactWay(configID string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -490,7 +585,12 @@ func (a *API) DelContactWay(configID string) (*BaseResp, error) {
 }
 
 func (a *API) AddMsgTemplate(template *MsgTemplate) (*MsgTemplateRes, error) {
-	token, err := a.Tokener.Token()
+	return a.AddMsgTemplateContext(context.Background(), template)
+}
+
+// AddMsgTemplateContext 为 AddMsgTemplate 的 context 版本
+func (a *API) AddMsgTemplateContext(ctx context.Context, template *MsgTemplate) (*MsgTemplateRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -501,7 +601,7 @@ func (a *API) AddMsgTemplate(template *MsgTemplate) (*MsgTemplateRes, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -511,7 +611,12 @@ func (a *API) AddMsgTemplate(template *MsgTemplate) (*MsgTemplateRes, error) {
 }
 
 func (a *API) RemindGroupmsgSend(msgid string) (*BaseResp, error) {
-	token, err := a.Tokener.Token()
+	return a.RemindGroupmsgSendContext(context.Background(), msgid)
+}
+
+// RemindGroupmsgSendContext 为 RemindGroupmsgSend 的 context 版本
+func (a *API) RemindGroupmsgSendContext(ctx context.Context, msgid string) (*BaseResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -522,7 +627,7 @@ func (a *API) RemindGroupmsgSend(msgid string) (*BaseResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -533,7 +638,12 @@ func (a *API) RemindGroupmsgSend(msgid string) (*BaseResp, error) {
 
 // 创建获客链接
 func (a *API) CreateLink(req *CreateLinkReq) (*CreateLinkResp, error) {
-	token, err := a.Tokener.Token()
+	return a.CreateLinkContext(context.Background(), req)
+}
+
+// CreateLinkContext 为 CreateLink 的 context 版本
+func (a *API) CreateLinkContext(ctx context.Context, req *CreateLinkReq) (*CreateLinkResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -544,7 +654,7 @@ func (a *API) CreateLink(req *CreateLinkReq) (*CreateLinkResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -555,7 +665,12 @@ func (a *API) CreateLink(req *CreateLinkReq) (*CreateLinkResp, error) {
 
 // 创建发表任务
 func (a *API) AddMomentTask(req *MomentTask) (*MomentTaskResp, error) {
-	token, err := a.Tokener.Token()
+	return a.AddMomentTaskContext(context.Background(), req)
+}
+
+// AddMomentTaskContext 为 AddMomentTask 的 context 版本
+func (a *API) AddMomentTaskContext(ctx context.Context, req *MomentTask) (*MomentTaskResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -566,7 +681,7 @@ func (a *API) AddMomentTask(req *MomentTask) (*MomentTaskResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -577,7 +692,12 @@ func (a *API) AddMomentTask(req *MomentTask) (*MomentTaskResp, error) {
 
 // 获取任务创建结果
 func (a *API) GetMomentTaskResult(jobid string) (*GetMomentTaskResultResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetMomentTaskResultContext(context.Background(), jobid)
+}
+
+// GetMomentTaskResultContext 为 GetMomentTaskResult 的 context 版本
+func (a *API) GetMomentTaskResultContext(ctx context.Context, jobid string) (*GetMomentTaskResultResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -585,7 +705,7 @@ func (a *API) GetMomentTaskResult(jobid string) (*GetMomentTaskResultResp, error
 	qs.Add("access_token", token)
 	qs.Add("jobid", jobid)
 	apiUrl := getMomentTaskResultURI + "?" + qs.Encode()
-	body, err := a.Client.GetJSON(apiUrl)
+	body, err := a.Client.GetJSONContext(ctx, apiUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -596,7 +716,12 @@ func (a *API) GetMomentTaskResult(jobid string) (*GetMomentTaskResultResp, error
 }
 
 func (a *API) GetGroupmsgTask(msgid, cursor string) (*GetGroupmsgTaskResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetGroupmsgTaskContext(context.Background(), msgid, cursor)
+}
+
+// GetGroupmsgTaskContext 为 GetGroupmsgTask 的 context 版本
+func (a *API) GetGroupmsgTaskContext(ctx context.Context, msgid, cursor string) (*GetGroupmsgTaskResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -607,7 +732,7 @@ func (a *API) GetGroupmsgTask(msgid, cursor string) (*GetGroupmsgTaskResp, error
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -618,7 +743,12 @@ func (a *API) GetGroupmsgTask(msgid, cursor string) (*GetGroupmsgTaskResp, error
 
 // 获取企业群发成员执行结果
 func (a *API) GetGroupmsgSendResult(req *GroupmsgSendResultReq) (*GroupmsgSendResultResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetGroupmsgSendResultContext(context.Background(), req)
+}
+
+// GetGroupmsgSendResultContext 为 GetGroupmsgSendResult 的 context 版本
+func (a *API) GetGroupmsgSendResultContext(ctx context.Context, req *GroupmsgSendResultReq) (*GroupmsgSendResultResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -629,7 +759,7 @@ func (a *API) GetGroupmsgSendResult(req *GroupmsgSendResultReq) (*GroupmsgSendRe
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -640,7 +770,12 @@ func (a *API) GetGroupmsgSendResult(req *GroupmsgSendResultReq) (*GroupmsgSendRe
 
 // 获取客户朋友圈的互动数据
 func (a *API) GetMomentComments(momentId, userid string) (*MomentCommentsRes, error) {
-	token, err := a.Tokener.Token()
+	return a.GetMomentCommentsContext(context.Background(), momentId, userid)
+}
+
+// GetMomentCommentsContext 为 GetMomentComments 的 context 版本
+func (a *API) GetMomentCommentsContext(ctx context.Context, momentId, userid string) (*MomentCommentsRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -654,7 +789,7 @@ func (a *API) GetMomentComments(momentId, userid string) (*MomentCommentsRes, er
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
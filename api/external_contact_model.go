@@ -1,9 +1,9 @@
 package api
 
-type BaseResp struct {
-	Errcode int    `json:"errcode"`
-	Errmsg  string `json:"errmsg"`
-}
+import "github.com/shengbox/wechat-qy/base"
+
+// BaseResp 为接口响应的公共字段
+type BaseResp = base.BaseResp
 
 type ExternalContact struct {
 	Avatar          string         `json:"avatar"`
@@ -296,8 +296,7 @@ type GroupmsgListReq struct {
 // Generated by https://quicktype.io
 
 type GroupMsgListResp struct {
-	Errcode      int64          `json:"errcode"`
-	Errmsg       string         `json:"errmsg"`
+	BaseResp
 	NextCursor   string         `json:"next_cursor"`
 	GroupMsgList []GroupMsgList `json:"group_msg_list"`
 }
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 )
@@ -63,7 +63,12 @@ type MiniprogramInfo struct {
 
 // 获取对外收款记录
 func (a *API) GetBillListURI(req *GetBillListReq) (*GetBillListResp, error) {
-	token, err := a.Tokener.Token()
+	return a.GetBillListURIContext(context.Background(), req)
+}
+
+// GetBillListURIContext 为 GetBillListURI 的 context 版本
+func (a *API) GetBillListURIContext(ctx context.Context, req *GetBillListReq) (*GetBillListResp, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +81,7 @@ func (a *API) GetBillListURI(req *GetBillListReq) (*GetBillListResp, error) {
 		return nil, err
 	}
 	fmt.Println(string(data))
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
@@ -86,8 +91,8 @@ func (a *API) GetBillListURI(req *GetBillListReq) (*GetBillListResp, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -1,13 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 )
 
 // PostJSON 发送 POST 请求并自动填充 access_token，且支持可选的参数和响应结构解析
 func (a *API) PostJSON(uri string, query url.Values, body, result interface{}) error {
-	token, err := a.Tokener.Token()
+	return a.PostJSONContext(context.Background(), uri, query, body, result)
+}
+
+// PostJSONContext 为 PostJSON 的 context 版本
+func (a *API) PostJSONContext(ctx context.Context, uri string, query url.Values, body, result interface{}) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -26,7 +32,7 @@ func (a *API) PostJSON(uri string, query url.Values, body, result interface{}) e
 		}
 	}
 
-	resp, err := a.Client.PostJSON(reqURL, data)
+	resp, err := a.Client.PostJSONContext(ctx, reqURL, data)
 	if err != nil {
 		return err
 	}
@@ -39,7 +45,12 @@ func (a *API) PostJSON(uri string, query url.Values, body, result interface{}) e
 
 // GetJSON 发送 GET 请求并自动填充 access_token，并解析响应结果
 func (a *API) GetJSON(uri string, query url.Values, result interface{}) error {
-	token, err := a.Tokener.Token()
+	return a.GetJSONContext(context.Background(), uri, query, result)
+}
+
+// GetJSONContext 为 GetJSON 的 context 版本
+func (a *API) GetJSONContext(ctx context.Context, uri string, query url.Values, result interface{}) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -50,7 +61,7 @@ func (a *API) GetJSON(uri string, query url.Values, result interface{}) error {
 	query.Set("access_token", token)
 	reqURL := uri + "?" + query.Encode()
 
-	resp, err := a.Client.GetJSON(reqURL)
+	resp, err := a.Client.GetJSONContext(ctx, reqURL)
 	if err != nil {
 		return err
 	}
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/url"
 )
 
@@ -16,7 +16,12 @@ const (
 
 // 新建敏感词规则
 func (a *API) AddInterceptRule(req *InterceptRule) (any, error) {
-	token, err := a.Tokener.Token()
+	return a.AddInterceptRuleContext(context.Background(), req)
+}
+
+// AddInterceptRuleContext 为 AddInterceptRule 的 context 版本
+func (a *API) AddInterceptRuleContext(ctx context.Context, req *InterceptRule) (any, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +32,7 @@ func (a *API) AddInterceptRule(req *InterceptRule) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +46,12 @@ func (a *API) AddInterceptRule(req *InterceptRule) (any, error) {
 
 // 编辑敏感词规则
 func (a *API) EditInterceptRule(ruleID string, rule *InterceptRule) (any, error) {
-	token, err := a.Tokener.Token()
+	return a.EditInterceptRuleContext(context.Background(), ruleID, rule)
+}
+
+// EditInterceptRuleContext 为 EditInterceptRule 的 context 版本
+func (a *API) EditInterceptRuleContext(ctx context.Context, ruleID string, rule *InterceptRule) (any, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +69,7 @@ func (a *API) EditInterceptRule(ruleID string, rule *InterceptRule) (any, error)
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -70,28 +80,38 @@ func (a *API) EditInterceptRule(ruleID string, rule *InterceptRule) (any, error)
 
 // 获取敏感词规则列表
 func (a *API) GetInterceptRuleList() ([]RuleList, error) {
-	token, err := a.Tokener.Token()
+	return a.GetInterceptRuleListContext(context.Background())
+}
+
+// GetInterceptRuleListContext 为 GetInterceptRuleList 的 context 版本
+func (a *API) GetInterceptRuleListContext(ctx context.Context) ([]RuleList, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	qs := make(url.Values)
 	qs.Add("access_token", token)
 	apiUrl := getInterceptRuleListURI + "?" + qs.Encode()
-	body, err := a.Client.GetJSON(apiUrl)
+	body, err := a.Client.GetJSONContext(ctx, apiUrl)
 	if err != nil {
 		return nil, err
 	}
 	var result RuleListRes
 	err = json.Unmarshal(body, &result)
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result.RuleList, err
 }
 
 // 获取敏感词规则详情
 func (a *API) GetInterceptRule(ruleID string) (*InterceptRule, error) {
-	token, err := a.Tokener.Token()
+	return a.GetInterceptRuleContext(context.Background(), ruleID)
+}
+
+// GetInterceptRuleContext 为 GetInterceptRule 的 context 版本
+func (a *API) GetInterceptRuleContext(ctx context.Context, ruleID string) (*InterceptRule, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +122,7 @@ func (a *API) GetInterceptRule(ruleID string) (*InterceptRule, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -111,15 +131,20 @@ func (a *API) GetInterceptRule(ruleID string) (*InterceptRule, error) {
 		Rule     InterceptRule `json:"rule"`
 	}
 	err = json.Unmarshal(body, &result)
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result.Rule, err
 }
 
 // 删除敏感词规则
 func (a *API) DelInterceptRule(ruleID string) error {
-	token, err := a.Tokener.Token()
+	return a.DelInterceptRuleContext(context.Background(), ruleID)
+}
+
+// DelInterceptRuleContext 为 DelInterceptRule 的 context 版本
+func (a *API) DelInterceptRuleContext(ctx context.Context, ruleID string) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -130,14 +155,14 @@ func (a *API) DelInterceptRule(ruleID string) error {
 	if err != nil {
 		return err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return err
 	}
 	var result BaseResp
 	err = json.Unmarshal(body, &result)
-	if result.Errcode != 0 {
-		return errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return err
 	}
 	return err
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
@@ -18,7 +19,12 @@ const (
 )
 
 func (a *API) GetSDKAgentTicket() (*base.Ticket, error) {
-	token, err := a.Tokener.Token()
+	return a.GetSDKAgentTicketContext(context.Background())
+}
+
+// GetSDKAgentTicketContext 为 GetSDKAgentTicket 的 context 版本
+func (a *API) GetSDKAgentTicketContext(ctx context.Context) (*base.Ticket, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +33,7 @@ func (a *API) GetSDKAgentTicket() (*base.Ticket, error) {
 	qs.Add("type", "agent_config")
 	ticketURL := jsSDKAgentTicketURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(ticketURL)
+	body, err := a.Client.GetJSONContext(ctx, ticketURL)
 	if err != nil {
 		return nil, err
 	}
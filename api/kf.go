@@ -1,7 +1,7 @@
 package api
 
 import (
-	"errors"
+	"context"
 )
 
 const (
@@ -60,13 +60,18 @@ type KfEvent struct {
 }
 
 func (a *API) SyncMsg(req SyncMsgReq) (*SyncMsgResp, error) {
+	return a.SyncMsgContext(context.Background(), req)
+}
+
+// SyncMsgContext 为 SyncMsg 的 context 版本
+func (a *API) SyncMsgContext(ctx context.Context, req SyncMsgReq) (*SyncMsgResp, error) {
 	result := &SyncMsgResp{}
-	err := a.PostJSON(syncMsgURI, nil, req, result)
+	err := a.PostJSONContext(ctx, syncMsgURI, nil, req, result)
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -85,13 +90,18 @@ type SendResp struct {
 }
 
 func (a *API) SendMsg(req SendReq) (*SendResp, error) {
+	return a.SendMsgContext(context.Background(), req)
+}
+
+// SendMsgContext 为 SendMsg 的 context 版本
+func (a *API) SendMsgContext(ctx context.Context, req SendReq) (*SendResp, error) {
 	result := &SendResp{}
-	err := a.PostJSON(sendMsgURI, nil, req, result)
+	err := a.PostJSONContext(ctx, sendMsgURI, nil, req, result)
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode != 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/url"
 )
 
@@ -67,7 +67,12 @@ type LivingExternalUser struct {
 
 // 创建预约直播
 func (a *API) LivingCreate(req *LiveCreateReq) (*string, error) {
-	token, err := a.Tokener.Token()
+	return a.LivingCreateContext(context.Background(), req)
+}
+
+// LivingCreateContext 为 LivingCreate 的 context 版本
+func (a *API) LivingCreateContext(ctx context.Context, req *LiveCreateReq) (*string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +83,7 @@ func (a *API) LivingCreate(req *LiveCreateReq) (*string, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +97,12 @@ func (a *API) LivingCreate(req *LiveCreateReq) (*string, error) {
 
 // 获取直播观看明细
 func (a *API) GetWatchStat(livingid, nextKey string) (*StatInfo, error) {
-	token, err := a.Tokener.Token()
+	return a.GetWatchStatContext(context.Background(), livingid, nextKey)
+}
+
+// GetWatchStatContext 为 GetWatchStat 的 context 版本
+func (a *API) GetWatchStatContext(ctx context.Context, livingid, nextKey string) (*StatInfo, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +117,7 @@ func (a *API) GetWatchStat(livingid, nextKey string) (*StatInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +133,12 @@ func (a *API) GetWatchStat(livingid, nextKey string) (*StatInfo, error) {
 
 // 获取成员直播ID列表
 func (a *API) GetUserAllLivingid(userid, cursor string) (any, error) {
-	token, err := a.Tokener.Token()
+	return a.GetUserAllLivingidContext(context.Background(), userid, cursor)
+}
+
+// GetUserAllLivingidContext 为 GetUserAllLivingid 的 context 版本
+func (a *API) GetUserAllLivingidContext(ctx context.Context, userid, cursor string) (any, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +153,7 @@ func (a *API) GetUserAllLivingid(userid, cursor string) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +168,12 @@ func (a *API) GetUserAllLivingid(userid, cursor string) (any, error) {
 
 // 在微信中观看直播或直播回放
 func (a *API) GetLivingCode(livingid, openid string) (*string, error) {
-	token, err := a.Tokener.Token()
+	return a.GetLivingCodeContext(context.Background(), livingid, openid)
+}
+
+// GetLivingCodeContext 为 GetLivingCode 的 context 版本
+func (a *API) GetLivingCodeContext(ctx context.Context, livingid, openid string) (*string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +185,7 @@ func (a *API) GetLivingCode(livingid, openid string) (*string, error) {
 	if err != nil {
 		return nil, err
 	}
-	body, err := a.Client.PostJSON(apiUrl, data)
+	body, err := a.Client.PostJSONContext(ctx, apiUrl, data)
 	if err != nil {
 		return nil, err
 	}
@@ -177,15 +197,20 @@ func (a *API) GetLivingCode(livingid, openid string) (*string, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result.LivingCode, err
 }
 
 // 获取直播详情
 func (a *API) GetLivingInfo(livingid string) (*LivingInfo, error) {
-	token, err := a.Tokener.Token()
+	return a.GetLivingInfoContext(context.Background(), livingid)
+}
+
+// GetLivingInfoContext 为 GetLivingInfo 的 context 版本
+func (a *API) GetLivingInfoContext(ctx context.Context, livingid string) (*LivingInfo, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +218,7 @@ func (a *API) GetLivingInfo(livingid string) (*LivingInfo, error) {
 	qs.Add("access_token", token)
 	qs.Add("livingid", livingid)
 	apiUrl := getLivingInfoURI + "?" + qs.Encode()
-	body, err := a.Client.GetJSON(apiUrl)
+	body, err := a.Client.GetJSONContext(ctx, apiUrl)
 	if err != nil {
 		return nil, err
 	}
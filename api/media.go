@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -35,8 +36,13 @@ type UploadedMedia struct {
 
 // UploadAttachment 上传附件资源 素材上传得到media_id，该media_id仅三天内有效
 func (a *API) UploadAttachment(mediaType mediaType, attachmentType, filename string, reader io.Reader) (UploadedMedia, error) {
+	return a.UploadAttachmentContext(context.Background(), mediaType, attachmentType, filename, reader)
+}
+
+// UploadAttachmentContext 为 UploadAttachment 的 context 版本
+func (a *API) UploadAttachmentContext(ctx context.Context, mediaType mediaType, attachmentType, filename string, reader io.Reader) (UploadedMedia, error) {
 	media := UploadedMedia{}
-	token, err := a.Tokener.Token()
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return media, err
 	}
@@ -47,7 +53,7 @@ func (a *API) UploadAttachment(mediaType mediaType, attachmentType, filename str
 	qs.Add("attachment_type", attachmentType)
 	url := uploadAttachmentURI + "?" + qs.Encode()
 
-	body, err := a.Client.PostMultipart(url, "media", filename, reader)
+	body, err := a.Client.PostMultipartContext(ctx, url, "media", filename, reader)
 	if err != nil {
 		return media, err
 	}
@@ -57,8 +63,13 @@ func (a *API) UploadAttachment(mediaType mediaType, attachmentType, filename str
 
 // UploadMedia 方法用于将媒体文件上传至微信服务器
 func (a *API) UploadMedia(mediaType mediaType, filename string, reader io.Reader) (UploadedMedia, error) {
+	return a.UploadMediaContext(context.Background(), mediaType, filename, reader)
+}
+
+// UploadMediaContext 为 UploadMedia 的 context 版本
+func (a *API) UploadMediaContext(ctx context.Context, mediaType mediaType, filename string, reader io.Reader) (UploadedMedia, error) {
 	media := UploadedMedia{}
-	token, err := a.Tokener.Token()
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return media, err
 	}
@@ -67,7 +78,7 @@ func (a *API) UploadMedia(mediaType mediaType, filename string, reader io.Reader
 	qs.Add("type", string(mediaType))
 	url := uploadMediaURI + "?" + qs.Encode()
 
-	body, err := a.Client.PostMultipart(url, "media", filename, reader)
+	body, err := a.Client.PostMultipartContext(ctx, url, "media", filename, reader)
 	if err != nil {
 		return media, err
 	}
@@ -77,7 +88,12 @@ func (a *API) UploadMedia(mediaType mediaType, filename string, reader io.Reader
 
 // DownloadMedia 方法用于从微信服务器获取媒体文件，文件流将写入 writer 中，并返回 filename 或者 error 信息
 func (a *API) DownloadMedia(mediaID string, writer io.Writer) (string, error) {
-	token, err := a.Tokener.Token()
+	return a.DownloadMediaContext(context.Background(), mediaID, writer)
+}
+
+// DownloadMediaContext 为 DownloadMedia 的 context 版本
+func (a *API) DownloadMediaContext(ctx context.Context, mediaID string, writer io.Writer) (string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -85,7 +101,7 @@ func (a *API) DownloadMedia(mediaID string, writer io.Writer) (string, error) {
 	qs.Add("access_token", token)
 	qs.Add("media_id", mediaID)
 	url := downloadMediaURI + "?" + qs.Encode()
-	resp, err := a.Client.GetMedia(url)
+	resp, err := a.Client.GetMediaContext(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -109,14 +125,19 @@ type UploadImageRes struct {
 
 // 上传图片
 func (a *API) UploadImg(filename string, reader io.Reader) (*UploadImageRes, error) {
-	token, err := a.Tokener.Token()
+	return a.UploadImgContext(context.Background(), filename, reader)
+}
+
+// UploadImgContext 为 UploadImg 的 context 版本
+func (a *API) UploadImgContext(ctx context.Context, filename string, reader io.Reader) (*UploadImageRes, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	qs := make(url.Values)
 	qs.Add("access_token", token)
 	url := uploadImgURI + "?" + qs.Encode()
-	body, err := a.Client.PostMultipart(url, "media", filename, reader)
+	body, err := a.Client.PostMultipartContext(ctx, url, "media", filename, reader)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -40,7 +41,12 @@ type Menu struct {
 
 // CreateMenu 方法用于创建某个应用的菜单
 func (a *API) CreateMenu(agentID int64, menu Menu) error {
-	token, err := a.Tokener.Token()
+	return a.CreateMenuContext(context.Background(), agentID, menu)
+}
+
+// CreateMenuContext 为 CreateMenu 的 context 版本
+func (a *API) CreateMenuContext(ctx context.Context, agentID int64, menu Menu) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -55,14 +61,19 @@ func (a *API) CreateMenu(agentID int64, menu Menu) error {
 		return err
 	}
 
-	_, err = a.Client.PostJSON(url, data)
+	_, err = a.Client.PostJSONContext(ctx, url, data)
 
 	return err
 }
 
 // DeleteMenu 方法用于删除某个应用的菜单
 func (a *API) DeleteMenu(agentID int64) error {
-	token, err := a.Tokener.Token()
+	return a.DeleteMenuContext(context.Background(), agentID)
+}
+
+// DeleteMenuContext 为 DeleteMenu 的 context 版本
+func (a *API) DeleteMenuContext(ctx context.Context, agentID int64) error {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -73,16 +84,21 @@ func (a *API) DeleteMenu(agentID int64) error {
 
 	url := deleteMenuURI + "?" + qs.Encode()
 
-	_, err = a.Client.GetJSON(url)
+	_, err = a.Client.GetJSONContext(ctx, url)
 
 	return err
 }
 
 // GetMenu 方法用于获取某个应用的菜单
 func (a *API) GetMenu(agentID int64) (Menu, error) {
+	return a.GetMenuContext(context.Background(), agentID)
+}
+
+// GetMenuContext 为 GetMenu 的 context 版本
+func (a *API) GetMenuContext(ctx context.Context, agentID int64) (Menu, error) {
 	var menu Menu
 
-	token, err := a.Tokener.Token()
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return menu, err
 	}
@@ -93,7 +109,7 @@ func (a *API) GetMenu(agentID int64) (Menu, error) {
 
 	url := getMenuURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(url)
+	body, err := a.Client.GetJSONContext(ctx, url)
 	if err != nil {
 		return menu, err
 	}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -31,7 +32,12 @@ func (a *API) GetOAuth2AuthorizeURI(redirectURI, state string) string {
 
 // GetOAuth2User 方法用于获取 OAuth2 方式验证登录后的用户信息
 func (a *API) GetOAuth2User(agentID int64, code string) (OAuth2UserInfo, error) {
-	token, err := a.Tokener.Token()
+	return a.GetOAuth2UserContext(context.Background(), agentID, code)
+}
+
+// GetOAuth2UserContext 为 GetOAuth2User 的 context 版本
+func (a *API) GetOAuth2UserContext(ctx context.Context, agentID int64, code string) (OAuth2UserInfo, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return OAuth2UserInfo{}, err
 	}
@@ -43,7 +49,7 @@ func (a *API) GetOAuth2User(agentID int64, code string) (OAuth2UserInfo, error)
 
 	url := oauth2GetUserURI + "?" + qs.Encode()
 
-	body, err := a.Client.GetJSON(url)
+	body, err := a.Client.GetJSONContext(ctx, url)
 	if err != nil {
 		return OAuth2UserInfo{}, err
 	}
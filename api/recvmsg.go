@@ -3,10 +3,14 @@ package api
 import (
 	"encoding/xml"
 	"fmt"
+	"time"
 
 	"github.com/shengbox/wechat-qy/base"
 )
 
+// UpdateButtonMsg 为被动响应中更新模板卡片按钮的消息类型
+const UpdateButtonMsg MessageType = "update_button"
+
 // 接收事件类型
 const (
 	SubscribeEvent       = "subscribe"
@@ -22,6 +26,7 @@ const (
 	LocationSelectEvent  = "location_select"
 	EnterAgentEvent      = "enter_agent"
 	BatchJobResultEvent  = "batch_job_result"
+	TemplateCardEvent    = "template_card_event"
 
 	ChangeExternalContactEvent = "change_external_contact"
 	MsgAuditNotifyEvent        = "msgaudit_notify"
@@ -192,6 +197,29 @@ type RecvBatchJobResultEvent struct {
 	BatchJob JobResultInfo
 }
 
+// TemplateCardOptionIDs 描述模板卡片事件中选中的选项 id 列表
+type TemplateCardOptionIDs struct {
+	OptionID []string `xml:"OptionId"`
+}
+
+// TemplateCardSelectedItem 描述模板卡片事件中单个问题的选择结果
+type TemplateCardSelectedItem struct {
+	QuestionKey string
+	OptionIDs   TemplateCardOptionIDs `xml:"OptionIds"`
+}
+
+// RecvTemplateCardEvent 描述模板卡片按钮点击或投票、选择提交事件的结构，
+// ResponseCode 可用于被动响应 update_button 或调用 UpdateTemplateCardMessage 更新卡片
+type RecvTemplateCardEvent struct {
+	RecvBaseData
+	Event         string
+	EventKey      string
+	TaskID        string `xml:"TaskId"`
+	CardType      TemplateCardType
+	ResponseCode  string
+	SelectedItems []TemplateCardSelectedItem `xml:"SelectedItems>SelectedItem"`
+}
+
 type RecChangeExternalContactEvent struct {
 	RecvBaseData
 	Event          string
@@ -266,6 +294,102 @@ type RespNewsMessage struct {
 	Articles     []RespArticleItem
 }
 
+// RespButton 描述被动响应中模板卡片按钮的替换文案
+type RespButton struct {
+	ReplaceName base.CDATAText
+}
+
+// RespUpdateButtonMessage 描述被动响应的更新模板卡片按钮消息结构，用于将点击后的按钮更新为不可点击状态
+type RespUpdateButtonMessage struct {
+	RespBaseData
+	Button RespButton
+}
+
+// newRespBaseData 方法用于根据接收的消息生成被动响应的公共结构，收发双方与接收的消息相反
+func newRespBaseData(msg RecvBaseData, msgType MessageType) RespBaseData {
+	return RespBaseData{
+		ToUserName:   base.StringToCDATA(msg.FromUserName),
+		FromUserName: base.StringToCDATA(msg.ToUserName),
+		CreateTime:   int(time.Now().Unix()),
+		MsgType:      base.StringToCDATA(string(msgType)),
+	}
+}
+
+// ReplyText 方法用于创建回复 msg 的文本消息
+func ReplyText(msg RecvBaseData, content string) *RespTextMessage {
+	return &RespTextMessage{
+		RespBaseData: newRespBaseData(msg, TextMsg),
+		Content:      base.StringToCDATA(content),
+	}
+}
+
+// ReplyImage 方法用于创建回复 msg 的图片消息
+func ReplyImage(msg RecvBaseData, mediaID string) *RespImageMessage {
+	return &RespImageMessage{
+		RespBaseData: newRespBaseData(msg, ImageMsg),
+		Image:        RespMedia{MediaID: base.StringToCDATA(mediaID)},
+	}
+}
+
+// ReplyVoice 方法用于创建回复 msg 的语音消息
+func ReplyVoice(msg RecvBaseData, mediaID string) *RespVoiceMessage {
+	return &RespVoiceMessage{
+		RespBaseData: newRespBaseData(msg, VoiceMsg),
+		Voice:        RespMedia{MediaID: base.StringToCDATA(mediaID)},
+	}
+}
+
+// ReplyVideo 方法用于创建回复 msg 的视频消息
+func ReplyVideo(msg RecvBaseData, mediaID, title, description string) *RespVideoMessage {
+	return &RespVideoMessage{
+		RespBaseData: newRespBaseData(msg, VideoMsg),
+		Video: RespVideoMedia{
+			MediaID:     base.StringToCDATA(mediaID),
+			Title:       base.StringToCDATA(title),
+			Description: base.StringToCDATA(description),
+		},
+	}
+}
+
+// ReplyNews 方法用于创建回复 msg 的图文消息
+func ReplyNews(msg RecvBaseData, articles ...Article) *RespNewsMessage {
+	items := make([]RespArticleItem, 0, len(articles))
+	for _, article := range articles {
+		items = append(items, RespArticleItem{Item: RespArticle{
+			Title:       base.StringToCDATA(article.Title),
+			Description: base.StringToCDATA(article.Description),
+			PicURL:      base.StringToCDATA(article.PicURL),
+			URL:         base.StringToCDATA(article.URL),
+		}})
+	}
+	return &RespNewsMessage{
+		RespBaseData: newRespBaseData(msg, NewsMsg),
+		ArticleCount: len(items),
+		Articles:     items,
+	}
+}
+
+// ReplyUpdateButton 方法用于创建回复模板卡片事件的更新按钮消息，将所有用户卡片上的按钮替换为 replaceName 且不可点击；
+// 如需更新卡片的其他内容，可使用事件中的 ResponseCode 调用 UpdateTemplateCardMessage
+func ReplyUpdateButton(msg RecvBaseData, replaceName string) *RespUpdateButtonMessage {
+	return &RespUpdateButtonMessage{
+		RespBaseData: newRespBaseData(msg, UpdateButtonMsg),
+		Button:       RespButton{ReplaceName: base.StringToCDATA(replaceName)},
+	}
+}
+
+// marshalReply 方法用于序列化被动响应消息，已序列化的 []byte 或 string 原样返回
+func marshalReply(reply Reply) ([]byte, error) {
+	switch v := reply.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return xml.Marshal(v)
+	}
+}
+
 type RecvChangeExternalChat struct {
 	RespBaseData
 	Event        string
@@ -282,10 +406,36 @@ type recvMsgHandler struct {
 }
 
 func (h *recvMsgHandler) Parse(body []byte, signature, timestamp, nonce string) (interface{}, error) {
-	var err error
+	origData, err := h.decrypt(body, signature, timestamp, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	probeData, err := probeRecvData(origData)
+	if err != nil {
+		return nil, err
+	}
+
+	data := newRecvData(probeData)
+	if data == nil {
+		if probeData.MsgType == EventMsg {
+			base.GetLogger().Println("origData=", string(origData))
+			return origData, fmt.Errorf("unknown event type: %s", probeData.Event)
+		}
+		return nil, fmt.Errorf("unknown message type: %s", probeData.MsgType)
+	}
+
+	if err = xml.Unmarshal(origData, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
 
+// decrypt 方法用于校验回调消息的签名并解密，返回消息的明文 XML
+func (h *recvMsgHandler) decrypt(body []byte, signature, timestamp, nonce string) ([]byte, error) {
 	reqBody := &base.RecvHTTPReqBody{}
-	if err = xml.Unmarshal(body, reqBody); err != nil {
+	if err := xml.Unmarshal(body, reqBody); err != nil {
 		return nil, err
 	}
 
@@ -302,66 +452,86 @@ func (h *recvMsgHandler) Parse(body []byte, signature, timestamp, nonce string)
 		return nil, fmt.Errorf("the request is from corp[%s], not from corp[%s]", corpID, h.api.CorpID)
 	}
 
-	probeData := &struct {
-		MsgType MessageType
-		Event   string
-	}{}
+	return origData, nil
+}
 
-	if err = xml.Unmarshal(origData, probeData); err != nil {
+// recvProbeData 为解析消息具体类型前探测得到的类型信息
+type recvProbeData struct {
+	MsgType    MessageType
+	Event      string
+	ChangeType string
+}
+
+func probeRecvData(origData []byte) (*recvProbeData, error) {
+	probeData := &recvProbeData{}
+	if err := xml.Unmarshal(origData, probeData); err != nil {
 		return nil, err
 	}
+	return probeData, nil
+}
 
-	var data interface{}
+// newRecvData 用于根据消息类型创建对应的消息结构，未知的类型返回 nil
+func newRecvData(probeData *recvProbeData) interface{} {
 	switch probeData.MsgType {
 	case TextMsg:
-		data = &RecvTextMessage{}
+		return &RecvTextMessage{}
 	case ImageMsg:
-		data = &RecvImageMessage{}
+		return &RecvImageMessage{}
 	case VoiceMsg:
-		data = &RecvVoiceMessage{}
+		return &RecvVoiceMessage{}
 	case VideoMsg:
-		data = &RecvVideoMessage{}
+		return &RecvVideoMessage{}
 	case LocationMsg:
-		data = &RecvLocationMessage{}
+		return &RecvLocationMessage{}
 	case EventMsg:
 		switch probeData.Event {
 		case SubscribeEvent, UnsubscribeEvent:
-			data = &RecvSubscribeEvent{}
+			return &RecvSubscribeEvent{}
 		case LocationEvent:
-			data = &RecvLocationEvent{}
+			return &RecvLocationEvent{}
 		case MenuClickEvent, MenuViewEvent:
-			data = &RecvMenuEvent{}
+			return &RecvMenuEvent{}
 		case ScanCodePushEvent, ScanCodeWaitMsgEvent:
-			data = &RecvScanCodeEvent{}
+			return &RecvScanCodeEvent{}
 		case PicSysPhotoEvent, PicPhotoOrAlbumEvent, PicWeiXinEvent:
-			data = &RecvPicEvent{}
+			return &RecvPicEvent{}
 		case LocationSelectEvent:
-			data = &RecvLocationSelectEvent{}
+			return &RecvLocationSelectEvent{}
 		case EnterAgentEvent:
-			data = &RecvEnterAgentEvent{}
+			return &RecvEnterAgentEvent{}
 		case BatchJobResultEvent:
-			data = &RecvBatchJobResultEvent{}
+			return &RecvBatchJobResultEvent{}
+		case TemplateCardEvent:
+			return &RecvTemplateCardEvent{}
 		case ChangeExternalContactEvent:
-			data = &RecChangeExternalContactEvent{}
+			return &RecChangeExternalContactEvent{}
 		case MsgAuditNotifyEvent:
-			data = &RecMsgAuditNotifyEvent{}
+			return &RecMsgAuditNotifyEvent{}
 		case ChangeContactEvent:
-			data = &RecChangeContactEvent{}
+			return &RecChangeContactEvent{}
 		case ChangeExternalChatEvent:
-			data = &RecvChangeExternalChat{}
-		default:
-			base.GetLogger().Println("origData=", string(origData))
-			return origData, fmt.Errorf("unknown event type: %s", probeData.Event)
+			return &RecvChangeExternalChat{}
 		}
-	default:
-		return nil, fmt.Errorf("unknown message type: %s", probeData.MsgType)
 	}
+	return nil
+}
 
-	if err = xml.Unmarshal(origData, data); err != nil {
-		return nil, err
+// VerifyURL 方法用于验证回调 URL，校验签名并解密 echostr，返回需要原样响应的明文
+func (h *recvMsgHandler) VerifyURL(signature, timestamp, nonce, echostr string) (string, error) {
+	if signature != h.api.MsgCrypter.GetSignature(timestamp, nonce, echostr) {
+		return "", fmt.Errorf("validate signature error")
 	}
 
-	return data, nil
+	echo, corpID, err := h.api.MsgCrypter.Decrypt(echostr)
+	if err != nil {
+		return "", err
+	}
+
+	if corpID != h.api.CorpID {
+		return "", fmt.Errorf("the request is from corp[%s], not from corp[%s]", corpID, h.api.CorpID)
+	}
+
+	return string(echo), nil
 }
 
 func (h *recvMsgHandler) Response(message []byte) ([]byte, error) {
@@ -384,7 +554,21 @@ func (h *recvMsgHandler) Response(message []byte) ([]byte, error) {
 	return xml.MarshalIndent(resp, " ", "  ")
 }
 
+// ResponseReply 方法用于序列化并加密被动响应消息，reply 可以是 ReplyText 等方法创建的消息
+func (h *recvMsgHandler) ResponseReply(reply Reply) ([]byte, error) {
+	message, err := marshalReply(reply)
+	if err != nil {
+		return nil, err
+	}
+	return h.Response(message)
+}
+
 // NewRecvMsgHandler 方法用于创建消息接收处理器的实例
 func (a *API) NewRecvMsgHandler() *recvMsgHandler {
 	return &recvMsgHandler{a}
 }
+
+// NewCallbackHandler 方法用于创建回调地址的 http.Handler，同一路径下处理 URL 验证与消息接收
+func (a *API) NewCallbackHandler(onMessage base.RecvMessageFunc) *base.CallbackHandler {
+	return base.NewCallbackHandler(a.NewRecvMsgHandler(), onMessage)
+}
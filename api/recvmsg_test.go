@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+const (
+	testToken          = "mockToken"
+	testEncodingAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+)
+
+var _ base.CallbackReceiver = (*recvMsgHandler)(nil)
+
+func signedQuery(a *API, encrypt string) url.Values {
+	timestamp, nonce := "1409659813", "1372623149"
+	qs := make(url.Values)
+	qs.Set("msg_signature", a.MsgCrypter.GetSignature(timestamp, nonce, encrypt))
+	qs.Set("timestamp", timestamp)
+	qs.Set("nonce", nonce)
+	return qs
+}
+
+func TestRecvMsgHandler_VerifyURL(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	h := a.NewRecvMsgHandler()
+
+	echostr, err := a.MsgCrypter.Encrypt("echo-12345")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	qs := signedQuery(a, echostr)
+
+	echo, err := h.VerifyURL(qs.Get("msg_signature"), qs.Get("timestamp"), qs.Get("nonce"), echostr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if echo != "echo-12345" {
+		t.Errorf("Expected echo-12345, got %s", echo)
+	}
+
+	if _, err = h.VerifyURL("bad-signature", qs.Get("timestamp"), qs.Get("nonce"), echostr); err == nil {
+		t.Error("Expected signature error, got nil")
+	}
+
+	other := New("otherCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	if _, err = other.NewRecvMsgHandler().VerifyURL(qs.Get("msg_signature"), qs.Get("timestamp"), qs.Get("nonce"), echostr); err == nil {
+		t.Error("Expected corp mismatch error, got nil")
+	}
+}
+
+func TestAPI_CallbackHandler(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+
+	var received interface{}
+	handler := a.NewCallbackHandler(func(r *http.Request, message interface{}) ([]byte, error) {
+		received = message
+		return []byte("<xml><Content><![CDATA[pong]]></Content></xml>"), nil
+	})
+
+	echostr, _ := a.MsgCrypter.Encrypt("echo-12345")
+	qs := signedQuery(a, echostr)
+	qs.Set("echostr", echostr)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback?"+qs.Encode(), nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "echo-12345" {
+		t.Fatalf("Unexpected verify response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	msg := "<xml><ToUserName>mockCorpID</ToUserName><FromUserName>zhangsan</FromUserName>" +
+		"<CreateTime>1348831860</CreateTime><MsgType>text</MsgType><Content>ping</Content>" +
+		"<MsgId>1234567890123456</MsgId><AgentID>1</AgentID></xml>"
+	encrypt, _ := a.MsgCrypter.Encrypt(msg)
+	body := fmt.Sprintf("<xml><ToUserName>mockCorpID</ToUserName><AgentID>1</AgentID><Encrypt>%s</Encrypt></xml>", encrypt)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback?"+signedQuery(a, encrypt).Encode(), strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d %s", rec.Code, rec.Body.String())
+	}
+
+	text, ok := received.(*RecvTextMessage)
+	if !ok || text.Content != "ping" {
+		t.Fatalf("Unexpected message: %#v", received)
+	}
+
+	resp := &struct {
+		Encrypt string
+	}{}
+	if err := xml.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reply, _, err := a.MsgCrypter.Decrypt(resp.Encrypt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(reply), "pong") {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+}
+
+func TestReply(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	h := a.NewRecvMsgHandler()
+	msg := RecvBaseData{ToUserName: "mockCorpID", FromUserName: "zhangsan", MsgType: TextMsg, AgentID: 1}
+
+	text := ReplyText(msg, "pong <ok>")
+	if text.ToUserName != base.StringToCDATA("zhangsan") || text.FromUserName != base.StringToCDATA("mockCorpID") || text.CreateTime == 0 {
+		t.Errorf("Unexpected reply: %+v", text)
+	}
+
+	resp, err := h.ResponseReply(text)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply := decryptReply(t, a, resp); !strings.Contains(reply, "<MsgType><![CDATA[text]]></MsgType><Content><![CDATA[pong <ok>]]></Content>") {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+
+	data, err := xml.Marshal(ReplyNews(msg, Article{Title: "周报", URL: "https://example.com"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), "<ArticleCount>1</ArticleCount><Articles><item><Title><![CDATA[周报]]></Title>") {
+		t.Errorf("Unexpected reply: %s", data)
+	}
+}
+
+func TestRouter_TemplateCardEvent(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	router := a.NewRouter()
+
+	var event *RecvTemplateCardEvent
+	router.OnTemplateCardEvent(func(e *RecvTemplateCardEvent) Reply {
+		event = e
+		return ReplyUpdateButton(e.RecvBaseData, "已同意")
+	})
+
+	msg := "<xml><ToUserName>mockCorpID</ToUserName><FromUserName>zhangsan</FromUserName>" +
+		"<CreateTime>1348831860</CreateTime><MsgType>event</MsgType><Event>template_card_event</Event>" +
+		"<EventKey>approve</EventKey><TaskId>task-1</TaskId><CardType>vote_interaction</CardType>" +
+		"<ResponseCode>code-1</ResponseCode><AgentID>1</AgentID><SelectedItems><SelectedItem>" +
+		"<QuestionKey>q1</QuestionKey><OptionIds><OptionId>a</OptionId><OptionId>b</OptionId></OptionIds>" +
+		"</SelectedItem></SelectedItems></xml>"
+	body, signature, timestamp, nonce := encryptedCallback(t, a, msg)
+
+	resp, err := router.Dispatch(context.Background(), body, signature, timestamp, nonce)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event == nil || event.TaskID != "task-1" || event.CardType != VoteInteractionCard || event.ResponseCode != "code-1" ||
+		len(event.SelectedItems) != 1 || strings.Join(event.SelectedItems[0].OptionIDs.OptionID, ",") != "a,b" {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+
+	reply := decryptReply(t, a, resp)
+	if !strings.Contains(reply, "<ToUserName><![CDATA[zhangsan]]></ToUserName>") ||
+		!strings.Contains(reply, "<MsgType><![CDATA[update_button]]></MsgType><Button><ReplaceName><![CDATA[已同意]]></ReplaceName></Button>") {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// Reply 为被动响应的明文消息，可以是 RespTextMessage 等可 xml 序列化的结构体，
+// 也可以是已序列化的 []byte 或 string，为 nil 时不响应消息
+type Reply interface{}
+
+// RecvRequest 描述一次回调中接收到的消息或事件
+type RecvRequest struct {
+	Context    context.Context
+	MsgType    MessageType
+	Event      string
+	ChangeType string
+	// Message 为解析后的消息结构，如 *RecvTextMessage，未知的消息或事件类型时为 nil
+	Message interface{}
+	// RawData 为解密后的消息明文 XML
+	RawData []byte
+}
+
+// RecvHandlerFunc 为消息或事件的处理函数
+type RecvHandlerFunc func(req *RecvRequest) (Reply, error)
+
+// RecvMiddleware 为消息处理的中间件，可用于日志、鉴权、异常恢复等
+type RecvMiddleware func(next RecvHandlerFunc) RecvHandlerFunc
+
+// Router 根据消息类型、事件类型及变更类型将回调分发给对应的处理函数，
+// 并将处理函数返回的被动响应消息加密后返回
+type Router struct {
+	handler     *recvMsgHandler
+	routes      map[string]RecvHandlerFunc
+	middlewares []RecvMiddleware
+	fallback    RecvHandlerFunc
+}
+
+// NewRouter 方法用于创建消息路由的实例
+func (a *API) NewRouter() *Router {
+	return &Router{
+		handler: a.NewRecvMsgHandler(),
+		routes:  make(map[string]RecvHandlerFunc),
+	}
+}
+
+func routeKey(msgType MessageType, event, changeType string) string {
+	if msgType != EventMsg || event == "" {
+		return string(msgType)
+	}
+	if changeType == "" {
+		return string(EventMsg) + ":" + event
+	}
+	return string(EventMsg) + ":" + event + ":" + changeType
+}
+
+// Use 方法用于添加中间件，先添加的中间件位于调用链的外层
+func (r *Router) Use(middlewares ...RecvMiddleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle 方法用于注册某种消息类型的处理函数，msgType 为 EventMsg 时处理所有未单独注册的事件
+func (r *Router) Handle(msgType MessageType, h RecvHandlerFunc) {
+	r.routes[routeKey(msgType, "", "")] = h
+}
+
+// OnEvent 方法用于注册事件的处理函数，changeType 为空时处理该事件的所有变更类型
+func (r *Router) OnEvent(event, changeType string, h RecvHandlerFunc) {
+	r.routes[routeKey(EventMsg, event, changeType)] = h
+}
+
+// Fallback 方法用于注册未匹配到处理函数时的兜底处理函数，未知类型的消息或事件也会交由其处理
+func (r *Router) Fallback(h RecvHandlerFunc) {
+	r.fallback = h
+}
+
+// OnText 方法用于注册文本消息的处理函数
+func (r *Router) OnText(fn func(*RecvTextMessage) Reply) {
+	r.Handle(TextMsg, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvTextMessage)), nil
+	})
+}
+
+// OnImage 方法用于注册图片消息的处理函数
+func (r *Router) OnImage(fn func(*RecvImageMessage) Reply) {
+	r.Handle(ImageMsg, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvImageMessage)), nil
+	})
+}
+
+// OnVoice 方法用于注册语音消息的处理函数
+func (r *Router) OnVoice(fn func(*RecvVoiceMessage) Reply) {
+	r.Handle(VoiceMsg, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvVoiceMessage)), nil
+	})
+}
+
+// OnVideo 方法用于注册视频消息的处理函数
+func (r *Router) OnVideo(fn func(*RecvVideoMessage) Reply) {
+	r.Handle(VideoMsg, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvVideoMessage)), nil
+	})
+}
+
+// OnLocation 方法用于注册地理位置消息的处理函数
+func (r *Router) OnLocation(fn func(*RecvLocationMessage) Reply) {
+	r.Handle(LocationMsg, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvLocationMessage)), nil
+	})
+}
+
+// OnSubscribe 方法用于注册成员关注事件的处理函数
+func (r *Router) OnSubscribe(fn func(*RecvSubscribeEvent) Reply) {
+	r.OnEvent(SubscribeEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvSubscribeEvent)), nil
+	})
+}
+
+// OnUnsubscribe 方法用于注册成员取消关注事件的处理函数
+func (r *Router) OnUnsubscribe(fn func(*RecvSubscribeEvent) Reply) {
+	r.OnEvent(UnsubscribeEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvSubscribeEvent)), nil
+	})
+}
+
+// OnMenuClick 方法用于注册点击菜单事件的处理函数
+func (r *Router) OnMenuClick(fn func(*RecvMenuEvent) Reply) {
+	r.OnEvent(MenuClickEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvMenuEvent)), nil
+	})
+}
+
+// OnMenuView 方法用于注册点击菜单跳转链接事件的处理函数
+func (r *Router) OnMenuView(fn func(*RecvMenuEvent) Reply) {
+	r.OnEvent(MenuViewEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvMenuEvent)), nil
+	})
+}
+
+// OnEnterAgent 方法用于注册成员进入应用事件的处理函数
+func (r *Router) OnEnterAgent(fn func(*RecvEnterAgentEvent) Reply) {
+	r.OnEvent(EnterAgentEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvEnterAgentEvent)), nil
+	})
+}
+
+// OnBatchJobResult 方法用于注册异步任务完成事件的处理函数
+func (r *Router) OnBatchJobResult(fn func(*RecvBatchJobResultEvent) Reply) {
+	r.OnEvent(BatchJobResultEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvBatchJobResultEvent)), nil
+	})
+}
+
+// OnTemplateCardEvent 方法用于注册模板卡片事件的处理函数，可返回 ReplyUpdateButton 创建的消息更新按钮
+func (r *Router) OnTemplateCardEvent(fn func(*RecvTemplateCardEvent) Reply) {
+	r.OnEvent(TemplateCardEvent, "", func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvTemplateCardEvent)), nil
+	})
+}
+
+// OnChangeContact 方法用于注册通讯录变更事件的处理函数，changeType 为空时处理所有变更类型
+func (r *Router) OnChangeContact(changeType string, fn func(*RecChangeContactEvent) Reply) {
+	r.OnEvent(ChangeContactEvent, changeType, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecChangeContactEvent)), nil
+	})
+}
+
+// OnChangeExternalContact 方法用于注册客户变更事件的处理函数，changeType 为空时处理所有变更类型
+func (r *Router) OnChangeExternalContact(changeType string, fn func(*RecChangeExternalContactEvent) Reply) {
+	r.OnEvent(ChangeExternalContactEvent, changeType, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecChangeExternalContactEvent)), nil
+	})
+}
+
+// OnChangeExternalChat 方法用于注册客户群变更事件的处理函数，changeType 为空时处理所有变更类型
+func (r *Router) OnChangeExternalChat(changeType string, fn func(*RecvChangeExternalChat) Reply) {
+	r.OnEvent(ChangeExternalChatEvent, changeType, func(req *RecvRequest) (Reply, error) {
+		return fn(req.Message.(*RecvChangeExternalChat)), nil
+	})
+}
+
+// Dispatch 方法用于解析回调消息并分发给对应的处理函数，返回加密后的被动响应内容，无需响应时返回 nil
+func (r *Router) Dispatch(ctx context.Context, body []byte, signature, timestamp, nonce string) ([]byte, error) {
+	req, err := r.parse(ctx, body, signature, timestamp, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := r.serve(req)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+
+	return r.handler.Response(reply)
+}
+
+// ServeHTTP 方法使 Router 可以直接挂载为回调地址，GET 请求用于回调 URL 验证，POST 请求用于接收消息
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	base.NewCallbackHandler(routerReceiver{r}, func(httpReq *http.Request, message interface{}) ([]byte, error) {
+		recvReq := message.(*RecvRequest)
+		recvReq.Context = httpReq.Context()
+		return r.serve(recvReq)
+	}).ServeHTTP(w, req)
+}
+
+func (r *Router) parse(ctx context.Context, body []byte, signature, timestamp, nonce string) (*RecvRequest, error) {
+	origData, err := r.handler.decrypt(body, signature, timestamp, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	probeData, err := probeRecvData(origData)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &RecvRequest{
+		Context:    ctx,
+		MsgType:    probeData.MsgType,
+		Event:      probeData.Event,
+		ChangeType: probeData.ChangeType,
+		RawData:    origData,
+	}
+
+	if data := newRecvData(probeData); data != nil {
+		if err = xml.Unmarshal(origData, data); err != nil {
+			return nil, err
+		}
+		req.Message = data
+	}
+
+	return req, nil
+}
+
+// serve 方法用于分发消息并返回序列化后的被动响应明文
+func (r *Router) serve(req *RecvRequest) ([]byte, error) {
+	h := r.match(req)
+	if h == nil {
+		h = func(*RecvRequest) (Reply, error) { return nil, nil }
+	}
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+
+	reply, err := h(req)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return marshalReply(reply)
+}
+
+// match 方法按 事件+变更类型、事件、消息类型 的顺序查找处理函数，未找到时使用兜底处理函数
+func (r *Router) match(req *RecvRequest) RecvHandlerFunc {
+	keys := []string{string(req.MsgType)}
+	if req.MsgType == EventMsg {
+		keys = []string{
+			routeKey(EventMsg, req.Event, req.ChangeType),
+			routeKey(EventMsg, req.Event, ""),
+			string(EventMsg),
+		}
+	}
+	for _, key := range keys {
+		if h, ok := r.routes[key]; ok {
+			return h
+		}
+	}
+	return r.fallback
+}
+
+// routerReceiver 将 Router 适配为 base.CallbackReceiver，Parse 返回 *RecvRequest
+type routerReceiver struct {
+	router *Router
+}
+
+func (rr routerReceiver) Parse(body []byte, signature, timestamp, nonce string) (interface{}, error) {
+	return rr.router.parse(context.Background(), body, signature, timestamp, nonce)
+}
+
+func (rr routerReceiver) Response(message []byte) ([]byte, error) {
+	return rr.router.handler.Response(message)
+}
+
+func (rr routerReceiver) VerifyURL(signature, timestamp, nonce, echostr string) (string, error) {
+	return rr.router.handler.VerifyURL(signature, timestamp, nonce, echostr)
+}
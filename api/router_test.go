@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func encryptedCallback(t *testing.T, a *API, msg string) (body []byte, signature, timestamp, nonce string) {
+	encrypt, err := a.MsgCrypter.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	qs := signedQuery(a, encrypt)
+	body = []byte(fmt.Sprintf("<xml><ToUserName>%s</ToUserName><Encrypt>%s</Encrypt></xml>", a.CorpID, encrypt))
+	return body, qs.Get("msg_signature"), qs.Get("timestamp"), qs.Get("nonce")
+}
+
+func decryptReply(t *testing.T, a *API, resp []byte) string {
+	body := &struct{ Encrypt string }{}
+	if err := xml.Unmarshal(resp, body); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reply, _, err := a.MsgCrypter.Decrypt(body.Encrypt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return string(reply)
+}
+
+func TestRouter_DispatchText(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	router := a.NewRouter()
+
+	var order []string
+	router.Use(func(next RecvHandlerFunc) RecvHandlerFunc {
+		return func(req *RecvRequest) (Reply, error) {
+			order = append(order, "outer")
+			return next(req)
+		}
+	}, func(next RecvHandlerFunc) RecvHandlerFunc {
+		return func(req *RecvRequest) (Reply, error) {
+			order = append(order, "inner")
+			return next(req)
+		}
+	})
+	router.OnText(func(msg *RecvTextMessage) Reply {
+		order = append(order, "handler")
+		return &RespTextMessage{
+			RespBaseData: RespBaseData{
+				ToUserName:   base.StringToCDATA(msg.FromUserName),
+				FromUserName: base.StringToCDATA(msg.ToUserName),
+				CreateTime:   msg.CreateTime,
+				MsgType:      base.StringToCDATA(string(TextMsg)),
+			},
+			Content: base.StringToCDATA("echo: " + msg.Content),
+		}
+	})
+
+	body, signature, timestamp, nonce := encryptedCallback(t, a,
+		"<xml><ToUserName>mockCorpID</ToUserName><FromUserName>zhangsan</FromUserName>"+
+			"<CreateTime>1348831860</CreateTime><MsgType>text</MsgType><Content>hello</Content><AgentID>1</AgentID></xml>")
+
+	resp, err := router.Dispatch(context.Background(), body, signature, timestamp, nonce)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if reply := decryptReply(t, a, resp); !strings.Contains(reply, "<![CDATA[echo: hello]]>") || !strings.Contains(reply, "<![CDATA[zhangsan]]>") {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+
+	if strings.Join(order, ",") != "outer,inner,handler" {
+		t.Errorf("Unexpected middleware order: %v", order)
+	}
+}
+
+func TestRouter_ChangeTypeAndFallback(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	router := a.NewRouter()
+
+	var matched string
+	router.OnChangeExternalContact("add_external_contact", func(e *RecChangeExternalContactEvent) Reply {
+		matched = "add:" + e.ExternalUserID
+		return nil
+	})
+	router.OnEvent(ChangeExternalContactEvent, "", func(req *RecvRequest) (Reply, error) {
+		matched = "any:" + req.ChangeType
+		return nil, nil
+	})
+	router.Fallback(func(req *RecvRequest) (Reply, error) {
+		matched = "fallback:" + req.Event
+		if req.Message != nil || !strings.Contains(string(req.RawData), "unknown_event") {
+			t.Errorf("Unexpected fallback request: %#v", req)
+		}
+		return nil, nil
+	})
+
+	cases := []struct {
+		event, changeType, expected string
+	}{
+		{ChangeExternalContactEvent, "add_external_contact", "add:wmXXX"},
+		{ChangeExternalContactEvent, "del_external_contact", "any:del_external_contact"},
+		{"unknown_event", "", "fallback:unknown_event"},
+	}
+
+	for _, c := range cases {
+		matched = ""
+		body, signature, timestamp, nonce := encryptedCallback(t, a, fmt.Sprintf(
+			"<xml><ToUserName>mockCorpID</ToUserName><FromUserName>sys</FromUserName><CreateTime>1403610513</CreateTime>"+
+				"<MsgType>event</MsgType><Event>%s</Event><ChangeType>%s</ChangeType>"+
+				"<UserID>zhangsan</UserID><ExternalUserID>wmXXX</ExternalUserID></xml>", c.event, c.changeType))
+
+		resp, err := router.Dispatch(context.Background(), body, signature, timestamp, nonce)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp != nil {
+			t.Errorf("Expected no reply, got %s", resp)
+		}
+		if matched != c.expected {
+			t.Errorf("Expected %s, got %s", c.expected, matched)
+		}
+	}
+}
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", testToken, testEncodingAESKey)
+	router := a.NewRouter()
+	router.OnText(func(msg *RecvTextMessage) Reply {
+		return "<xml><Content><![CDATA[pong]]></Content></xml>"
+	})
+
+	body, signature, timestamp, nonce := encryptedCallback(t, a,
+		"<xml><ToUserName>mockCorpID</ToUserName><FromUserName>zhangsan</FromUserName>"+
+			"<CreateTime>1348831860</CreateTime><MsgType>text</MsgType><Content>ping</Content></xml>")
+	target := fmt.Sprintf("/callback?msg_signature=%s&timestamp=%s&nonce=%s", signature, timestamp, nonce)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d %s", rec.Code, rec.Body.String())
+	}
+	if reply := decryptReply(t, a, rec.Body.Bytes()); !strings.Contains(reply, "pong") {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+}
@@ -2,13 +2,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/url"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
-	sendMessageURI       = "https://qyapi.weixin.qq.com/cgi-bin/message/send"
-	getPermitUserListURI = "https://qyapi.weixin.qq.com/cgi-bin/msgaudit/get_permit_user_list"
+	sendMessageURI        = "https://qyapi.weixin.qq.com/cgi-bin/message/send"
+	recallMessageURI      = "https://qyapi.weixin.qq.com/cgi-bin/message/recall"
+	updateTemplateCardURI = "https://qyapi.weixin.qq.com/cgi-bin/message/update_template_card"
+	getPermitUserListURI  = "https://qyapi.weixin.qq.com/cgi-bin/msgaudit/get_permit_user_list"
 )
 
 // MessageType 消息类型定义
@@ -25,9 +31,13 @@ const (
 
 	// 发送的额外消息类型
 
-	FileMsg   MessageType = "file"
-	NewsMsg   MessageType = "news"
-	MpNewsMsg MessageType = "mpnews"
+	FileMsg              MessageType = "file"
+	NewsMsg              MessageType = "news"
+	MpNewsMsg            MessageType = "mpnews"
+	TextCardMsg          MessageType = "textcard"
+	MarkdownMsg          MessageType = "markdown"
+	MiniprogramNoticeMsg MessageType = "miniprogram_notice"
+	TemplateCardMsg      MessageType = "template_card"
 
 	// 接收的额外消息类型
 
@@ -35,6 +45,29 @@ const (
 	EventMsg    MessageType = "event"
 )
 
+// 消息重复检查的间隔，单位为秒
+const (
+	DefaultDuplicateCheckInterval = 1800
+	MaxDuplicateCheckInterval     = 4 * 3600
+)
+
+// 消息内容的长度限制，超出时企业微信会截断或拒绝发送
+const (
+	maxTextContentBytes         = 2048
+	maxMarkdownContentBytes     = 2048
+	maxArticles                 = 8
+	maxArticleTitleBytes        = 128
+	maxArticleDescriptionBytes  = 512
+	maxTextCardTitleBytes       = 128
+	maxTextCardDescriptionBytes = 512
+	maxTextCardBtnTxtChars      = 4
+	minMiniprogramNoticeChars   = 4
+	maxMiniprogramNoticeChars   = 12
+	maxMiniprogramNoticeItems   = 10
+	maxMiniprogramNoticeKey     = 10
+	maxMiniprogramNoticeValue   = 30
+)
+
 // TextContent 为文本类型消息的文本内容
 type TextContent struct {
 	Content string `json:"content"`
@@ -42,13 +75,30 @@ type TextContent struct {
 
 // TextMessage 为发送的文本类型消息
 type TextMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	Text    TextContent `json:"text"`
-	Safe    int         `json:"safe"`
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	Text                   TextContent `json:"text"`
+	Safe                   int         `json:"safe"`
+	EnableIDTrans          int         `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验文本消息，返回 *MessageValidationError
+func (m TextMessage) Validate() error {
+	v := newMessageValidator(TextMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m TextMessage) validateContent(v *messageValidator) {
+	v.require("text.content", m.Text.Content)
+	v.maxBytes("text.content", m.Text.Content, maxTextContentBytes)
 }
 
 // Media 为发送消息的媒体文件内容
@@ -58,24 +108,54 @@ type Media struct {
 
 // ImageMessage 为发送的图片类型消息
 type ImageMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	Image   Media       `json:"image"`
-	Safe    int         `json:"safe"`
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	Image                  Media       `json:"image"`
+	Safe                   int         `json:"safe"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验图片消息，返回 *MessageValidationError
+func (m ImageMessage) Validate() error {
+	v := newMessageValidator(ImageMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m ImageMessage) validateContent(v *messageValidator) {
+	v.require("image.media_id", m.Image.ID)
 }
 
 // VoiceMessage 为发送的声音类型消息
 type VoiceMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	Voice   Media       `json:"voice"`
-	Safe    int         `json:"safe"`
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	Voice                  Media       `json:"voice"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+	Safe                   int         `json:"safe"`
+}
+
+// Validate 方法用于校验语音消息，返回 *MessageValidationError
+func (m VoiceMessage) Validate() error {
+	v := newMessageValidator(VoiceMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m VoiceMessage) validateContent(v *messageValidator) {
+	v.require("voice.media_id", m.Voice.ID)
 }
 
 // VideoContent 为视频类型消息的内容
@@ -87,24 +167,54 @@ type VideoContent struct {
 
 // VideoMessage 为发送的视频类型消息
 type VideoMessage struct {
-	ToUser  string       `json:"touser,omitempty"`
-	ToParty string       `json:"toparty,omitempty"`
-	ToTag   string       `json:"totag,omitempty"`
-	MsgType MessageType  `json:"msgtype"`
-	AgentID int64        `json:"agentid"`
-	Video   VideoContent `json:"video"`
-	Safe    int          `json:"safe"`
+	ToUser                 string       `json:"touser,omitempty"`
+	ToParty                string       `json:"toparty,omitempty"`
+	ToTag                  string       `json:"totag,omitempty"`
+	MsgType                MessageType  `json:"msgtype"`
+	AgentID                int64        `json:"agentid"`
+	Video                  VideoContent `json:"video"`
+	Safe                   int          `json:"safe"`
+	EnableDuplicateCheck   int          `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int          `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验视频消息，返回 *MessageValidationError
+func (m VideoMessage) Validate() error {
+	v := newMessageValidator(VideoMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m VideoMessage) validateContent(v *messageValidator) {
+	v.require("video.media_id", m.Video.ID)
 }
 
 // FileMessage 为发送的文件类型消息
 type FileMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	File    Media       `json:"file"`
-	Safe    int         `json:"safe"`
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	File                   Media       `json:"file"`
+	Safe                   int         `json:"safe"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验文件消息，返回 *MessageValidationError
+func (m FileMessage) Validate() error {
+	v := newMessageValidator(FileMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m FileMessage) validateContent(v *messageValidator) {
+	v.require("file.media_id", m.File.ID)
 }
 
 // Article 为普通图文消息的文章内容
@@ -122,12 +232,34 @@ type Articles struct {
 
 // NewsMessage 为发送的普通图文类型消息
 type NewsMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	News    Articles    `json:"news"`
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	News                   Articles    `json:"news"`
+	EnableIDTrans          int         `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验普通图文消息，返回 *MessageValidationError
+func (m NewsMessage) Validate() error {
+	v := newMessageValidator(NewsMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m NewsMessage) validateContent(v *messageValidator) {
+	v.rangeItems("news.articles", len(m.News.Articles), 1, maxArticles)
+	for i, article := range m.News.Articles {
+		field := fmt.Sprintf("news.articles[%d]", i)
+		v.require(field+".title", article.Title)
+		v.maxBytes(field+".title", article.Title, maxArticleTitleBytes)
+		v.maxBytes(field+".description", article.Description, maxArticleDescriptionBytes)
+	}
 }
 
 // MpArticle 为特殊图文消息的文章内容
@@ -148,43 +280,463 @@ type MpArticles struct {
 
 // MpNewsMessage 为发送的特殊图文类型消息
 type MpNewsMessage struct {
-	ToUser  string      `json:"touser,omitempty"`
-	ToParty string      `json:"toparty,omitempty"`
-	ToTag   string      `json:"totag,omitempty"`
-	MsgType MessageType `json:"msgtype"`
-	AgentID int64       `json:"agentid"`
-	MpNews  MpArticles  `json:"mpnews"`
-	Safe    int         `json:"safe"`
-}
-
-// SendMessage 方法用于主动发送消息给企业成员
-func (a *API) SendMessage(message interface{}) error {
-	token, err := a.Tokener.Token()
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	MpNews                 MpArticles  `json:"mpnews"`
+	Safe                   int         `json:"safe"`
+	EnableIDTrans          int         `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// Validate 方法用于校验特殊图文消息，返回 *MessageValidationError
+func (m MpNewsMessage) Validate() error {
+	v := newMessageValidator(MpNewsMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m MpNewsMessage) validateContent(v *messageValidator) {
+	v.rangeItems("mpnews.articles", len(m.MpNews.Articles), 1, maxArticles)
+	for i, article := range m.MpNews.Articles {
+		field := fmt.Sprintf("mpnews.articles[%d]", i)
+		v.require(field+".title", article.Title)
+		v.require(field+".thumb_media_id", article.ThumbMediaID)
+		v.require(field+".content", article.Content)
+	}
+}
+
+// TextCardContent 为文本卡片消息的内容，Description 支持 div 标签设置颜色
+type TextCardContent struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	BtnTxt      string `json:"btntxt,omitempty"`
+}
+
+// TextCardMessage 为发送的文本卡片类型消息
+type TextCardMessage struct {
+	ToUser                 string          `json:"touser,omitempty"`
+	ToParty                string          `json:"toparty,omitempty"`
+	ToTag                  string          `json:"totag,omitempty"`
+	MsgType                MessageType     `json:"msgtype"`
+	AgentID                int64           `json:"agentid"`
+	TextCard               TextCardContent `json:"textcard"`
+	EnableIDTrans          int             `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int             `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int             `json:"duplicate_check_interval,omitempty"`
+}
+
+// NewTextCardMessage 方法用于创建文本卡片消息，接收人需另行设置
+func NewTextCardMessage(agentID int64, title, description, url string) *TextCardMessage {
+	return &TextCardMessage{
+		MsgType:  TextCardMsg,
+		AgentID:  agentID,
+		TextCard: TextCardContent{Title: title, Description: description, URL: url},
+	}
+}
+
+// Validate 方法用于校验文本卡片消息，返回 *MessageValidationError
+func (m TextCardMessage) Validate() error {
+	v := newMessageValidator(TextCardMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m TextCardMessage) validateContent(v *messageValidator) {
+	v.require("textcard.title", m.TextCard.Title)
+	v.maxBytes("textcard.title", m.TextCard.Title, maxTextCardTitleBytes)
+	v.require("textcard.description", m.TextCard.Description)
+	v.maxBytes("textcard.description", m.TextCard.Description, maxTextCardDescriptionBytes)
+	v.require("textcard.url", m.TextCard.URL)
+	v.maxChars("textcard.btntxt", m.TextCard.BtnTxt, maxTextCardBtnTxtChars)
+}
+
+// MarkdownMessage 为发送的 markdown 类型消息，仅支持企业微信客户端内查看
+type MarkdownMessage struct {
+	ToUser                 string      `json:"touser,omitempty"`
+	ToParty                string      `json:"toparty,omitempty"`
+	ToTag                  string      `json:"totag,omitempty"`
+	MsgType                MessageType `json:"msgtype"`
+	AgentID                int64       `json:"agentid"`
+	Markdown               TextContent `json:"markdown"`
+	EnableDuplicateCheck   int         `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int         `json:"duplicate_check_interval,omitempty"`
+}
+
+// NewMarkdownMessage 方法用于创建 markdown 消息，接收人需另行设置
+func NewMarkdownMessage(agentID int64, content string) *MarkdownMessage {
+	return &MarkdownMessage{
+		MsgType:  MarkdownMsg,
+		AgentID:  agentID,
+		Markdown: TextContent{Content: content},
+	}
+}
+
+// Validate 方法用于校验 markdown 消息，返回 *MessageValidationError
+func (m MarkdownMessage) Validate() error {
+	v := newMessageValidator(MarkdownMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m MarkdownMessage) validateContent(v *messageValidator) {
+	v.require("markdown.content", m.Markdown.Content)
+	v.maxBytes("markdown.content", m.Markdown.Content, maxMarkdownContentBytes)
+}
+
+// MiniprogramNoticeItem 为小程序通知消息中的键值对
+type MiniprogramNoticeItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// MiniprogramNoticeContent 为小程序通知消息的内容，AppID 须为已关联到应用的小程序
+type MiniprogramNoticeContent struct {
+	AppID             string                  `json:"appid"`
+	Page              string                  `json:"page,omitempty"`
+	Title             string                  `json:"title"`
+	Description       string                  `json:"description,omitempty"`
+	EmphasisFirstItem bool                    `json:"emphasis_first_item,omitempty"`
+	ContentItem       []MiniprogramNoticeItem `json:"content_item,omitempty"`
+}
+
+// MiniprogramNoticeMessage 为发送的小程序通知类型消息，仅可发送给关联了小程序的应用
+type MiniprogramNoticeMessage struct {
+	ToUser                 string                   `json:"touser,omitempty"`
+	ToParty                string                   `json:"toparty,omitempty"`
+	ToTag                  string                   `json:"totag,omitempty"`
+	MsgType                MessageType              `json:"msgtype"`
+	MiniprogramNotice      MiniprogramNoticeContent `json:"miniprogram_notice"`
+	EnableIDTrans          int                      `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int                      `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int                      `json:"duplicate_check_interval,omitempty"`
+}
+
+// NewMiniprogramNoticeMessage 方法用于创建小程序通知消息，接收人需另行设置
+func NewMiniprogramNoticeMessage(appID, page, title string, items ...MiniprogramNoticeItem) *MiniprogramNoticeMessage {
+	return &MiniprogramNoticeMessage{
+		MsgType: MiniprogramNoticeMsg,
+		MiniprogramNotice: MiniprogramNoticeContent{
+			AppID:       appID,
+			Page:        page,
+			Title:       title,
+			ContentItem: items,
+		},
+	}
+}
+
+// Validate 方法用于校验小程序通知消息，返回 *MessageValidationError
+func (m MiniprogramNoticeMessage) Validate() error {
+	v := newMessageValidator(MiniprogramNoticeMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m MiniprogramNoticeMessage) validateContent(v *messageValidator) {
+	n := m.MiniprogramNotice
+	v.require("miniprogram_notice.appid", n.AppID)
+	v.rangeChars("miniprogram_notice.title", n.Title, minMiniprogramNoticeChars, maxMiniprogramNoticeChars)
+	if n.Description != "" {
+		v.rangeChars("miniprogram_notice.description", n.Description, minMiniprogramNoticeChars, maxMiniprogramNoticeChars)
+	}
+	v.maxItems("miniprogram_notice.content_item", len(n.ContentItem), maxMiniprogramNoticeItems)
+	for i, item := range n.ContentItem {
+		field := fmt.Sprintf("miniprogram_notice.content_item[%d]", i)
+		v.maxChars(field+".key", item.Key, maxMiniprogramNoticeKey)
+		v.maxChars(field+".value", item.Value, maxMiniprogramNoticeValue)
+	}
+}
+
+// MessageFieldError 为消息中不合法的字段
+type MessageFieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e *MessageFieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// MessageValidationError 为消息校验失败的错误，包含所有不合法的字段
+type MessageValidationError struct {
+	MsgType MessageType
+	Fields  []*MessageFieldError
+}
+
+func (e *MessageValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		msgs = append(msgs, field.Error())
+	}
+	return fmt.Sprintf("invalid %s message: %s", e.MsgType, strings.Join(msgs, "; "))
+}
+
+// messageValidator 用于收集消息校验过程中的不合法字段
+type messageValidator struct {
+	verr *MessageValidationError
+}
+
+func newMessageValidator(msgType MessageType) *messageValidator {
+	return &messageValidator{verr: &MessageValidationError{MsgType: msgType}}
+}
+
+func (v *messageValidator) add(field, format string, args ...interface{}) {
+	v.verr.Fields = append(v.verr.Fields, &MessageFieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *messageValidator) err() error {
+	if len(v.verr.Fields) == 0 {
+		return nil
+	}
+	return v.verr
+}
+
+func (v *messageValidator) checkReceivers(toUser, toParty, toTag string) {
+	if toUser == "" && toParty == "" && toTag == "" {
+		v.add("touser", "one of touser, toparty and totag is required")
+	}
+}
+
+func (v *messageValidator) checkDuplicate(interval int) {
+	if interval < 0 || interval > MaxDuplicateCheckInterval {
+		v.add("duplicate_check_interval", "must be between 0 and %d", MaxDuplicateCheckInterval)
+	}
+}
+
+func (v *messageValidator) require(field, value string) {
+	if value == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *messageValidator) maxBytes(field, value string, max int) {
+	if len(value) > max {
+		v.add(field, "exceeds %d bytes", max)
+	}
+}
+
+func (v *messageValidator) maxChars(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "exceeds %d characters", max)
+	}
+}
+
+func (v *messageValidator) rangeChars(field, value string, min, max int) {
+	if n := utf8.RuneCountInString(value); n < min || n > max {
+		v.add(field, "must be %d to %d characters", min, max)
+	}
+}
+
+func (v *messageValidator) maxItems(field string, n, max int) {
+	if n > max {
+		v.add(field, "exceeds %d items", max)
+	}
+}
+
+func (v *messageValidator) rangeItems(field string, n, min, max int) {
+	if n < min || n > max {
+		v.add(field, "must have %d to %d items", min, max)
+	}
+}
+
+// SendMessageResult 为发送应用消息的结果，无效或无权限的接收人以 | 分隔，
+// ResponseCode 仅在发送按钮交互型、投票选择型、多项选择型模板卡片时返回，用于更新卡片
+type SendMessageResult struct {
+	InvalidUser    string `json:"invaliduser"`
+	InvalidParty   string `json:"invalidparty"`
+	InvalidTag     string `json:"invalidtag"`
+	UnlicensedUser string `json:"unlicenseduser"`
+	MsgID          string `json:"msgid"`
+	ResponseCode   string `json:"response_code"`
+}
+
+// InvalidUsers 方法返回无效或无权限的成员 userid 列表
+func (r *SendMessageResult) InvalidUsers() []string {
+	return splitRecipients(r.InvalidUser)
+}
+
+// InvalidParties 方法返回无效或无权限的部门 id 列表
+func (r *SendMessageResult) InvalidParties() []string {
+	return splitRecipients(r.InvalidParty)
+}
+
+// InvalidTags 方法返回无效或无权限的标签 id 列表
+func (r *SendMessageResult) InvalidTags() []string {
+	return splitRecipients(r.InvalidTag)
+}
+
+// UnlicensedUsers 方法返回没有基础接口许可（包含已过期）的成员 userid 列表
+func (r *SendMessageResult) UnlicensedUsers() []string {
+	return splitRecipients(r.UnlicensedUser)
+}
+
+// Delivered 方法返回是否所有接收人均有效
+func (r *SendMessageResult) Delivered() bool {
+	return r.InvalidUser == "" && r.InvalidParty == "" && r.InvalidTag == "" && r.UnlicensedUser == ""
+}
+
+func splitRecipients(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "|")
+}
+
+// SendMessage 方法用于主动发送消息给企业成员，部分接收人无效时不返回错误，需检查结果中的无效接收人；
+// 接收人全部无效时返回 base.ErrInvalidRecipients 错误，结果中同样包含无效接收人
+func (a *API) SendMessage(message interface{}) (*SendMessageResult, error) {
+	return a.SendMessageContext(context.Background(), message)
+}
+
+// SendMessageContext 为 SendMessage 的 context 版本
+func (a *API) SendMessageContext(ctx context.Context, message interface{}) (*SendMessageResult, error) {
+	// 卡片及通知类消息在发送前校验，避免无效请求消耗调用次数，其他消息需自行调用 Validate 方法校验
+	switch message.(type) {
+	case TextCardMessage, *TextCardMessage, MarkdownMessage, *MarkdownMessage,
+		MiniprogramNoticeMessage, *MiniprogramNoticeMessage, TemplateCardMessage, *TemplateCardMessage:
+		if err := message.(interface{ Validate() error }).Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 接收人全部无效（81013）等错误时响应中仍包含无效接收人列表，与错误一并返回
+	body, err := a.postMessageContext(ctx, sendMessageURI, message)
+	if len(body) == 0 {
+		return nil, err
+	}
+
+	result := &SendMessageResult{}
+	if uerr := json.Unmarshal(body, result); uerr != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, uerr
+	}
+	return result, err
+}
+
+// postMessageContext 方法用于发送消息请求，消息内容中的 <、> 等字符不做 HTML 转义
+func (a *API) postMessageContext(ctx context.Context, uri string, message interface{}) ([]byte, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	qs := make(url.Values)
 	qs.Add("access_token", token)
 
-	url := sendMessageURI + "?" + qs.Encode()
+	data, err := encodeMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Client.PostJSONContext(ctx, uri+"?"+qs.Encode(), data)
+}
 
+func encodeMessage(message interface{}) ([]byte, error) {
 	bf := bytes.NewBuffer([]byte{})
 	jsonEncoder := json.NewEncoder(bf)
 	jsonEncoder.SetEscapeHTML(false)
-	err = jsonEncoder.Encode(message)
-	// data, err := json.Marshal(message)
-	if err != nil {
-		return err
+	if err := jsonEncoder.Encode(message); err != nil {
+		return nil, err
 	}
+	return bf.Bytes(), nil
+}
+
+// RecallMessage 方法用于撤回 24 小时内通过 SendMessage 发送的消息
+func (a *API) RecallMessage(msgID string) error {
+	return a.RecallMessageContext(context.Background(), msgID)
+}
 
-	_, err = a.Client.PostJSON(url, bf.Bytes())
-	return err
+// RecallMessageContext 为 RecallMessage 的 context 版本
+func (a *API) RecallMessageContext(ctx context.Context, msgID string) error {
+	return a.PostJSONContext(ctx, recallMessageURI, nil, map[string]string{"msgid": msgID}, nil)
+}
+
+// UpdateTemplateCardButton 为更新模板卡片时将按钮置为不可点击状态，ReplaceName 为替换后的按钮文案
+type UpdateTemplateCardButton struct {
+	ReplaceName string `json:"replace_name"`
+}
+
+// UpdateTemplateCard 为更新模板卡片消息的请求，Button 与 TemplateCard 二选一：
+// Button 仅将卡片按钮更新为不可点击状态，TemplateCard 则替换整张卡片。
+// 接收人须为原消息的接收人，AtAll 为 1 时更新所有接收人的卡片
+type UpdateTemplateCard struct {
+	UserIDs       []string                  `json:"userids,omitempty"`
+	PartyIDs      []int64                   `json:"partyids,omitempty"`
+	TagIDs        []int64                   `json:"tagids,omitempty"`
+	AtAll         int                       `json:"atall,omitempty"`
+	AgentID       int64                     `json:"agentid"`
+	ResponseCode  string                    `json:"response_code"`
+	EnableIDTrans int                       `json:"enable_id_trans,omitempty"`
+	Button        *UpdateTemplateCardButton `json:"button,omitempty"`
+	TemplateCard  *TemplateCard             `json:"template_card,omitempty"`
+}
+
+// Validate 方法用于校验更新模板卡片的请求，返回 *MessageValidationError
+func (u UpdateTemplateCard) Validate() error {
+	v := newMessageValidator(TemplateCardMsg)
+	if len(u.UserIDs) == 0 && len(u.PartyIDs) == 0 && len(u.TagIDs) == 0 && u.AtAll == 0 {
+		v.add("userids", "one of userids, partyids, tagids and atall is required")
+	}
+	v.require("response_code", u.ResponseCode)
+	switch {
+	case u.Button != nil && u.TemplateCard != nil:
+		v.add("button", "button and template_card are mutually exclusive")
+	case u.Button != nil:
+		v.require("button.replace_name", u.Button.ReplaceName)
+	case u.TemplateCard != nil:
+		u.TemplateCard.validate(v, "template_card")
+	default:
+		v.add("template_card", "button or template_card is required")
+	}
+	return v.err()
+}
+
+// UpdateTemplateCardResult 为更新模板卡片消息的结果
+type UpdateTemplateCardResult struct {
+	InvalidUser []string `json:"invaliduser"`
+}
+
+// UpdateTemplateCardMessage 方法用于更新已发送的模板卡片消息，ResponseCode 来自 SendMessage 的结果或模板卡片事件回调，
+// 72 小时内有效且只能使用一次
+func (a *API) UpdateTemplateCardMessage(update *UpdateTemplateCard) (*UpdateTemplateCardResult, error) {
+	return a.UpdateTemplateCardMessageContext(context.Background(), update)
+}
+
+// UpdateTemplateCardMessageContext 为 UpdateTemplateCardMessage 的 context 版本
+func (a *API) UpdateTemplateCardMessageContext(ctx context.Context, update *UpdateTemplateCard) (*UpdateTemplateCardResult, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &UpdateTemplateCardResult{}
+	if err := a.PostJSONContext(ctx, updateTemplateCardURI, nil, update, result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // 获取会话内容存档开启成员列表
 func (a *API) GetPermitUserList() ([]string, error) {
-	token, err := a.Tokener.Token()
+	return a.GetPermitUserListContext(context.Background())
+}
+
+// GetPermitUserListContext 为 GetPermitUserList 的 context 版本
+func (a *API) GetPermitUserListContext(ctx context.Context) ([]string, error) {
+	token, err := a.Tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +744,7 @@ func (a *API) GetPermitUserList() ([]string, error) {
 	qs.Add("access_token", token)
 	url := getPermitUserListURI + "?" + qs.Encode()
 	data, _ := json.Marshal(map[string]any{"type": 1})
-	body, err := a.Client.PostJSON(url, data)
+	body, err := a.Client.PostJSONContext(ctx, url, data)
 	if err != nil {
 		return nil, err
 	}
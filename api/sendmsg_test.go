@@ -0,0 +1,304 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func TestTemplateCardMessage_JSON(t *testing.T) {
+	card := NewButtonInteractionCard("task-1", "请假审批", "张三申请年假 3 天",
+		TemplateCardButton{Text: "同意", Style: 1, Key: "approve"},
+		TemplateCardButton{Type: CardButtonURL, Text: "详情", URL: "https://example.com/leave/1"},
+	)
+	card.HorizontalContentList = []TemplateCardHorizontalContent{{Type: HorizontalContentUser, KeyName: "申请人", UserID: "zhangsan"}}
+
+	message := NewTemplateCardMessage(1000002, card)
+	message.ToUser = "lisi"
+	message.EnableIDTrans = 1
+	message.EnableDuplicateCheck = 1
+	message.DuplicateCheckInterval = DefaultDuplicateCheckInterval
+	if err := message.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"touser":"lisi","msgtype":"template_card","agentid":1000002,"template_card":{"card_type":"button_interaction",` +
+		`"task_id":"task-1","main_title":{"title":"请假审批","desc":"张三申请年假 3 天"},` +
+		`"horizontal_content_list":[{"type":3,"keyname":"申请人","userid":"zhangsan"}],` +
+		`"button_list":[{"text":"同意","style":1,"key":"approve"},{"type":1,"text":"详情","url":"https://example.com/leave/1"}]},` +
+		`"enable_id_trans":1,"enable_duplicate_check":1,"duplicate_check_interval":1800}`
+	if string(data) != want {
+		t.Errorf("Unexpected json:\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestTemplateCard_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		card   *TemplateCard
+		fields []string
+	}{
+		{
+			name: "text notice",
+			card: NewTextNoticeCard("通知", "", NewURLCardAction("https://example.com")),
+		},
+		{
+			name:   "text notice without action",
+			card:   NewTextNoticeCard("", "", &TemplateCardAction{Type: CardJumpMiniprogram}),
+			fields: []string{"template_card.main_title.title", "template_card.card_action.appid"},
+		},
+		{
+			name:   "news notice without image",
+			card:   NewNewsNoticeCard("新闻", "", nil, NewMiniprogramCardAction("wx123", "pages/index")),
+			fields: []string{"template_card.card_image"},
+		},
+		{
+			name: "button interaction",
+			card: NewButtonInteractionCard("bad task id", "审批", "",
+				TemplateCardButton{Text: "a", Key: "a"}, TemplateCardButton{Text: "b", Key: "b"}, TemplateCardButton{Text: "c", Key: "c"},
+				TemplateCardButton{Text: "d", Key: "d"}, TemplateCardButton{Text: "e", Key: "e"}, TemplateCardButton{Text: "f", Key: "f"},
+				TemplateCardButton{Text: "g"}),
+			fields: []string{"template_card.button_list", "template_card.task_id", "template_card.button_list[6].key"},
+		},
+		{
+			name: "vote interaction",
+			card: NewVoteInteractionCard("task-2", "投票", "", &TemplateCardCheckbox{QuestionKey: "q", Mode: 2}, nil),
+			fields: []string{"template_card.submit_button", "template_card.checkbox.option_list",
+				"template_card.checkbox.mode"},
+		},
+		{
+			name: "multiple interaction",
+			card: NewMultipleInteractionCard("", "评分", "", []TemplateCardSelect{
+				{QuestionKey: "q1", OptionList: []TemplateCardOption{{ID: "1", Text: "好"}}},
+			}, &TemplateCardSubmitButton{Text: "提交", Key: "submit"}),
+			fields: []string{"template_card.task_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.card.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *MessageValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected *MessageValidationError, got %v", err)
+			}
+			var fields []string
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Unexpected fields: %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestMessages_Validate(t *testing.T) {
+	notice := NewMiniprogramNoticeMessage("wx123", "pages/index", "会议室预订成功",
+		MiniprogramNoticeItem{Key: "会议室", Value: "402"})
+	notice.ToUser = "zhangsan"
+	if err := notice.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	notice.MiniprogramNotice.Title = "预订"
+	notice.MiniprogramNotice.ContentItem = append(notice.MiniprogramNotice.ContentItem, MiniprogramNoticeItem{Key: "一二三四五六七八九十一", Value: "x"})
+	if err := notice.Validate(); err == nil || !strings.Contains(err.Error(), "content_item[1].key") || !strings.Contains(err.Error(), "miniprogram_notice.title") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	card := NewTextCardMessage(1000002, "领奖通知", "<div class=\"gray\">2016年9月26日</div>", "https://example.com")
+	card.ToParty = "1"
+	card.TextCard.BtnTxt = "更多详细信息"
+	card.DuplicateCheckInterval = MaxDuplicateCheckInterval + 1
+	if err := card.Validate(); err == nil || !strings.Contains(err.Error(), "textcard.btntxt") || !strings.Contains(err.Error(), "duplicate_check_interval") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	markdown := NewMarkdownMessage(1000002, strings.Repeat("#", maxMarkdownContentBytes+1))
+	if err := markdown.Validate(); err == nil || !strings.Contains(err.Error(), "touser") || !strings.Contains(err.Error(), "markdown.content") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	news := NewsMessage{ToTag: "1", MsgType: NewsMsg}
+	if err := news.Validate(); err == nil || !strings.Contains(err.Error(), "news.articles") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestAPI_SendMessage_Validate(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	var sent []string
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if req.URL.Path == "/cgi-bin/message/send" {
+				data, _ := io.ReadAll(req.Body)
+				sent = append(sent, string(data))
+				respBody = `{"errcode":0,"errmsg":"ok","msgid":"msg-1"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	// 校验失败时不会发送
+	var verr *MessageValidationError
+	if _, err := a.SendMessage(NewMarkdownMessage(1000002, "")); !errors.As(err, &verr) {
+		t.Fatalf("Expected *MessageValidationError, got %v", err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("Unexpected request: %v", sent)
+	}
+
+	message := NewMarkdownMessage(1000002, "**hello** <@zhangsan>")
+	message.ToUser = "zhangsan"
+	result, err := a.SendMessage(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.MsgID != "msg-1" || !result.Delivered() {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], `"markdown":{"content":"**hello** <@zhangsan>"}`) {
+		t.Errorf("Unexpected request: %v", sent)
+	}
+
+	// 原有消息类型不在发送前校验，超长内容由企业微信截断
+	text := TextMessage{MsgType: TextMsg, AgentID: 1000002, Text: TextContent{Content: strings.Repeat("a", 3000)}}
+	if _, err := a.SendMessage(text); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("Unexpected requests: %d", len(sent))
+	}
+}
+
+func TestAPI_SendMessage_InvalidRecipients(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		return `{"errcode":81013,"errmsg":"user & party & tag all invalid","invaliduser":"left1|left2","invalidparty":"","invalidtag":"","unlicenseduser":"trial1"}`
+	})
+
+	message := NewMarkdownMessage(1000002, "**hello**")
+	message.ToUser = "left1|left2|trial1"
+	result, err := a.SendMessage(message)
+	if !errors.Is(err, base.ErrInvalidRecipients) {
+		t.Fatalf("Expected base.ErrInvalidRecipients, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected result with invalid recipients")
+	}
+	if got := result.InvalidUsers(); len(got) != 2 || got[0] != "left1" || got[1] != "left2" {
+		t.Errorf("Unexpected invalid users: %v", got)
+	}
+	if got := result.UnlicensedUsers(); len(got) != 1 || got[0] != "trial1" {
+		t.Errorf("Unexpected unlicensed users: %v", got)
+	}
+}
+
+func TestAPI_RecallAndUpdateTemplateCard(t *testing.T) {
+	var sent []string
+	a := newMockAPI(func(req *http.Request, body string) string {
+		sent = append(sent, req.URL.Path+" "+strings.TrimSpace(body))
+		switch req.URL.Path {
+		case "/cgi-bin/message/send":
+			return `{"errcode":0,"errmsg":"ok","invaliduser":"lisi","msgid":"msg-1","response_code":"code-1"}`
+		case "/cgi-bin/message/update_template_card":
+			if strings.Contains(body, `"response_code":"code-1"`) {
+				return `{"errcode":0,"errmsg":"ok","invaliduser":[]}`
+			}
+		case "/cgi-bin/message/recall":
+			if strings.Contains(body, `"msgid":"msg-1"`) {
+				return `{"errcode":0,"errmsg":"ok"}`
+			}
+		}
+		return `{"errcode":40058,"errmsg":"invalid parameter"}`
+	})
+
+	message := NewTemplateCardMessage(1000002, NewButtonInteractionCard("task-1", "请假审批", "",
+		TemplateCardButton{Text: "同意", Key: "approve"}))
+	message.ToUser = "zhangsan|lisi"
+	result, err := a.SendMessage(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if users := result.InvalidUsers(); len(users) != 1 || users[0] != "lisi" || result.Delivered() {
+		t.Errorf("Unexpected invalid users: %v", users)
+	}
+	if result.MsgID != "msg-1" || result.ResponseCode != "code-1" {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	update := &UpdateTemplateCard{
+		UserIDs:      []string{"zhangsan"},
+		AgentID:      1000002,
+		ResponseCode: result.ResponseCode,
+		Button:       &UpdateTemplateCardButton{ReplaceName: "已同意"},
+	}
+	if _, err := a.UpdateTemplateCardMessage(update); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"userids":["zhangsan"],"agentid":1000002,"response_code":"code-1","button":{"replace_name":"已同意"}}`
+	if got := sent[len(sent)-1]; got != "/cgi-bin/message/update_template_card "+want {
+		t.Errorf("Unexpected request: %s", got)
+	}
+	update.ResponseCode = "code-2"
+	if _, err := a.UpdateTemplateCardMessage(update); !errors.Is(err, base.ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter, got %v", err)
+	}
+
+	if err := a.RecallMessage(result.MsgID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := a.RecallMessage("msg-unknown"); !errors.Is(err, base.ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter, got %v", err)
+	}
+	if len(sent) != 5 {
+		t.Errorf("Unexpected requests: %v", sent)
+	}
+}
+
+func TestUpdateTemplateCard_Validate(t *testing.T) {
+	update := UpdateTemplateCard{
+		AtAll:        1,
+		ResponseCode: "code",
+		Button:       &UpdateTemplateCardButton{ReplaceName: "已处理"},
+		TemplateCard: NewTextNoticeCard("通知", "", NewURLCardAction("https://example.com")),
+	}
+	if err := update.Validate(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	update.Button = nil
+	if err := update.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	update.AtAll = 0
+	update.ResponseCode = ""
+	var verr *MessageValidationError
+	if err := update.Validate(); !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	createTagURI   = "https://qyapi.weixin.qq.com/cgi-bin/tag/create"
+	updateTagURI   = "https://qyapi.weixin.qq.com/cgi-bin/tag/update"
+	deleteTagURI   = "https://qyapi.weixin.qq.com/cgi-bin/tag/delete"
+	getTagURI      = "https://qyapi.weixin.qq.com/cgi-bin/tag/get"
+	addTagUsersURI = "https://qyapi.weixin.qq.com/cgi-bin/tag/addtagusers"
+	delTagUsersURI = "https://qyapi.weixin.qq.com/cgi-bin/tag/deltagusers"
+	listTagURI     = "https://qyapi.weixin.qq.com/cgi-bin/tag/list"
+)
+
+// MemberTag 表示通讯录标签信息，与客户联系的企业标签 Tag 不同，其 ID 可用于 TextMessage.ToTag 等
+type MemberTag struct {
+	ID   int    `json:"tagid,omitempty"`
+	Name string `json:"tagname,omitempty"`
+}
+
+// TagUser 表示标签中的成员
+type TagUser struct {
+	UserID string `json:"userid"`
+	Name   string `json:"name"`
+}
+
+// TagDetail 表示标签的名称及其包含的成员与部门
+type TagDetail struct {
+	Name     string    `json:"tagname"`
+	Users    []TagUser `json:"userlist"`
+	PartyIDs []int64   `json:"partylist"`
+}
+
+// TagMembersError 为增加或删除标签成员时部分成员或部门无效的错误，其余成员已处理成功
+type TagMembersError struct {
+	TagID          int
+	InvalidUsers   []string
+	InvalidParties []int64
+}
+
+func (e *TagMembersError) Error() string {
+	return fmt.Sprintf("tag %d: invalid users %v, invalid parties %v", e.TagID, e.InvalidUsers, e.InvalidParties)
+}
+
+// tagMembersResp 为增加或删除标签成员的响应，invalidlist 以 | 分隔
+type tagMembersResp struct {
+	InvalidList  string  `json:"invalidlist"`
+	InvalidParty []int64 `json:"invalidparty"`
+}
+
+func (r *tagMembersResp) err(tagID int) error {
+	if r.InvalidList == "" && len(r.InvalidParty) == 0 {
+		return nil
+	}
+
+	e := &TagMembersError{TagID: tagID, InvalidParties: r.InvalidParty}
+	if r.InvalidList != "" {
+		e.InvalidUsers = strings.Split(r.InvalidList, "|")
+	}
+	return e
+}
+
+// CreateTag 方法用于创建标签，tag.ID 为 0 时由企业微信分配，创建成功后回填到 tag.ID
+func (a *API) CreateTag(tag *MemberTag) error {
+	return a.CreateTagContext(context.Background(), tag)
+}
+
+// CreateTagContext 为 CreateTag 的 context 版本
+func (a *API) CreateTagContext(ctx context.Context, tag *MemberTag) error {
+	result := &struct {
+		TagID int `json:"tagid"`
+	}{}
+	if err := a.PostJSONContext(ctx, createTagURI, nil, tag, result); err != nil {
+		return err
+	}
+
+	tag.ID = result.TagID
+	return nil
+}
+
+// UpdateTag 方法用于更新标签名称
+func (a *API) UpdateTag(tag *MemberTag) error {
+	return a.UpdateTagContext(context.Background(), tag)
+}
+
+// UpdateTagContext 为 UpdateTag 的 context 版本
+func (a *API) UpdateTagContext(ctx context.Context, tag *MemberTag) error {
+	return a.PostJSONContext(ctx, updateTagURI, nil, tag, nil)
+}
+
+// DeleteTag 方法用于删除标签
+func (a *API) DeleteTag(id int) error {
+	return a.DeleteTagContext(context.Background(), id)
+}
+
+// DeleteTagContext 为 DeleteTag 的 context 版本
+func (a *API) DeleteTagContext(ctx context.Context, id int) error {
+	qs := make(url.Values)
+	qs.Add("tagid", strconv.Itoa(id))
+	return a.GetJSONContext(ctx, deleteTagURI, qs, nil)
+}
+
+// GetTag 方法用于获取标签的成员与部门
+func (a *API) GetTag(id int) (*TagDetail, error) {
+	return a.GetTagContext(context.Background(), id)
+}
+
+// GetTagContext 为 GetTag 的 context 版本
+func (a *API) GetTagContext(ctx context.Context, id int) (*TagDetail, error) {
+	qs := make(url.Values)
+	qs.Add("tagid", strconv.Itoa(id))
+	tag := &TagDetail{}
+	err := a.GetJSONContext(ctx, getTagURI, qs, tag)
+	return tag, err
+}
+
+// AddTagUsers 方法用于增加标签成员，userIDs 与 partyIDs 不能同时为空；
+// 部分成员或部门无效时其余成员仍会加入标签，并返回 *TagMembersError
+func (a *API) AddTagUsers(id int, userIDs []string, partyIDs []int64) error {
+	return a.AddTagUsersContext(context.Background(), id, userIDs, partyIDs)
+}
+
+// AddTagUsersContext 为 AddTagUsers 的 context 版本
+func (a *API) AddTagUsersContext(ctx context.Context, id int, userIDs []string, partyIDs []int64) error {
+	return a.changeTagUsers(ctx, addTagUsersURI, id, userIDs, partyIDs)
+}
+
+// DelTagUsers 方法用于删除标签成员，userIDs 与 partyIDs 不能同时为空；
+// 部分成员或部门无效时其余成员仍会被删除，并返回 *TagMembersError
+func (a *API) DelTagUsers(id int, userIDs []string, partyIDs []int64) error {
+	return a.DelTagUsersContext(context.Background(), id, userIDs, partyIDs)
+}
+
+// DelTagUsersContext 为 DelTagUsers 的 context 版本
+func (a *API) DelTagUsersContext(ctx context.Context, id int, userIDs []string, partyIDs []int64) error {
+	return a.changeTagUsers(ctx, delTagUsersURI, id, userIDs, partyIDs)
+}
+
+func (a *API) changeTagUsers(ctx context.Context, uri string, id int, userIDs []string, partyIDs []int64) error {
+	body := &struct {
+		TagID     int      `json:"tagid"`
+		UserList  []string `json:"userlist,omitempty"`
+		PartyList []int64  `json:"partylist,omitempty"`
+	}{id, userIDs, partyIDs}
+
+	result := &tagMembersResp{}
+	if err := a.PostJSONContext(ctx, uri, nil, body, result); err != nil {
+		return err
+	}
+	return result.err(id)
+}
+
+// ListTag 方法用于获取标签列表
+func (a *API) ListTag() ([]*MemberTag, error) {
+	return a.ListTagContext(context.Background())
+}
+
+// ListTagContext 为 ListTag 的 context 版本
+func (a *API) ListTagContext(ctx context.Context) ([]*MemberTag, error) {
+	result := &struct {
+		Tags []*MemberTag `json:"taglist"`
+	}{}
+	err := a.GetJSONContext(ctx, listTagURI, nil, result)
+	return result.Tags, err
+}
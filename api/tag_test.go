@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func TestAPI_CreateTag(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		if req.URL.Path != "/cgi-bin/tag/create" || body != `{"tagname":"销售"}` {
+			t.Errorf("Unexpected request: %s %s", req.URL.Path, body)
+		}
+		return `{"errcode":0,"errmsg":"created","tagid":12}`
+	})
+
+	tag := &MemberTag{Name: "销售"}
+	if err := a.CreateTag(tag); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tag.ID != 12 {
+		t.Errorf("Expected tag id 12, got %d", tag.ID)
+	}
+}
+
+func TestAPI_GetTag(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		if req.URL.Query().Get("tagid") != "12" {
+			t.Errorf("Unexpected tagid: %s", req.URL.Query().Get("tagid"))
+		}
+		return `{"errcode":0,"errmsg":"ok","tagname":"销售","userlist":[{"userid":"zhangsan","name":"张三"}],"partylist":[2]}`
+	})
+
+	tag, err := a.GetTag(12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tag.Name != "销售" || len(tag.Users) != 1 || tag.Users[0].UserID != "zhangsan" || len(tag.PartyIDs) != 1 {
+		t.Errorf("Unexpected tag: %+v", tag)
+	}
+}
+
+func TestAPI_AddTagUsers_PartialFailure(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		if body != `{"tagid":12,"userlist":["zhangsan","lisi","wangwu"],"partylist":[2,9]}` {
+			t.Errorf("Unexpected body: %s", body)
+		}
+		return `{"errcode":0,"errmsg":"ok","invalidlist":"lisi|wangwu","invalidparty":[9]}`
+	})
+
+	err := a.AddTagUsers(12, []string{"zhangsan", "lisi", "wangwu"}, []int64{2, 9})
+	var membersErr *TagMembersError
+	if !errors.As(err, &membersErr) {
+		t.Fatalf("Expected *TagMembersError, got %v", err)
+	}
+	if membersErr.TagID != 12 || len(membersErr.InvalidUsers) != 2 || membersErr.InvalidUsers[1] != "wangwu" ||
+		len(membersErr.InvalidParties) != 1 || membersErr.InvalidParties[0] != 9 {
+		t.Errorf("Unexpected error: %+v", membersErr)
+	}
+}
+
+func TestAPI_DeleteTag_Error(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		return `{"errcode":40068,"errmsg":"invalid tagid"}`
+	})
+
+	if err := a.DeleteTag(99); !errors.Is(err, base.ErrInvalidTagID) {
+		t.Errorf("Expected ErrInvalidTagID, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 轮询异步任务结果的默认间隔，每次轮询后间隔翻倍直至最大值
+const (
+	DefaultTaskPollInterval    = time.Second
+	DefaultTaskMaxPollInterval = 30 * time.Second
+)
+
+// TaskResult 为已完成的异步任务结果，按任务类型仅其中一个结果列表有效
+type TaskResult struct {
+	JobID             string
+	Type              string
+	Total             int
+	InviteUsers       []InviteUserTaskResult
+	UpdateUsers       []UpdateUserTaskResult
+	UpdateDepartments []UpdateDepartmentTaskResult
+}
+
+// Failed 方法返回执行失败（errcode 不为 0）的行数
+func (r *TaskResult) Failed() int {
+	failed := 0
+	for _, row := range r.InviteUsers {
+		if row.ErrCode != 0 {
+			failed++
+		}
+	}
+	for _, row := range r.UpdateUsers {
+		if row.ErrCode != 0 {
+			failed++
+		}
+	}
+	for _, row := range r.UpdateDepartments {
+		if row.ErrCode != 0 {
+			failed++
+		}
+	}
+	return failed
+}
+
+// TaskWaiter 用于等待异步任务完成，在轮询 GetTaskResult 的同时，
+// 可通过 Notify 或 HandleEvent 接收 batch_job_result 回调以提前结束等待：
+//
+//	waiter := a.NewTaskWaiter()
+//	router.OnBatchJobResult(waiter.HandleEvent)
+//	result, err := waiter.Wait(ctx, jobID)
+type TaskWaiter struct {
+	api         *API
+	interval    time.Duration
+	maxInterval time.Duration
+
+	mu       sync.Mutex
+	notifies map[string]*taskNotify
+}
+
+// taskNotify 为正在等待某个任务的 Wait 调用共用的通知，waiters 为等待者数量
+type taskNotify struct {
+	ch      chan struct{}
+	waiters int
+}
+
+// NewTaskWaiter 方法用于创建 TaskWaiter 实例
+func (a *API) NewTaskWaiter() *TaskWaiter {
+	return &TaskWaiter{
+		api:         a,
+		interval:    DefaultTaskPollInterval,
+		maxInterval: DefaultTaskMaxPollInterval,
+		notifies:    make(map[string]*taskNotify),
+	}
+}
+
+// SetPollInterval 方法用于设置轮询的初始间隔与最大间隔
+func (w *TaskWaiter) SetPollInterval(interval, maxInterval time.Duration) {
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	w.interval = interval
+	w.maxInterval = maxInterval
+}
+
+// register 方法用于登记等待任务的 Wait 调用，返回其接收通知的 taskNotify
+func (w *TaskWaiter) register(jobID string) *taskNotify {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, ok := w.notifies[jobID]
+	if !ok {
+		n = &taskNotify{ch: make(chan struct{})}
+		w.notifies[jobID] = n
+	}
+	n.waiters++
+	return n
+}
+
+// unregister 方法用于在 Wait 返回时注销登记，最后一个等待者返回时删除通知
+func (w *TaskWaiter) unregister(jobID string, n *taskNotify) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n.waiters--
+	if n.waiters == 0 && w.notifies[jobID] == n {
+		delete(w.notifies, jobID)
+	}
+}
+
+// Notify 方法用于告知任务已完成，唤醒正在 Wait 该任务的调用；没有等待者时直接忽略，
+// 不会保留任何状态。Wait 开始时会先查询一次结果，因此在 Wait 之前完成的任务同样可以立即返回
+func (w *TaskWaiter) Notify(jobID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n, ok := w.notifies[jobID]; ok {
+		close(n.ch)
+		delete(w.notifies, jobID)
+	}
+}
+
+// HandleEvent 方法用于接收异步任务完成事件，可直接注册为 Router.OnBatchJobResult 的处理函数
+func (w *TaskWaiter) HandleEvent(event *RecvBatchJobResultEvent) Reply {
+	w.Notify(event.BatchJob.JobID)
+	return nil
+}
+
+// Wait 方法用于等待任务完成并返回结果，ctx 取消或超时时返回 ctx 的错误。
+// batch/getresult 在任务完成后一次返回全部行的结果，无需分页获取
+func (w *TaskWaiter) Wait(ctx context.Context, jobID string) (*TaskResult, error) {
+	n := w.register(jobID)
+	defer w.unregister(jobID, n)
+	notify := n.ch
+
+	interval := w.interval
+	for {
+		info, err := w.api.GetTaskResultContext(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if info.Status == TaskStatusFinished {
+			return newTaskResult(jobID, info)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-notify:
+			// 收到回调后立即查询一次结果，之后不再等待通知
+			timer.Stop()
+			notify = nil
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > w.maxInterval {
+			interval = w.maxInterval
+		}
+	}
+}
+
+func newTaskResult(jobID string, info AsyncTaskResultInfo) (*TaskResult, error) {
+	result := &TaskResult{JobID: jobID, Type: info.Type, Total: info.Total}
+	switch rows := info.Result.(type) {
+	case []InviteUserTaskResult:
+		result.InviteUsers = rows
+	case []UpdateUserTaskResult:
+		result.UpdateUsers = rows
+	case []UpdateDepartmentTaskResult:
+		result.UpdateDepartments = rows
+	case nil:
+	default:
+		return nil, fmt.Errorf("unknown task type: %s", info.Type)
+	}
+	return result, nil
+}
+
+// WaitTask 方法用于轮询等待异步任务完成并返回各行的执行结果，轮询间隔由 DefaultTaskPollInterval 起按指数增长；
+// 需要结合回调提前结束等待时使用 NewTaskWaiter
+func (a *API) WaitTask(ctx context.Context, jobID string) (*TaskResult, error) {
+	return a.NewTaskWaiter().Wait(ctx, jobID)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTaskWaiter_Wait(t *testing.T) {
+	var calls int32
+	a := newMockAPI(func(req *http.Request, body string) string {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return `{"errcode":0,"errmsg":"ok","status":2,"type":"replace_party","total":2,"percentage":50}`
+		}
+		return `{"errcode":0,"errmsg":"ok","status":3,"type":"replace_party","total":2,"percentage":100,"result":[
+			{"action":1,"partyid":2,"errcode":0,"errmsg":"ok"},
+			{"action":3,"partyid":3,"errcode":60003,"errmsg":"department not found"}]}`
+	})
+
+	waiter := a.NewTaskWaiter()
+	waiter.SetPollInterval(time.Millisecond, 2*time.Millisecond)
+
+	result, err := waiter.Wait(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 polls, got %d", calls)
+	}
+	if result.Type != ReplaceDepartmentTask || result.Total != 2 || len(result.UpdateDepartments) != 2 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if row := result.UpdateDepartments[1]; row.DepartmentID != 3 || row.ErrCode != 60003 {
+		t.Errorf("Unexpected row: %+v", row)
+	}
+	if result.Failed() != 1 {
+		t.Errorf("Expected 1 failed row, got %d", result.Failed())
+	}
+}
+
+func TestTaskWaiter_Notify(t *testing.T) {
+	var calls, finished int32
+	a := newMockAPI(func(req *http.Request, body string) string {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&finished) == 0 {
+			return `{"errcode":0,"errmsg":"ok","status":2,"type":"invite_user","total":1}`
+		}
+		return `{"errcode":0,"errmsg":"ok","status":3,"type":"invite_user","total":1,"result":[
+			{"userid":"zhangsan","invitetype":1,"errcode":0,"errmsg":"ok"}]}`
+	})
+
+	waiter := a.NewTaskWaiter()
+	waiter.SetPollInterval(time.Hour, time.Hour)
+
+	go func() {
+		for atomic.LoadInt32(&calls) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		atomic.StoreInt32(&finished, 1)
+		waiter.HandleEvent(&RecvBatchJobResultEvent{BatchJob: JobResultInfo{JobID: "job-2", JobType: InviteUserTask}})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := waiter.Wait(ctx, "job-2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.InviteUsers) != 1 || result.InviteUsers[0].UserID != "zhangsan" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestTaskWaiter_WaitCanceled(t *testing.T) {
+	a := newMockAPI(func(req *http.Request, body string) string {
+		return `{"errcode":0,"errmsg":"ok","status":1,"type":"sync_user"}`
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := a.WaitTask(ctx, "job-3"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTaskWaiter_NotifyWithoutWait(t *testing.T) {
+	waiter := newMockAPI(func(req *http.Request, body string) string {
+		return `{"errcode":0,"errmsg":"ok","status":3,"type":"sync_user","total":0}`
+	}).NewTaskWaiter()
+
+	// 未被等待的任务的回调不会在 waiter 中留下状态
+	for i := 0; i < 100; i++ {
+		waiter.HandleEvent(&RecvBatchJobResultEvent{BatchJob: JobResultInfo{JobID: fmt.Sprintf("job-%d", i)}})
+	}
+	if n := len(waiter.notifies); n != 0 {
+		t.Errorf("Expected no pending notifies, got %d", n)
+	}
+
+	// Wait 之前已完成的任务在首次查询时返回，返回后同样不保留状态
+	if _, err := waiter.Wait(context.Background(), "job-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := len(waiter.notifies); n != 0 {
+		t.Errorf("Expected no pending notifies, got %d", n)
+	}
+}
@@ -0,0 +1,505 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TemplateCardType 为模板卡片的类型
+type TemplateCardType string
+
+// 模板卡片类型值
+const (
+	TextNoticeCard          TemplateCardType = "text_notice"
+	NewsNoticeCard          TemplateCardType = "news_notice"
+	ButtonInteractionCard   TemplateCardType = "button_interaction"
+	VoteInteractionCard     TemplateCardType = "vote_interaction"
+	MultipleInteractionCard TemplateCardType = "multiple_interaction"
+)
+
+// 模板卡片中跳转类的类型值，用于卡片点击、引用区域、跳转指引及图文展示区域
+const (
+	CardJumpNone        = 0
+	CardJumpURL         = 1
+	CardJumpMiniprogram = 2
+)
+
+// 二级标题+文本列表中内容的类型值
+const (
+	HorizontalContentText  = 0
+	HorizontalContentURL   = 1
+	HorizontalContentMedia = 2
+	HorizontalContentUser  = 3
+)
+
+// 按钮的类型值
+const (
+	CardButtonCallback = 0
+	CardButtonURL      = 1
+)
+
+// 模板卡片字段的数量与长度限制
+const (
+	maxCardActionMenuItems     = 3
+	maxCardHorizontalContents  = 6
+	maxCardJumps               = 3
+	maxCardVerticalContents    = 4
+	maxCardButtons             = 6
+	maxCardButtonSelectOptions = 10
+	maxCardCheckboxOptions     = 20
+	maxCardSelects             = 3
+	maxCardSelectOptions       = 10
+	maxCardTaskIDBytes         = 128
+	maxCardKeyBytes            = 1024
+	minCardImageAspectRatio    = 1.3
+	maxCardImageAspectRatio    = 2.25
+)
+
+var cardTaskIDPattern = regexp.MustCompile(`^[0-9A-Za-z_\-@]+$`)
+
+// TemplateCardSource 为卡片来源样式信息，DescColor 取值 0 灰色、1 黑色、2 红色、3 绿色
+type TemplateCardSource struct {
+	IconURL   string `json:"icon_url,omitempty"`
+	Desc      string `json:"desc,omitempty"`
+	DescColor int    `json:"desc_color,omitempty"`
+}
+
+// TemplateCardMenuAction 为卡片右上角更多操作中的操作项
+type TemplateCardMenuAction struct {
+	Text string `json:"text"`
+	Key  string `json:"key"`
+}
+
+// TemplateCardActionMenu 为卡片右上角更多操作按钮，设置时须填写 TaskID
+type TemplateCardActionMenu struct {
+	Desc       string                   `json:"desc,omitempty"`
+	ActionList []TemplateCardMenuAction `json:"action_list"`
+}
+
+// TemplateCardMainTitle 为卡片的一级标题
+type TemplateCardMainTitle struct {
+	Title string `json:"title,omitempty"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+// TemplateCardQuoteArea 为卡片的引用文献样式
+type TemplateCardQuoteArea struct {
+	Type      int    `json:"type,omitempty"`
+	URL       string `json:"url,omitempty"`
+	AppID     string `json:"appid,omitempty"`
+	PagePath  string `json:"pagepath,omitempty"`
+	Title     string `json:"title,omitempty"`
+	QuoteText string `json:"quote_text,omitempty"`
+}
+
+// TemplateCardEmphasisContent 为文本通知型卡片的关键数据样式
+type TemplateCardEmphasisContent struct {
+	Title string `json:"title,omitempty"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+// TemplateCardHorizontalContent 为二级标题+文本列表中的一项，Type 决定 URL、MediaID、UserID 中哪一项有效
+type TemplateCardHorizontalContent struct {
+	Type    int    `json:"type,omitempty"`
+	KeyName string `json:"keyname"`
+	Value   string `json:"value,omitempty"`
+	URL     string `json:"url,omitempty"`
+	MediaID string `json:"media_id,omitempty"`
+	UserID  string `json:"userid,omitempty"`
+}
+
+// TemplateCardJump 为跳转指引样式中的一项
+type TemplateCardJump struct {
+	Type     int    `json:"type,omitempty"`
+	Title    string `json:"title"`
+	URL      string `json:"url,omitempty"`
+	AppID    string `json:"appid,omitempty"`
+	PagePath string `json:"pagepath,omitempty"`
+}
+
+// TemplateCardAction 为整体卡片的点击跳转事件
+type TemplateCardAction struct {
+	Type     int    `json:"type"`
+	URL      string `json:"url,omitempty"`
+	AppID    string `json:"appid,omitempty"`
+	PagePath string `json:"pagepath,omitempty"`
+}
+
+// NewURLCardAction 方法用于创建点击后跳转网页的卡片事件
+func NewURLCardAction(url string) *TemplateCardAction {
+	return &TemplateCardAction{Type: CardJumpURL, URL: url}
+}
+
+// NewMiniprogramCardAction 方法用于创建点击后跳转小程序的卡片事件
+func NewMiniprogramCardAction(appID, pagePath string) *TemplateCardAction {
+	return &TemplateCardAction{Type: CardJumpMiniprogram, AppID: appID, PagePath: pagePath}
+}
+
+// TemplateCardImage 为图文展示型卡片的图片样式，AspectRatio 取值 1.3 ~ 2.25，默认 1.3
+type TemplateCardImage struct {
+	URL         string  `json:"url"`
+	AspectRatio float64 `json:"aspect_ratio,omitempty"`
+}
+
+// TemplateCardImageTextArea 为图文展示型卡片的左图右文样式
+type TemplateCardImageTextArea struct {
+	Type     int    `json:"type,omitempty"`
+	URL      string `json:"url,omitempty"`
+	AppID    string `json:"appid,omitempty"`
+	PagePath string `json:"pagepath,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Desc     string `json:"desc,omitempty"`
+	ImageURL string `json:"image_url"`
+}
+
+// TemplateCardVerticalContent 为图文展示型卡片的二级垂直内容
+type TemplateCardVerticalContent struct {
+	Title string `json:"title"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+// TemplateCardOption 为下拉式选择器、投票选择中的选项
+type TemplateCardOption struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	IsChecked bool   `json:"is_checked,omitempty"`
+}
+
+// TemplateCardButtonSelection 为按钮交互型卡片的下拉式选择器
+type TemplateCardButtonSelection struct {
+	QuestionKey string               `json:"question_key"`
+	Title       string               `json:"title,omitempty"`
+	OptionList  []TemplateCardOption `json:"option_list"`
+	SelectedID  string               `json:"selected_id,omitempty"`
+	Disable     bool                 `json:"disable,omitempty"`
+}
+
+// TemplateCardButton 为按钮交互型卡片的按钮，Type 为 CardButtonCallback 时点击回调 Key，为 CardButtonURL 时跳转 URL；
+// Style 取值 1 ~ 4 对应不同颜色
+type TemplateCardButton struct {
+	Type  int    `json:"type,omitempty"`
+	Text  string `json:"text"`
+	Style int    `json:"style,omitempty"`
+	Key   string `json:"key,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// TemplateCardCheckbox 为投票选择型卡片的选择题，Mode 为 0 时单选、为 1 时多选
+type TemplateCardCheckbox struct {
+	QuestionKey string               `json:"question_key"`
+	OptionList  []TemplateCardOption `json:"option_list"`
+	Mode        int                  `json:"mode,omitempty"`
+	Disable     bool                 `json:"disable,omitempty"`
+}
+
+// TemplateCardSelect 为多项选择型卡片中的下拉式选择器
+type TemplateCardSelect struct {
+	QuestionKey string               `json:"question_key"`
+	Title       string               `json:"title,omitempty"`
+	SelectedID  string               `json:"selected_id,omitempty"`
+	OptionList  []TemplateCardOption `json:"option_list"`
+	Disable     bool                 `json:"disable,omitempty"`
+}
+
+// TemplateCardSubmitButton 为投票选择型及多项选择型卡片的提交按钮
+type TemplateCardSubmitButton struct {
+	Text string `json:"text"`
+	Key  string `json:"key"`
+}
+
+// TemplateCard 为模板卡片内容，CardType 决定可使用的字段：
+//
+//	text_notice          EmphasisContent、SubTitleText，须设置 CardAction
+//	news_notice          CardImage 或 ImageTextArea、VerticalContentList，须设置 CardAction
+//	button_interaction   ButtonSelection、ButtonList，须设置 TaskID
+//	vote_interaction     Checkbox、SubmitButton，须设置 TaskID
+//	multiple_interaction SelectList、SubmitButton，须设置 TaskID
+//
+// 通过 UpdateTemplateCardMessage 更新交互型卡片时，可设置 ReplaceText 及各选择器的 Disable 字段
+type TemplateCard struct {
+	CardType              TemplateCardType                `json:"card_type"`
+	Source                *TemplateCardSource             `json:"source,omitempty"`
+	ActionMenu            *TemplateCardActionMenu         `json:"action_menu,omitempty"`
+	TaskID                string                          `json:"task_id,omitempty"`
+	MainTitle             *TemplateCardMainTitle          `json:"main_title,omitempty"`
+	QuoteArea             *TemplateCardQuoteArea          `json:"quote_area,omitempty"`
+	EmphasisContent       *TemplateCardEmphasisContent    `json:"emphasis_content,omitempty"`
+	SubTitleText          string                          `json:"sub_title_text,omitempty"`
+	HorizontalContentList []TemplateCardHorizontalContent `json:"horizontal_content_list,omitempty"`
+	JumpList              []TemplateCardJump              `json:"jump_list,omitempty"`
+	CardAction            *TemplateCardAction             `json:"card_action,omitempty"`
+	CardImage             *TemplateCardImage              `json:"card_image,omitempty"`
+	ImageTextArea         *TemplateCardImageTextArea      `json:"image_text_area,omitempty"`
+	VerticalContentList   []TemplateCardVerticalContent   `json:"vertical_content_list,omitempty"`
+	ButtonSelection       *TemplateCardButtonSelection    `json:"button_selection,omitempty"`
+	ButtonList            []TemplateCardButton            `json:"button_list,omitempty"`
+	Checkbox              *TemplateCardCheckbox           `json:"checkbox,omitempty"`
+	SelectList            []TemplateCardSelect            `json:"select_list,omitempty"`
+	SubmitButton          *TemplateCardSubmitButton       `json:"submit_button,omitempty"`
+	ReplaceText           string                          `json:"replace_text,omitempty"`
+}
+
+// NewTextNoticeCard 方法用于创建文本通知型卡片
+func NewTextNoticeCard(title, desc string, action *TemplateCardAction) *TemplateCard {
+	return &TemplateCard{
+		CardType:   TextNoticeCard,
+		MainTitle:  &TemplateCardMainTitle{Title: title, Desc: desc},
+		CardAction: action,
+	}
+}
+
+// NewNewsNoticeCard 方法用于创建图文展示型卡片
+func NewNewsNoticeCard(title, desc string, image *TemplateCardImage, action *TemplateCardAction) *TemplateCard {
+	return &TemplateCard{
+		CardType:   NewsNoticeCard,
+		MainTitle:  &TemplateCardMainTitle{Title: title, Desc: desc},
+		CardImage:  image,
+		CardAction: action,
+	}
+}
+
+// NewButtonInteractionCard 方法用于创建按钮交互型卡片，taskID 用于回调时识别卡片及后续更新
+func NewButtonInteractionCard(taskID, title, desc string, buttons ...TemplateCardButton) *TemplateCard {
+	return &TemplateCard{
+		CardType:   ButtonInteractionCard,
+		TaskID:     taskID,
+		MainTitle:  &TemplateCardMainTitle{Title: title, Desc: desc},
+		ButtonList: buttons,
+	}
+}
+
+// NewVoteInteractionCard 方法用于创建投票选择型卡片
+func NewVoteInteractionCard(taskID, title, desc string, checkbox *TemplateCardCheckbox, submit *TemplateCardSubmitButton) *TemplateCard {
+	return &TemplateCard{
+		CardType:     VoteInteractionCard,
+		TaskID:       taskID,
+		MainTitle:    &TemplateCardMainTitle{Title: title, Desc: desc},
+		Checkbox:     checkbox,
+		SubmitButton: submit,
+	}
+}
+
+// NewMultipleInteractionCard 方法用于创建多项选择型卡片
+func NewMultipleInteractionCard(taskID, title, desc string, selects []TemplateCardSelect, submit *TemplateCardSubmitButton) *TemplateCard {
+	return &TemplateCard{
+		CardType:     MultipleInteractionCard,
+		TaskID:       taskID,
+		MainTitle:    &TemplateCardMainTitle{Title: title, Desc: desc},
+		SelectList:   selects,
+		SubmitButton: submit,
+	}
+}
+
+// Validate 方法用于按卡片类型校验必填字段及数量、长度限制，返回 *MessageValidationError
+func (c *TemplateCard) Validate() error {
+	v := newMessageValidator(TemplateCardMsg)
+	c.validate(v, "template_card")
+	return v.err()
+}
+
+func (c *TemplateCard) validate(v *messageValidator, prefix string) {
+	field := func(name string) string {
+		return prefix + "." + name
+	}
+
+	interaction := false
+	switch c.CardType {
+	case TextNoticeCard:
+		if (c.MainTitle == nil || c.MainTitle.Title == "") && c.SubTitleText == "" {
+			v.add(field("main_title.title"), "main_title.title or sub_title_text is required")
+		}
+		v.checkCardAction(field("card_action"), c.CardAction)
+	case NewsNoticeCard:
+		v.checkMainTitle(field("main_title"), c.MainTitle)
+		v.checkCardAction(field("card_action"), c.CardAction)
+		if c.CardImage == nil && c.ImageTextArea == nil {
+			v.add(field("card_image"), "card_image or image_text_area is required")
+		}
+		v.maxItems(field("vertical_content_list"), len(c.VerticalContentList), maxCardVerticalContents)
+	case ButtonInteractionCard:
+		interaction = true
+		v.checkMainTitle(field("main_title"), c.MainTitle)
+		v.rangeItems(field("button_list"), len(c.ButtonList), 1, maxCardButtons)
+	case VoteInteractionCard:
+		interaction = true
+		v.checkMainTitle(field("main_title"), c.MainTitle)
+		if c.Checkbox == nil {
+			v.add(field("checkbox"), "is required")
+		}
+		v.checkSubmitButton(field("submit_button"), c.SubmitButton)
+	case MultipleInteractionCard:
+		interaction = true
+		v.checkMainTitle(field("main_title"), c.MainTitle)
+		v.rangeItems(field("select_list"), len(c.SelectList), 1, maxCardSelects)
+		v.checkSubmitButton(field("submit_button"), c.SubmitButton)
+	default:
+		v.add(field("card_type"), "unknown card type %q", c.CardType)
+	}
+
+	if interaction || c.ActionMenu != nil {
+		v.require(field("task_id"), c.TaskID)
+	}
+	if c.TaskID != "" {
+		v.maxBytes(field("task_id"), c.TaskID, maxCardTaskIDBytes)
+		if !cardTaskIDPattern.MatchString(c.TaskID) {
+			v.add(field("task_id"), "only digits, letters and _-@ are allowed")
+		}
+	}
+
+	if c.Source != nil && (c.Source.DescColor < 0 || c.Source.DescColor > 3) {
+		v.add(field("source.desc_color"), "must be between 0 and 3")
+	}
+	if c.ActionMenu != nil {
+		v.rangeItems(field("action_menu.action_list"), len(c.ActionMenu.ActionList), 1, maxCardActionMenuItems)
+	}
+	if c.QuoteArea != nil {
+		v.checkJump(field("quote_area"), c.QuoteArea.Type, c.QuoteArea.URL, c.QuoteArea.AppID)
+	}
+
+	v.maxItems(field("horizontal_content_list"), len(c.HorizontalContentList), maxCardHorizontalContents)
+	for i, content := range c.HorizontalContentList {
+		name := field(fmt.Sprintf("horizontal_content_list[%d]", i))
+		v.require(name+".keyname", content.KeyName)
+		switch content.Type {
+		case HorizontalContentText:
+		case HorizontalContentURL:
+			v.require(name+".url", content.URL)
+		case HorizontalContentMedia:
+			v.require(name+".media_id", content.MediaID)
+		case HorizontalContentUser:
+			v.require(name+".userid", content.UserID)
+		default:
+			v.add(name+".type", "unknown type %d", content.Type)
+		}
+	}
+
+	v.maxItems(field("jump_list"), len(c.JumpList), maxCardJumps)
+	for i, jump := range c.JumpList {
+		name := field(fmt.Sprintf("jump_list[%d]", i))
+		v.require(name+".title", jump.Title)
+		v.checkJump(name, jump.Type, jump.URL, jump.AppID)
+	}
+
+	if c.CardImage != nil {
+		v.require(field("card_image.url"), c.CardImage.URL)
+		if r := c.CardImage.AspectRatio; r != 0 && (r < minCardImageAspectRatio || r > maxCardImageAspectRatio) {
+			v.add(field("card_image.aspect_ratio"), "must be between %.2f and %.2f", minCardImageAspectRatio, maxCardImageAspectRatio)
+		}
+	}
+	if c.ImageTextArea != nil {
+		v.require(field("image_text_area.image_url"), c.ImageTextArea.ImageURL)
+		v.checkJump(field("image_text_area"), c.ImageTextArea.Type, c.ImageTextArea.URL, c.ImageTextArea.AppID)
+	}
+
+	if s := c.ButtonSelection; s != nil {
+		v.checkKey(field("button_selection.question_key"), s.QuestionKey)
+		v.rangeItems(field("button_selection.option_list"), len(s.OptionList), 1, maxCardButtonSelectOptions)
+	}
+	for i, button := range c.ButtonList {
+		name := field(fmt.Sprintf("button_list[%d]", i))
+		v.require(name+".text", button.Text)
+		switch button.Type {
+		case CardButtonCallback:
+			v.checkKey(name+".key", button.Key)
+		case CardButtonURL:
+			v.require(name+".url", button.URL)
+		default:
+			v.add(name+".type", "unknown type %d", button.Type)
+		}
+	}
+	if cb := c.Checkbox; cb != nil {
+		v.checkKey(field("checkbox.question_key"), cb.QuestionKey)
+		v.rangeItems(field("checkbox.option_list"), len(cb.OptionList), 1, maxCardCheckboxOptions)
+		if cb.Mode != 0 && cb.Mode != 1 {
+			v.add(field("checkbox.mode"), "must be 0 or 1")
+		}
+	}
+	for i, s := range c.SelectList {
+		name := field(fmt.Sprintf("select_list[%d]", i))
+		v.checkKey(name+".question_key", s.QuestionKey)
+		v.rangeItems(name+".option_list", len(s.OptionList), 1, maxCardSelectOptions)
+	}
+}
+
+func (v *messageValidator) checkMainTitle(field string, title *TemplateCardMainTitle) {
+	if title == nil || title.Title == "" {
+		v.add(field+".title", "is required")
+	}
+}
+
+func (v *messageValidator) checkCardAction(field string, action *TemplateCardAction) {
+	if action == nil {
+		v.add(field, "is required")
+		return
+	}
+	if action.Type == CardJumpNone {
+		v.add(field+".type", "must be %d or %d", CardJumpURL, CardJumpMiniprogram)
+		return
+	}
+	v.checkJump(field, action.Type, action.URL, action.AppID)
+}
+
+func (v *messageValidator) checkJump(field string, jumpType int, url, appID string) {
+	switch jumpType {
+	case CardJumpNone:
+	case CardJumpURL:
+		v.require(field+".url", url)
+	case CardJumpMiniprogram:
+		v.require(field+".appid", appID)
+	default:
+		v.add(field+".type", "unknown type %d", jumpType)
+	}
+}
+
+func (v *messageValidator) checkSubmitButton(field string, button *TemplateCardSubmitButton) {
+	if button == nil {
+		v.add(field, "is required")
+		return
+	}
+	v.require(field+".text", button.Text)
+	v.checkKey(field+".key", button.Key)
+}
+
+func (v *messageValidator) checkKey(field, key string) {
+	v.require(field, key)
+	v.maxBytes(field, key, maxCardKeyBytes)
+}
+
+// TemplateCardMessage 为发送的模板卡片类型消息
+type TemplateCardMessage struct {
+	ToUser                 string        `json:"touser,omitempty"`
+	ToParty                string        `json:"toparty,omitempty"`
+	ToTag                  string        `json:"totag,omitempty"`
+	MsgType                MessageType   `json:"msgtype"`
+	AgentID                int64         `json:"agentid"`
+	TemplateCard           *TemplateCard `json:"template_card"`
+	EnableIDTrans          int           `json:"enable_id_trans,omitempty"`
+	EnableDuplicateCheck   int           `json:"enable_duplicate_check,omitempty"`
+	DuplicateCheckInterval int           `json:"duplicate_check_interval,omitempty"`
+}
+
+// NewTemplateCardMessage 方法用于创建模板卡片消息，接收人需另行设置
+func NewTemplateCardMessage(agentID int64, card *TemplateCard) *TemplateCardMessage {
+	return &TemplateCardMessage{
+		MsgType:      TemplateCardMsg,
+		AgentID:      agentID,
+		TemplateCard: card,
+	}
+}
+
+// Validate 方法用于校验模板卡片消息，返回 *MessageValidationError
+func (m TemplateCardMessage) Validate() error {
+	v := newMessageValidator(TemplateCardMsg)
+	v.checkReceivers(m.ToUser, m.ToParty, m.ToTag)
+	v.checkDuplicate(m.DuplicateCheckInterval)
+	m.validateContent(v)
+	return v.err()
+}
+
+func (m TemplateCardMessage) validateContent(v *messageValidator) {
+	if m.TemplateCard == nil {
+		v.add("template_card", "is required")
+	} else {
+		m.TemplateCard.validate(v, "template_card")
+	}
+}
@@ -1,85 +1,236 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"net/url"
 	"strconv"
+
+	"github.com/shengbox/wechat-qy/base"
 )
 
 const (
-	createUserURI      = "https://qyapi.weixin.qq.com/cgi-bin/user/create"
-	updateUserURI      = "https://qyapi.weixin.qq.com/cgi-bin/user/update"
-	deleteUserURI      = "https://qyapi.weixin.qq.com/cgi-bin/user/delete"
-	batchDeleteUserURI = "https://qyapi.weixin.qq.com/cgi-bin/user/batchdelete"
-	getUserURI         = "https://qyapi.weixin.qq.com/cgi-bin/user/get"
-	listSimpleUserURI  = "https://qyapi.weixin.qq.com/cgi-bin/user/simplelist"
-	listUserURI        = "https://qyapi.weixin.qq.com/cgi-bin/user/list"
-	inviteUserURI      = "https://qyapi.weixin.qq.com/cgi-bin/invite/send"
-	listMemberAuthURI  = "https://qyapi.weixin.qq.com/cgi-bin/user/list_member_auth"
+	createUserURI       = "https://qyapi.weixin.qq.com/cgi-bin/user/create"
+	updateUserURI       = "https://qyapi.weixin.qq.com/cgi-bin/user/update"
+	deleteUserURI       = "https://qyapi.weixin.qq.com/cgi-bin/user/delete"
+	batchDeleteUserURI  = "https://qyapi.weixin.qq.com/cgi-bin/user/batchdelete"
+	getUserURI          = "https://qyapi.weixin.qq.com/cgi-bin/user/get"
+	listSimpleUserURI   = "https://qyapi.weixin.qq.com/cgi-bin/user/simplelist"
+	listUserURI         = "https://qyapi.weixin.qq.com/cgi-bin/user/list"
+	inviteUserURI       = "https://qyapi.weixin.qq.com/cgi-bin/invite/send"
+	listMemberAuthURI   = "https://qyapi.weixin.qq.com/cgi-bin/user/list_member_auth"
+	getUserIDURI        = "https://qyapi.weixin.qq.com/cgi-bin/user/getuserid"
+	getUserIDByEmailURI = "https://qyapi.weixin.qq.com/cgi-bin/user/get_userid_by_email"
+	listUserIDURI       = "https://qyapi.weixin.qq.com/cgi-bin/user/list_id"
+	convertToOpenIDURI  = "https://qyapi.weixin.qq.com/cgi-bin/user/convert_to_openid"
+	convertToUserIDURI  = "https://qyapi.weixin.qq.com/cgi-bin/user/convert_to_userid"
+	authSuccURI         = "https://qyapi.weixin.qq.com/cgi-bin/user/authsucc"
 )
 
-// UserAttribute struct 为用户扩展信息
-type UserAttribute struct {
-	Name  string `json:"name"`
+// 通过邮箱获取 userid 时的邮箱类型
+const (
+	EmailTypeCorp     = 1 // 企业邮箱
+	EmailTypePersonal = 2 // 个人邮箱
+)
+
+// DefaultListUserIDLimit 为 ListUserID 每页的默认及最大数量
+const DefaultListUserIDLimit = 10000
+
+// 扩展属性的类型
+const (
+	UserAttrTypeText        = 0 // 文本
+	UserAttrTypeWeb         = 1 // 网页
+	UserAttrTypeMiniprogram = 2 // 小程序，仅用于对外属性
+)
+
+// UserAttrText 为文本类型的扩展属性
+type UserAttrText struct {
 	Value string `json:"value"`
 }
 
+// UserAttrWeb 为网页类型的扩展属性
+type UserAttrWeb struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// UserAttrMiniprogram 为小程序类型的扩展属性
+type UserAttrMiniprogram struct {
+	AppID    string `json:"appid"`
+	PagePath string `json:"pagepath,omitempty"`
+	Title    string `json:"title"`
+}
+
+// UserAttribute struct 为用户扩展信息，Type 决定 Text、Web、Miniprogram 中哪一项有效；
+// Value 为旧版仅包含名称与值的格式
+type UserAttribute struct {
+	Type        int                  `json:"type"`
+	Name        string               `json:"name"`
+	Value       string               `json:"value,omitempty"`
+	Text        *UserAttrText        `json:"text,omitempty"`
+	Web         *UserAttrWeb         `json:"web,omitempty"`
+	Miniprogram *UserAttrMiniprogram `json:"miniprogram,omitempty"`
+}
+
+// MarshalJSON 方法在 Text、Web、Miniprogram 均未设置时按旧版格式输出，仅包含 name 与 value
+func (a UserAttribute) MarshalJSON() ([]byte, error) {
+	if a.Text == nil && a.Web == nil && a.Miniprogram == nil {
+		return json.Marshal(struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}{a.Name, a.Value})
+	}
+
+	type attribute UserAttribute
+	return json.Marshal(attribute(a))
+}
+
+// NewTextAttr 方法用于创建文本类型的扩展属性
+func NewTextAttr(name, value string) *UserAttribute {
+	return &UserAttribute{Type: UserAttrTypeText, Name: name, Text: &UserAttrText{Value: value}}
+}
+
+// NewWebAttr 方法用于创建网页类型的扩展属性
+func NewWebAttr(name, url, title string) *UserAttribute {
+	return &UserAttribute{Type: UserAttrTypeWeb, Name: name, Web: &UserAttrWeb{URL: url, Title: title}}
+}
+
+// NewMiniprogramAttr 方法用于创建小程序类型的对外属性
+func NewMiniprogramAttr(name, appID, pagePath, title string) *UserAttribute {
+	return &UserAttribute{Type: UserAttrTypeMiniprogram, Name: name, Miniprogram: &UserAttrMiniprogram{AppID: appID, PagePath: pagePath, Title: title}}
+}
+
 // UserAttributes struct 为用户扩展信息列表
 type UserAttributes struct {
 	Attrs []*UserAttribute `json:"attrs,omitempty"`
 }
 
-// User struct 为企业用户信息
+// WechatChannels 为成员对外展示的视频号信息
+type WechatChannels struct {
+	Nickname string `json:"nickname,omitempty"`
+	Status   int    `json:"status,omitempty"`
+}
+
+// ExternalProfile 为成员的对外属性
+type ExternalProfile struct {
+	ExternalCorpName string           `json:"external_corp_name,omitempty"`
+	WechatChannels   *WechatChannels  `json:"wechat_channels,omitempty"`
+	ExternalAttr     []*UserAttribute `json:"external_attr,omitempty"`
+}
+
+// User struct 为企业用户信息，Order 与 IsLeaderInDept 按顺序与 DepartmentIds 一一对应，
+// IsLeaderInDept 中 1 表示为该部门的负责人
 type User struct {
-	UserID        string         `json:"userid"`
-	Name          string         `json:"name,omitempty"`
-	Alias         string         `json:"alias,omitempty"`
-	DepartmentIds []int64        `json:"department,omitempty"`
-	Position      string         `json:"position,omitempty"`
-	Mobile        string         `json:"mobile,omitempty"`
-	Email         string         `json:"email,omitempty"`
-	WeixinID      string         `json:"weixinid,omitempty"`
-	Enable        *int           `json:"enable,omitempty"`
-	Avatar        string         `json:"avatar,omitempty"`
-	Status        *int           `json:"status,omitempty"`
-	ExtAttr       UserAttributes `json:"extattr,omitempty"`
+	UserID           string           `json:"userid"`
+	OpenUserID       string           `json:"open_userid,omitempty"`
+	Name             string           `json:"name,omitempty"`
+	Alias            string           `json:"alias,omitempty"`
+	DepartmentIds    []int64          `json:"department,omitempty"`
+	Order            []int64          `json:"order,omitempty"`
+	MainDepartment   int64            `json:"main_department,omitempty"`
+	IsLeaderInDept   []int            `json:"is_leader_in_dept,omitempty"`
+	DirectLeader     []string         `json:"direct_leader,omitempty"`
+	Position         string           `json:"position,omitempty"`
+	Mobile           string           `json:"mobile,omitempty"`
+	Gender           string           `json:"gender,omitempty"`
+	Email            string           `json:"email,omitempty"`
+	BizMail          string           `json:"biz_mail,omitempty"`
+	Telephone        string           `json:"telephone,omitempty"`
+	Address          string           `json:"address,omitempty"`
+	WeixinID         string           `json:"weixinid,omitempty"`
+	Enable           *int             `json:"enable,omitempty"`
+	Avatar           string           `json:"avatar,omitempty"`
+	ThumbAvatar      string           `json:"thumb_avatar,omitempty"`
+	AvatarMediaID    string           `json:"avatar_mediaid,omitempty"`
+	QRCode           string           `json:"qr_code,omitempty"`
+	Status           *int             `json:"status,omitempty"`
+	ToInvite         *bool            `json:"to_invite,omitempty"`
+	ExtAttr          UserAttributes   `json:"extattr,omitempty"`
+	ExternalPosition string           `json:"external_position,omitempty"`
+	ExternalProfile  *ExternalProfile `json:"external_profile,omitempty"`
 }
 
 // CreateUser 方法用于创建用户
 func (a *API) CreateUser(user *User) error {
-	return a.PostJSON(createUserURI, nil, user, nil)
+	return a.CreateUserContext(context.Background(), user)
+}
+
+// CreateUserContext 为 CreateUser 的 context 版本
+func (a *API) CreateUserContext(ctx context.Context, user *User) error {
+	return a.PostJSONContext(ctx, createUserURI, nil, user, nil)
 }
 
 // UpdateUser 方法用于更新用户信息
 func (a *API) UpdateUser(user *User) error {
-	return a.PostJSON(updateUserURI, nil, user, nil)
+	return a.UpdateUserContext(context.Background(), user)
+}
+
+// UpdateUserContext 为 UpdateUser 的 context 版本
+func (a *API) UpdateUserContext(ctx context.Context, user *User) error {
+	return a.PostJSONContext(ctx, updateUserURI, nil, user, nil)
+}
+
+// UpdateUserFields 方法用于仅更新成员的部分字段，fields 的 key 为 JSON 字段名，未包含的字段不做修改
+func (a *API) UpdateUserFields(userID string, fields map[string]interface{}) error {
+	return a.UpdateUserFieldsContext(context.Background(), userID, fields)
+}
+
+// UpdateUserFieldsContext 为 UpdateUserFields 的 context 版本
+func (a *API) UpdateUserFieldsContext(ctx context.Context, userID string, fields map[string]interface{}) error {
+	body := make(map[string]interface{}, len(fields)+1)
+	for name, value := range fields {
+		body[name] = value
+	}
+	body["userid"] = userID
+	return a.PostJSONContext(ctx, updateUserURI, nil, body, nil)
 }
 
 // DeleteUser 方法用于删除某个用户
 func (a *API) DeleteUser(userID string) error {
+	return a.DeleteUserContext(context.Background(), userID)
+}
+
+// DeleteUserContext 为 DeleteUser 的 context 版本
+func (a *API) DeleteUserContext(ctx context.Context, userID string) error {
 	qs := make(url.Values)
 	qs.Add("userid", userID)
-	return a.GetJSON(deleteUserURI, qs, nil)
+	return a.GetJSONContext(ctx, deleteUserURI, qs, nil)
 }
 
 // BatchDeleteUser 方法用于批量删除用户
 func (a *API) BatchDeleteUser(userIds []string) error {
+	return a.BatchDeleteUserContext(context.Background(), userIds)
+}
+
+// BatchDeleteUserContext 为 BatchDeleteUser 的 context 版本
+func (a *API) BatchDeleteUserContext(ctx context.Context, userIds []string) error {
 	body := map[string][]string{
 		"useridlist": userIds,
 	}
-	return a.PostJSON(batchDeleteUserURI, nil, body, nil)
+	return a.PostJSONContext(ctx, batchDeleteUserURI, nil, body, nil)
 }
 
 // GetUser 方法用于获取某个用户的信息
 func (a *API) GetUser(userID string) (*User, error) {
+	return a.GetUserContext(context.Background(), userID)
+}
+
+// GetUserContext 为 GetUser 的 context 版本
+func (a *API) GetUserContext(ctx context.Context, userID string) (*User, error) {
 	qs := make(url.Values)
 	qs.Add("userid", userID)
 	user := &User{}
-	err := a.GetJSON(getUserURI, qs, user)
+	err := a.GetJSONContext(ctx, getUserURI, qs, user)
 	return user, err
 }
 
 // ListSimpleUser 方法用于获取部门成员列表（成员仅有简单信息）
 func (a *API) ListSimpleUser(departmentID int64, fetchChild *int, status *int) ([]*User, error) {
+	return a.ListSimpleUserContext(context.Background(), departmentID, fetchChild, status)
+}
+
+// ListSimpleUserContext 为 ListSimpleUser 的 context 版本
+func (a *API) ListSimpleUserContext(ctx context.Context, departmentID int64, fetchChild *int, status *int) ([]*User, error) {
 	qs := make(url.Values)
 	qs.Add("department_id", strconv.FormatInt(departmentID, 10))
 	if fetchChild != nil {
@@ -92,12 +243,17 @@ func (a *API) ListSimpleUser(departmentID int64, fetchChild *int, status *int) (
 	result := &struct {
 		UserList []*User `json:"userlist"`
 	}{}
-	err := a.GetJSON(listSimpleUserURI, qs, result)
+	err := a.GetJSONContext(ctx, listSimpleUserURI, qs, result)
 	return result.UserList, err
 }
 
 // ListUser 方法用于获取部门成员列表（成员带有详情信息）
 func (a *API) ListUser(departmentID int64, fetchChild *int, status *int) ([]*User, error) {
+	return a.ListUserContext(context.Background(), departmentID, fetchChild, status)
+}
+
+// ListUserContext 为 ListUser 的 context 版本
+func (a *API) ListUserContext(ctx context.Context, departmentID int64, fetchChild *int, status *int) ([]*User, error) {
 	qs := make(url.Values)
 	qs.Add("department_id", strconv.FormatInt(departmentID, 10))
 	if fetchChild != nil {
@@ -110,12 +266,17 @@ func (a *API) ListUser(departmentID int64, fetchChild *int, status *int) ([]*Use
 	result := &struct {
 		UserList []*User `json:"userlist"`
 	}{}
-	err := a.GetJSON(listUserURI, qs, result)
+	err := a.GetJSONContext(ctx, listUserURI, qs, result)
 	return result.UserList, err
 }
 
 // InviteUser 方法用于邀请成员关注
 func (a *API) InviteUser(userID, inviteTips string) (inviteType int, err error) {
+	return a.InviteUserContext(context.Background(), userID, inviteTips)
+}
+
+// InviteUserContext 为 InviteUser 的 context 版本
+func (a *API) InviteUserContext(ctx context.Context, userID, inviteTips string) (inviteType int, err error) {
 	body := map[string]string{
 		"userid":      userID,
 		"invite_tips": inviteTips,
@@ -123,7 +284,7 @@ func (a *API) InviteUser(userID, inviteTips string) (inviteType int, err error)
 	result := &struct {
 		Type int `json:"type"`
 	}{}
-	err = a.PostJSON(inviteUserURI, nil, body, result)
+	err = a.PostJSONContext(ctx, inviteUserURI, nil, body, result)
 	if err != nil {
 		return 0, err
 	}
@@ -131,20 +292,222 @@ func (a *API) InviteUser(userID, inviteTips string) (inviteType int, err error)
 }
 
 func (a *API) ListMemberAuth(cursor string, limit int) (result *ListMemberAuthRes, err error) {
+	return a.ListMemberAuthContext(context.Background(), cursor, limit)
+}
+
+// ListMemberAuthContext 为 ListMemberAuth 的 context 版本
+func (a *API) ListMemberAuthContext(ctx context.Context, cursor string, limit int) (result *ListMemberAuthRes, err error) {
 	body := map[string]any{
 		"cursor": cursor,
 		"limit":  limit,
 	}
 	result = &ListMemberAuthRes{}
-	err = a.PostJSON(listMemberAuthURI, nil, body, result)
+	err = a.PostJSONContext(ctx, listMemberAuthURI, nil, body, result)
 	return result, err
 }
 
 type ListMemberAuthRes struct {
-	Errcode        int64  `json:"errcode"`
-	Errmsg         string `json:"errmsg"`
+	BaseResp
 	NextCursor     string `json:"next_cursor"`
 	MemberAuthList []struct {
 		OpenUserid string `json:"open_userid"`
 	} `json:"member_auth_list"`
 }
+
+// GetUserIDByMobile 方法用于通过手机号获取成员的 userid
+func (a *API) GetUserIDByMobile(mobile string) (string, error) {
+	return a.GetUserIDByMobileContext(context.Background(), mobile)
+}
+
+// GetUserIDByMobileContext 为 GetUserIDByMobile 的 context 版本
+func (a *API) GetUserIDByMobileContext(ctx context.Context, mobile string) (string, error) {
+	body := map[string]string{
+		"mobile": mobile,
+	}
+	result := &struct {
+		UserID string `json:"userid"`
+	}{}
+	err := a.PostJSONContext(base.WithIdempotent(ctx), getUserIDURI, nil, body, result)
+	return result.UserID, err
+}
+
+// GetUserIDByEmail 方法用于通过邮箱获取成员的 userid，emailType 为 EmailTypeCorp 或 EmailTypePersonal，为 0 时默认为企业邮箱
+func (a *API) GetUserIDByEmail(email string, emailType int) (string, error) {
+	return a.GetUserIDByEmailContext(context.Background(), email, emailType)
+}
+
+// GetUserIDByEmailContext 为 GetUserIDByEmail 的 context 版本
+func (a *API) GetUserIDByEmailContext(ctx context.Context, email string, emailType int) (string, error) {
+	body := map[string]any{
+		"email": email,
+	}
+	if emailType != 0 {
+		body["email_type"] = emailType
+	}
+	result := &struct {
+		UserID string `json:"userid"`
+	}{}
+	err := a.PostJSONContext(base.WithIdempotent(ctx), getUserIDByEmailURI, nil, body, result)
+	return result.UserID, err
+}
+
+// DeptUser 为成员 userid 与其所属部门的对应关系，成员属于多个部门时会对应多条记录
+type DeptUser struct {
+	UserID     string `json:"userid"`
+	Department int64  `json:"department"`
+}
+
+// ListUserIDRes 为 ListUserID 的分页结果，NextCursor 为空时表示已是最后一页
+type ListUserIDRes struct {
+	NextCursor string      `json:"next_cursor"`
+	DeptUser   []*DeptUser `json:"dept_user"`
+}
+
+// ListUserID 方法用于分页获取企业全部成员的 userid，首次调用 cursor 为空，limit 为 0 时使用 DefaultListUserIDLimit；
+// 与其余查询类的 POST 接口一样会被视为幂等请求，按重试策略重试
+func (a *API) ListUserID(cursor string, limit int) (*ListUserIDRes, error) {
+	return a.ListUserIDContext(context.Background(), cursor, limit)
+}
+
+// ListUserIDContext 为 ListUserID 的 context 版本
+func (a *API) ListUserIDContext(ctx context.Context, cursor string, limit int) (*ListUserIDRes, error) {
+	if limit <= 0 {
+		limit = DefaultListUserIDLimit
+	}
+	body := map[string]any{
+		"limit": limit,
+	}
+	if cursor != "" {
+		body["cursor"] = cursor
+	}
+	result := &ListUserIDRes{}
+	err := a.PostJSONContext(base.WithIdempotent(ctx), listUserIDURI, nil, body, result)
+	return result, err
+}
+
+// UserIDIterator 用于按游标遍历企业的全部成员 userid，同一成员属于多个部门时只返回一次，用法与 bufio.Scanner 类似：
+//
+//	it := a.NewUserIDIterator(ctx, 0)
+//	for it.Next() {
+//		fmt.Println(it.UserID())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type UserIDIterator struct {
+	a      *API
+	ctx    context.Context
+	limit  int
+	cursor string
+	buf    []string
+	seen   map[string]bool
+	userID string
+	done   bool
+	err    error
+}
+
+// NewUserIDIterator 方法用于创建成员 userid 的迭代器，limit 为每页数量，为 0 时使用 DefaultListUserIDLimit
+func (a *API) NewUserIDIterator(ctx context.Context, limit int) *UserIDIterator {
+	return &UserIDIterator{a: a, ctx: ctx, limit: limit, seen: make(map[string]bool)}
+}
+
+// Next 方法用于前进到下一个 userid，没有更多成员或出错时返回 false
+func (it *UserIDIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.userID, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+func (it *UserIDIterator) fetch() {
+	result, err := it.a.ListUserIDContext(it.ctx, it.cursor, it.limit)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	for _, u := range result.DeptUser {
+		if !it.seen[u.UserID] {
+			it.seen[u.UserID] = true
+			it.buf = append(it.buf, u.UserID)
+		}
+	}
+	it.cursor = result.NextCursor
+	it.done = result.NextCursor == ""
+}
+
+// UserID 方法返回当前的 userid
+func (it *UserIDIterator) UserID() string {
+	return it.userID
+}
+
+// Err 方法返回遍历过程中的错误
+func (it *UserIDIterator) Err() error {
+	return it.err
+}
+
+// WalkUsers 方法用于遍历企业的全部成员并逐个获取成员详情，fn 返回错误时停止遍历并返回该错误
+func (a *API) WalkUsers(ctx context.Context, fn func(user *User) error) error {
+	it := a.NewUserIDIterator(ctx, 0)
+	for it.Next() {
+		user, err := a.GetUserContext(ctx, it.UserID())
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// ConvertToOpenID 方法用于将企业成员的 userid 转换为 openid，可用于企业支付等场景
+func (a *API) ConvertToOpenID(userID string) (string, error) {
+	return a.ConvertToOpenIDContext(context.Background(), userID)
+}
+
+// ConvertToOpenIDContext 为 ConvertToOpenID 的 context 版本
+func (a *API) ConvertToOpenIDContext(ctx context.Context, userID string) (string, error) {
+	body := map[string]string{
+		"userid": userID,
+	}
+	result := &struct {
+		OpenID string `json:"openid"`
+	}{}
+	err := a.PostJSONContext(base.WithIdempotent(ctx), convertToOpenIDURI, nil, body, result)
+	return result.OpenID, err
+}
+
+// ConvertToUserID 方法用于将 openid 转换为企业成员的 userid
+func (a *API) ConvertToUserID(openID string) (string, error) {
+	return a.ConvertToUserIDContext(context.Background(), openID)
+}
+
+// ConvertToUserIDContext 为 ConvertToUserID 的 context 版本
+func (a *API) ConvertToUserIDContext(ctx context.Context, openID string) (string, error) {
+	body := map[string]string{
+		"openid": openID,
+	}
+	result := &struct {
+		UserID string `json:"userid"`
+	}{}
+	err := a.PostJSONContext(base.WithIdempotent(ctx), convertToUserIDURI, nil, body, result)
+	return result.UserID, err
+}
+
+// AuthSucc 方法用于在成员完成企业自定义的二次验证后，通知企业微信使其成功加入企业
+func (a *API) AuthSucc(userID string) error {
+	return a.AuthSuccContext(context.Background(), userID)
+}
+
+// AuthSuccContext 为 AuthSucc 的 context 版本
+func (a *API) AuthSuccContext(ctx context.Context, userID string) error {
+	qs := make(url.Values)
+	qs.Add("userid", userID)
+	return a.GetJSONContext(ctx, authSuccURI, qs, nil)
+}
@@ -2,10 +2,17 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/shengbox/wechat-qy/base"
 )
 
 type mockRoundTripper struct {
@@ -16,6 +23,30 @@ func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	return m.roundTripFunc(req)
 }
 
+// newMockAPI 方法用于创建使用 mockRoundTripper 的 API，gettoken 以外的请求交由 handle 处理，
+// handle 的参数为请求及请求内容，返回值为响应内容
+func newMockAPI(handle func(req *http.Request, body string) string) *API {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+	a.Client.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if !strings.Contains(req.URL.Path, "/cgi-bin/gettoken") {
+				var body []byte
+				if req.Body != nil {
+					body, _ = io.ReadAll(req.Body)
+				}
+				respBody = handle(req, string(body))
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}})
+	return a
+}
+
 func TestAPI_ListMemberAuth_Success(t *testing.T) {
 	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
 
@@ -66,3 +97,244 @@ func TestAPI_ListMemberAuth_Success(t *testing.T) {
 		t.Errorf("Unexpected member auth list content: %v", res.MemberAuthList)
 	}
 }
+
+func TestAPI_GetUserContext_CanceledBeforeRetry(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var getUserCalls int32
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if strings.Contains(req.URL.Path, "/cgi-bin/user/get") {
+				atomic.AddInt32(&getUserCalls, 1)
+				// token 失效会触发重试，此时取消 ctx 应当中断重试
+				cancel()
+				respBody = `{"errcode":40001,"errmsg":"invalid credential"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	_, err := a.GetUserContext(ctx, "zhangsan")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	if calls := atomic.LoadInt32(&getUserCalls); calls != 1 {
+		t.Errorf("Expected user/get to be called once, got %d", calls)
+	}
+}
+
+func TestAPI_GetUser_TypedError(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if strings.Contains(req.URL.Path, "/cgi-bin/user/get") {
+				respBody = `{"errcode":60111,"errmsg":"userid not found, hint: [1669788321304542233998402], from ip: 127.0.0.1"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	_, err := a.GetUser("nobody")
+	if !errors.Is(err, base.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+	if errors.Is(err, base.ErrUserExists) {
+		t.Error("Expected err not to match ErrUserExists")
+	}
+
+	var apiErr *base.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *base.Error, got %T", err)
+	}
+	if hint := apiErr.Hint(); hint != "1669788321304542233998402" {
+		t.Errorf("Unexpected hint: %s", hint)
+	}
+}
+
+func TestAPI_UserIDIterator(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	pages := map[string]string{
+		"":        `{"errcode":0,"errmsg":"ok","next_cursor":"cursor2","dept_user":[{"userid":"zhangsan","department":1},{"userid":"lisi","department":1}]}`,
+		"cursor2": `{"errcode":0,"errmsg":"ok","next_cursor":"cursor3","dept_user":[]}`,
+		"cursor3": `{"errcode":0,"errmsg":"ok","next_cursor":"","dept_user":[{"userid":"lisi","department":2},{"userid":"wangwu","department":2}]}`,
+	}
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if strings.Contains(req.URL.Path, "/cgi-bin/user/list_id") {
+				body := &struct {
+					Cursor string `json:"cursor"`
+					Limit  int    `json:"limit"`
+				}{}
+				data, _ := io.ReadAll(req.Body)
+				if err := json.Unmarshal(data, body); err != nil || body.Limit != 2 {
+					t.Errorf("Unexpected request body: %s", data)
+				}
+				respBody = pages[body.Cursor]
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	var userIDs []string
+	it := a.NewUserIDIterator(context.Background(), 2)
+	for it.Next() {
+		userIDs = append(userIDs, it.UserID())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(userIDs, ",") != "zhangsan,lisi,wangwu" {
+		t.Errorf("Unexpected userids: %v", userIDs)
+	}
+}
+
+func TestAPI_UserIDIterator_Error(t *testing.T) {
+	a := New("mockCorpID", "mockCorpSecret", "mockToken", "mockAESKey")
+
+	mockTransport := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			respBody := `{"access_token":"valid-token","expires_in":7200}`
+			if strings.Contains(req.URL.Path, "/cgi-bin/user/list_id") {
+				respBody = `{"errcode":48002,"errmsg":"api forbidden"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	a.Client.SetHTTPClient(&http.Client{Transport: mockTransport})
+
+	it := a.NewUserIDIterator(context.Background(), 0)
+	if it.Next() {
+		t.Fatal("Expected no userid")
+	}
+	if !errors.Is(it.Err(), base.ErrNoPrivilege) {
+		t.Errorf("Expected ErrNoPrivilege, got %v", it.Err())
+	}
+}
+
+func TestUser_JSONRoundTrip(t *testing.T) {
+	data := `{
+		"userid": "zhangsan",
+		"open_userid": "woAJ2GCAAAXtWyujaWJHDDGi0mACHAAA",
+		"name": "张三",
+		"alias": "jackzhang",
+		"department": [1, 2],
+		"order": [10, 40],
+		"main_department": 1,
+		"is_leader_in_dept": [1, 0],
+		"direct_leader": ["lisi"],
+		"position": "后台工程师",
+		"mobile": "13800000000",
+		"gender": "1",
+		"email": "zhangsan@gzdev.com",
+		"biz_mail": "zhangsan@qyycs2.wecom.work",
+		"telephone": "020-123456",
+		"address": "广州市海珠区新港中路",
+		"avatar": "http://wx.qlogo.cn/mmopen/ajNVdqHZLLA3WJ6DSZUfiakYe37PKnQhBIeOQBO4czqrnZDS79FH5Wm5m4X69TBicnHFlhiafvDwklOpZeXYQQ2icg/0",
+		"thumb_avatar": "http://wx.qlogo.cn/mmopen/ajNVdqHZLLA3WJ6DSZUfiakYe37PKnQhBIeOQBO4czqrnZDS79FH5Wm5m4X69TBicnHFlhiafvDwklOpZeXYQQ2icg/100",
+		"qr_code": "https://open.work.weixin.qq.com/wwopen/userQRCode?vcode=xxx",
+		"status": 1,
+		"enable": 1,
+		"extattr": {"attrs": [
+			{"type": 0, "name": "文本名称", "text": {"value": "文本"}},
+			{"type": 1, "name": "网页名称", "web": {"url": "http://www.test.com", "title": "标题"}}
+		]},
+		"external_position": "高级工程师",
+		"external_profile": {
+			"external_corp_name": "企业简称",
+			"wechat_channels": {"nickname": "视频号名称", "status": 1},
+			"external_attr": [
+				{"type": 0, "name": "文本名称", "text": {"value": "文本"}},
+				{"type": 1, "name": "网页名称", "web": {"url": "http://www.test.com", "title": "标题"}},
+				{"type": 2, "name": "测试app", "miniprogram": {"appid": "wx8bd80126147dFAKE", "pagepath": "/index", "title": "my miniprogram"}}
+			]
+		}
+	}`
+
+	user := &User{}
+	if err := json.Unmarshal([]byte(data), user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.MainDepartment != 1 || user.IsLeaderInDept[0] != 1 || user.DirectLeader[0] != "lisi" || user.BizMail == "" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if attr := user.ExternalProfile.ExternalAttr[2]; attr.Type != UserAttrTypeMiniprogram || attr.Miniprogram.AppID != "wx8bd80126147dFAKE" {
+		t.Errorf("Unexpected external attr: %+v", attr)
+	}
+
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var want, got interface{}
+	json.Unmarshal([]byte(data), &want)
+	json.Unmarshal(encoded, &got)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Round trip mismatch:\nwant %v\ngot  %v", want, got)
+	}
+}
+
+func TestUser_MarshalAttrs(t *testing.T) {
+	user := &User{
+		UserID:          "zhangsan",
+		ExtAttr:         UserAttributes{Attrs: []*UserAttribute{NewTextAttr("工号", "1001")}},
+		ExternalProfile: &ExternalProfile{ExternalAttr: []*UserAttribute{NewWebAttr("官网", "https://example.com", "官网")}},
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"userid":"zhangsan","extattr":{"attrs":[{"type":0,"name":"工号","text":{"value":"1001"}}]},` +
+		`"external_profile":{"external_attr":[{"type":1,"name":"官网","web":{"url":"https://example.com","title":"官网"}}]}}`
+	if string(data) != want {
+		t.Errorf("Unexpected json: %s", data)
+	}
+	// 旧版仅包含名称与值的属性按原格式输出
+	user = &User{UserID: "lisi", ExtAttr: UserAttributes{Attrs: []*UserAttribute{{Name: "工号", Value: "1002"}, {Name: "爱好"}}}}
+	if data, err = json.Marshal(user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = `{"userid":"lisi","extattr":{"attrs":[{"name":"工号","value":"1002"},{"name":"爱好","value":""}]}}`
+	if string(data) != want {
+		t.Errorf("Unexpected json: %s", data)
+	}
+
+	var decoded User
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attr := decoded.ExtAttr.Attrs[0]; attr.Name != "工号" || attr.Value != "1002" || attr.Text != nil {
+		t.Errorf("Unexpected attr: %+v", attr)
+	}
+}
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"errors"
+	"context"
 )
 
 const (
@@ -80,6 +80,11 @@ type Property struct {
 }
 
 func (a *API) Copytemplate(openTemplateId string) (string, error) {
+	return a.CopytemplateContext(context.Background(), openTemplateId)
+}
+
+// CopytemplateContext 为 Copytemplate 的 context 版本
+func (a *API) CopytemplateContext(ctx context.Context, openTemplateId string) (string, error) {
 	result := &struct {
 		BaseResp   `json:",inline"`
 		TemplateId string `json:"template_id"`
@@ -87,48 +92,63 @@ func (a *API) Copytemplate(openTemplateId string) (string, error) {
 	body := map[string]string{
 		"open_template_id": openTemplateId,
 	}
-	err := a.PostJSON(copytemplateURI, nil, body, result)
+	err := a.PostJSONContext(ctx, copytemplateURI, nil, body, result)
 	if err != nil {
 		return "", err
 	}
-	if result.Errcode > 0 {
-		return "", errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return "", err
 	}
 	return result.TemplateId, nil
 }
 
 // 获取模板详情
 func (a *API) GetTemplateDetail(templateId string) (*TemplateDetailObj, error) {
+	return a.GetTemplateDetailContext(context.Background(), templateId)
+}
+
+// GetTemplateDetailContext 为 GetTemplateDetail 的 context 版本
+func (a *API) GetTemplateDetailContext(ctx context.Context, templateId string) (*TemplateDetailObj, error) {
 	result := &TemplateDetailObj{}
 	body := map[string]string{
 		"template_id": templateId,
 	}
-	err := a.PostJSON(gettemplatedetailURI, nil, body, result)
+	err := a.PostJSONContext(ctx, gettemplatedetailURI, nil, body, result)
 	return result, err
 }
 
 // 提交申请
 func (a *API) Applyevent(body ApplyObj) (string, error) {
+	return a.ApplyeventContext(context.Background(), body)
+}
+
+// ApplyeventContext 为 Applyevent 的 context 版本
+func (a *API) ApplyeventContext(ctx context.Context, body ApplyObj) (string, error) {
 	result := &struct {
 		BaseResp `json:",inline"`
 		SpNO     string `json:"sp_no"`
 	}{}
-	err := a.PostJSON(applyeventURI, nil, body, result)
+	err := a.PostJSONContext(ctx, applyeventURI, nil, body, result)
 	if err != nil {
 		return "", err
 	}
-	if result.Errcode > 0 {
-		return "", errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return "", err
 	}
 	return result.SpNO, nil
 }
 
 func (a *API) GetApprovalInfo(body ApprovalReq) ([]string, error) {
+	return a.GetApprovalInfoContext(context.Background(), body)
+}
+
+// GetApprovalInfoContext 为 GetApprovalInfo 的 context 版本
+func (a *API) GetApprovalInfoContext(ctx context.Context, body ApprovalReq) ([]string, error) {
 	result := &struct {
 		BaseResp `json:",inline"`
 		SpNOList []string `json:"sp_no_list"`
 	}{}
-	err := a.PostJSON(getapprovalinfoURI, nil, body, result)
+	err := a.PostJSONContext(ctx, getapprovalinfoURI, nil, body, result)
 	if err != nil {
 		return nil, err
 	}
@@ -149,10 +169,15 @@ type Filter struct {
 }
 
 func (a *API) GetApprovalDetail(spNO string) (*map[string]interface{}, error) {
+	return a.GetApprovalDetailContext(context.Background(), spNO)
+}
+
+// GetApprovalDetailContext 为 GetApprovalDetail 的 context 版本
+func (a *API) GetApprovalDetailContext(ctx context.Context, spNO string) (*map[string]interface{}, error) {
 	result := &map[string]interface{}{}
 	body := map[string]string{
 		"sp_no": spNO,
 	}
-	err := a.PostJSON(getapprovaldetailURI, nil, body, result)
+	err := a.PostJSONContext(ctx, getapprovaldetailURI, nil, body, result)
 	return result, err
 }
@@ -0,0 +1,85 @@
+package base
+
+import (
+	"io"
+	"net/http"
+)
+
+// URLVerifier 为回调模式中验证回调 URL 有效性需要实现的接口
+type URLVerifier interface {
+	VerifyURL(signature, timestamp, nonce, echostr string) (string, error)
+}
+
+// CallbackReceiver 同时支持回调 URL 验证与消息接收
+type CallbackReceiver interface {
+	RecvHandler
+	URLVerifier
+}
+
+// RecvMessageFunc 用于处理回调解析得到的消息或事件，返回的 reply 为需要被动响应的明文 XML，
+// reply 为 nil 时仅响应空包
+type RecvMessageFunc func(r *http.Request, message interface{}) (reply []byte, err error)
+
+// CallbackHandler 为回调地址的 http.Handler 实现，
+// GET 请求用于回调 URL 验证，POST 请求用于接收消息和事件
+type CallbackHandler struct {
+	receiver  CallbackReceiver
+	onMessage RecvMessageFunc
+}
+
+// NewCallbackHandler 方法用于创建 CallbackHandler 实例
+func NewCallbackHandler(receiver CallbackReceiver, onMessage RecvMessageFunc) *CallbackHandler {
+	return &CallbackHandler{receiver: receiver, onMessage: onMessage}
+}
+
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	signature, timestamp, nonce := q.Get("msg_signature"), q.Get("timestamp"), q.Get("nonce")
+
+	switch r.Method {
+	case http.MethodGet:
+		echo, err := h.receiver.VerifyURL(signature, timestamp, nonce, q.Get("echostr"))
+		if err != nil {
+			GetLogger().Printf("verify callback url failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, echo)
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		message, err := h.receiver.Parse(body, signature, timestamp, nonce)
+		if err != nil {
+			GetLogger().Printf("parse callback message failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var reply []byte
+		if h.onMessage != nil {
+			if reply, err = h.onMessage(r, message); err != nil {
+				GetLogger().Printf("handle callback message failed: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if reply == nil {
+			return
+		}
+
+		resp, err := h.receiver.Response(reply)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write(resp)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
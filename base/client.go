@@ -2,7 +2,7 @@ package base
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"io"
 	"mime"
 	"mime/multipart"
@@ -16,11 +16,21 @@ type Retrier interface {
 	Retriable(url string, body []byte) (retriable bool, newURL string, err error)
 }
 
+// ContextRetrier 为支持 context 的 Retrier，实现该接口后刷新 token 时会沿用请求的 ctx
+type ContextRetrier interface {
+	RetriableContext(ctx context.Context, url string, body []byte) (retriable bool, newURL string, err error)
+}
+
 // Client 封装了公共的请求方法
 type Client struct {
-	httpClient *http.Client
-	api        interface{}
-	BaseURI    string // Custom proxy BaseURI
+	httpClient   *http.Client
+	api          interface{}
+	retryPolicy  *RetryPolicy
+	rateLimiter  *RateLimiter
+	corpScope    string
+	appScope     string
+	interceptors []Interceptor
+	BaseURI      string // Custom proxy BaseURI
 }
 
 // NewClient 方法用于创建 Client 实例
@@ -29,7 +39,8 @@ func NewClient(api interface{}) *Client {
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		api: api,
+		api:         api,
+		retryPolicy: DefaultRetryPolicy,
 	}
 }
 
@@ -57,80 +68,68 @@ func (c *Client) SetTimeout(d time.Duration) {
 	c.httpClient.Timeout = d
 }
 
-// GetHTTPClient 返回当前的 http.Client
-func (c *Client) GetHTTPClient() *http.Client {
-	return c.httpClient
+// SetRetryPolicy 允许用户设置自定义的重试策略，为 nil 时仅在 token 失效时重试
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
 }
 
+// SetRateLimiter 允许用户设置频率限制器，corp 与 app 分别为企业与应用的标识，
+// 用于区分企业级与应用级的配额，为 nil 时不限流
+func (c *Client) SetRateLimiter(limiter *RateLimiter, corp, app string) {
+	c.rateLimiter = limiter
+	c.corpScope = corp
+	c.appScope = app
+}
 
-// GetJSON 方法用于发起 JSON GET 请求
-func (c *Client) GetJSON(url string) ([]byte, error) {
-	reqURL := c.rewriteURL(url)
-	hasRetried := false
-	retriable := false
-RETRY:
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return nil, err
-	}
+// Use 方法用于添加拦截器，BeforeRequest 按添加顺序调用，AfterResponse 按相反顺序调用
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.interceptors = append(c.interceptors, interceptors...)
+}
 
-	body, err := c.request(req, map[string]string{"Content-Type": "application/json"})
-	if err != nil {
-		return nil, err
-	}
+// GetHTTPClient 返回当前的 http.Client
+func (c *Client) GetHTTPClient() *http.Client {
+	return c.httpClient
+}
 
-	switch c.api.(type) {
+func (c *Client) retriable(ctx context.Context, url string, body []byte) (bool, string, error) {
+	switch api := c.api.(type) {
+	case ContextRetrier:
+		return api.RetriableContext(ctx, url, body)
 	case Retrier:
-		api := c.api.(Retrier)
-		retriable, reqURL, err = api.Retriable(url, body)
-		if err != nil {
-			return nil, err
-		}
+		return api.Retriable(url, body)
 	}
+	return false, "", nil
+}
 
-	if !hasRetried && retriable {
-		hasRetried = true
-		goto RETRY
-	}
+// GetJSON 方法用于发起 JSON GET 请求
+func (c *Client) GetJSON(url string) ([]byte, error) {
+	return c.GetJSONContext(context.Background(), url)
+}
 
-	return body, nil
+// GetJSONContext 为 GetJSON 的 context 版本，ctx 被取消时会中断请求及重试
+func (c *Client) GetJSONContext(ctx context.Context, url string) ([]byte, error) {
+	_, body, err := c.execute(ctx, http.MethodGet, url, nil, "application/json", false)
+	return body, err
 }
 
 // PostJSON 方法用于发起 JSON POST 请求
 func (c *Client) PostJSON(url string, data []byte) ([]byte, error) {
-	reqURL := c.rewriteURL(url)
-	hasRetried := false
-	retriable := false
-RETRY:
-	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-
-	body, err := c.request(req, map[string]string{"Content-Type": "application/json"})
-	if err != nil {
-		return nil, err
-	}
-
-	switch c.api.(type) {
-	case Retrier:
-		api := c.api.(Retrier)
-		retriable, reqURL, err = api.Retriable(url, body)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if !hasRetried && retriable {
-		hasRetried = true
-		goto RETRY
-	}
+	return c.PostJSONContext(context.Background(), url, data)
+}
 
-	return body, nil
+// PostJSONContext 为 PostJSON 的 context 版本，ctx 被取消时会中断请求及重试
+func (c *Client) PostJSONContext(ctx context.Context, url string, data []byte) ([]byte, error) {
+	_, body, err := c.execute(ctx, http.MethodPost, url, data, "application/json", false)
+	return body, err
 }
 
 // PostMultipart 方法用于发起 multipart/form-data POST 请求
 func (c *Client) PostMultipart(url, fieldname, filename string, dataReader io.Reader) ([]byte, error) {
+	return c.PostMultipartContext(context.Background(), url, fieldname, filename, dataReader)
+}
+
+// PostMultipartContext 为 PostMultipart 的 context 版本，ctx 被取消时会中断请求及重试
+func (c *Client) PostMultipartContext(ctx context.Context, url, fieldname, filename string, dataReader io.Reader) ([]byte, error) {
 	bodyBuf := new(bytes.Buffer)
 	multipartWriter := multipart.NewWriter(bodyBuf)
 
@@ -147,107 +146,143 @@ func (c *Client) PostMultipart(url, fieldname, filename string, dataReader io.Re
 		return nil, err
 	}
 
-	bodyBytes := bodyBuf.Bytes()
+	_, body, err := c.execute(ctx, http.MethodPost, url, bodyBuf.Bytes(), multipartWriter.FormDataContentType(), false)
+	return body, err
+}
+
+// GetMedia 方法专用于从微信服务器获取媒体文件
+func (c *Client) GetMedia(url string) (*http.Response, error) {
+	return c.GetMediaContext(context.Background(), url)
+}
 
+// GetMediaContext 为 GetMedia 的 context 版本，ctx 被取消时会中断请求及重试
+func (c *Client) GetMediaContext(ctx context.Context, url string) (*http.Response, error) {
+	resp, _, err := c.execute(ctx, http.MethodGet, url, nil, "", true)
+	return resp, err
+}
+
+// execute 方法发起请求并处理重试：token 失效时刷新 token 后重试一次，
+// 其余错误按重试策略重试。media 为 true 时非 JSON 的响应直接返回 *http.Response
+func (c *Client) execute(ctx context.Context, method, url string, data []byte, contentType string, media bool) (*http.Response, []byte, error) {
 	reqURL := c.rewriteURL(url)
+	idempotent := c.retryPolicy != nil && c.retryPolicy.idempotent(ctx, method, reqURL)
 	hasRetried := false
-	retriable := false
-RETRY:
-	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, err
-	}
-
-	req.ContentLength = int64(len(bodyBytes))
+	calls := 0
+
+	for attempt := 1; ; attempt++ {
+		calls++
+		if c.rateLimiter != nil {
+			if err := c.rateLimiter.Wait(ctx, c.corpScope, c.appScope, url); err != nil {
+				return nil, nil, err
+			}
+		}
 
-	body, err := c.request(req, map[string]string{
-		"Content-Type": multipartWriter.FormDataContentType(),
-	})
-	if err != nil {
-		return nil, err
-	}
+		resp, body, err := c.attempt(ctx, calls, method, reqURL, data, contentType, media)
+		if resp != nil {
+			return resp, nil, nil
+		}
 
-	switch c.api.(type) {
-	case Retrier:
-		api := c.api.(Retrier)
-		retriable, reqURL, err = api.Retriable(url, body)
-		if err != nil {
-			return nil, err
+		if err == nil {
+			var retriable bool
+			var newURL string
+			retriable, newURL, err = c.retriable(ctx, url, body)
+			if err == nil && retriable && !hasRetried {
+				if err = ctx.Err(); err != nil {
+					return nil, nil, err
+				}
+				hasRetried = true
+				reqURL = c.rewriteURL(newURL)
+				attempt--
+				continue
+			}
+			if err == nil {
+				err = CheckError(body)
+			}
+			if err == nil {
+				return nil, body, nil
+			}
 		}
-	}
 
-	if !hasRetried && retriable {
-		hasRetried = true
-		goto RETRY
+		if !c.retryPolicy.shouldRetry(ctx, attempt, idempotent, err) {
+			return nil, body, err
+		}
+		GetLogger().Printf("request %s failed (attempt %d): %v, retrying", method, attempt, redactError(err))
+		if err = c.retryPolicy.wait(ctx, attempt); err != nil {
+			return nil, body, err
+		}
 	}
-
-	return body, nil
 }
 
-// GetMedia 方法专用于从微信服务器获取媒体文件
-func (c *Client) GetMedia(url string) (*http.Response, error) {
-	reqURL := c.rewriteURL(url)
-	hasRetried := false
-	retriable := false
-RETRY:
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return nil, err
+// attempt 方法发起一次请求，media 为 true 且响应不是 JSON 时返回未读取的 *http.Response
+func (c *Client) attempt(ctx context.Context, n int, method, reqURL string, data []byte, contentType string, media bool) (resp *http.Response, body []byte, err error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
 	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed, status[%d]", resp.StatusCode)
+		return nil, nil, err
 	}
-
-	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
-	if contentType != "text/plain" && contentType != "application/json" {
-		return resp, nil
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
 	}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	info := &CallInfo{
+		Method:   method,
+		URL:      reqURL,
+		Endpoint: req.URL.Path,
+		Attempt:  n,
 	}
-
-	switch c.api.(type) {
-	case Retrier:
-		api := c.api.(Retrier)
-		retriable, reqURL, err = api.Retriable(url, body)
+	// reqs[i] 为第 i 个拦截器返回的请求，AfterResponse 时传回给同一个拦截器
+	reqs := make([]*http.Request, len(c.interceptors))
+	for i, interceptor := range c.interceptors {
+		next, err := interceptor.BeforeRequest(req, info)
 		if err != nil {
-			return nil, err
+			c.afterResponse(reqs, info, i, err)
+			return nil, nil, err
 		}
+		if next != nil {
+			req = next
+		}
+		reqs[i] = req
 	}
 
-	if !hasRetried && retriable {
-		hasRetried = true
-		goto RETRY
-	}
-
-	return nil, nil
-}
-
-func (c *Client) request(req *http.Request, headers map[string]string) ([]byte, error) {
-	header := make(http.Header)
-	for key, val := range headers {
-		header.Set(key, val)
-	}
-	req.Header = header
+	start := time.Now()
+	defer func() {
+		info.Duration = time.Since(start)
+		info.Body = body
+		info.ErrCode, info.ErrMsg = parseErrCode(body)
+		c.afterResponse(reqs, info, len(c.interceptors), err)
+	}()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err = c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	info.StatusCode = resp.StatusCode
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed, status[%d]", resp.StatusCode)
+		resp.Body.Close()
+		return nil, nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if media {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mediaType != "text/plain" && mediaType != "application/json" {
+			return resp, nil, nil
+		}
 	}
 
 	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
+	return nil, body, err
+}
+
+// afterResponse 方法按相反顺序调用前 n 个拦截器的 AfterResponse，拦截器看到的错误已脱敏
+func (c *Client) afterResponse(reqs []*http.Request, info *CallInfo, n int, err error) {
+	info.Err = redactError(err)
+	for i := n - 1; i >= 0; i-- {
+		c.interceptors[i].AfterResponse(reqs[i], info)
+	}
 }
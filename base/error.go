@@ -1,17 +1,59 @@
 package base
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
 
 // 错误返回码
 const (
-	ErrCodeOk                = 0
-	ErrCodeTokenInvalid      = 40001
-	ErrCodeTokenTimeout      = 42001
-	ErrCodeSuiteTokenTimeout = 42004
-	ErrCodeSuiteTokenInvalid = 42009
-	ErrCodeSuiteTokenFailure = 48003
+	ErrCodeSystemBusy         = -1
+	ErrCodeOk                 = 0
+	ErrCodeTokenInvalid       = 40001
+	ErrCodeInvalidUserID      = 40003
+	ErrCodeAccessTokenInvalid = 40014
+	ErrCodeInvalidParameter   = 40058
+	ErrCodeInvalidTagID       = 40068
+	ErrCodeTokenTimeout       = 42001
+	ErrCodeSuiteTokenTimeout  = 42004
+	ErrCodeSuiteTokenInvalid  = 42009
+	ErrCodeFrequencyLimit     = 45009
+	ErrCodeConcurrencyLimit   = 45033
+	ErrCodeNoPrivilege        = 48002
+	ErrCodeSuiteTokenFailure  = 48003
+	ErrCodeContactNoPrivilege = 60011
+	ErrCodeIPNotAllowed       = 60020
+	ErrCodeUserExists         = 60102
+	ErrCodeUserNotFound       = 60111
+	ErrCodeInvalidDepartment  = 60123
+	ErrCodeInvalidRecipients  = 81013
+	ErrCodeAppChatNotFound    = 86003
+)
+
+// 常见错误，可通过 errors.Is 判断接口返回的错误码，如 errors.Is(err, base.ErrFrequencyLimit)
+var (
+	ErrSystemBusy         = &Error{ErrCode: ErrCodeSystemBusy, ErrMsg: "system busy"}
+	ErrTokenInvalid       = &Error{ErrCode: ErrCodeTokenInvalid, ErrMsg: "invalid credential"}
+	ErrInvalidUserID      = &Error{ErrCode: ErrCodeInvalidUserID, ErrMsg: "invalid userid"}
+	ErrAccessTokenInvalid = &Error{ErrCode: ErrCodeAccessTokenInvalid, ErrMsg: "invalid access_token"}
+	ErrInvalidParameter   = &Error{ErrCode: ErrCodeInvalidParameter, ErrMsg: "invalid parameter"}
+	ErrInvalidTagID       = &Error{ErrCode: ErrCodeInvalidTagID, ErrMsg: "invalid tagid"}
+	ErrTokenTimeout       = &Error{ErrCode: ErrCodeTokenTimeout, ErrMsg: "access_token expired"}
+	ErrFrequencyLimit     = &Error{ErrCode: ErrCodeFrequencyLimit, ErrMsg: "api freq out of limit"}
+	ErrConcurrencyLimit   = &Error{ErrCode: ErrCodeConcurrencyLimit, ErrMsg: "api concurrent out of limit"}
+	ErrNoPrivilege        = &Error{ErrCode: ErrCodeNoPrivilege, ErrMsg: "api forbidden"}
+	ErrContactNoPrivilege = &Error{ErrCode: ErrCodeContactNoPrivilege, ErrMsg: "no privilege to access/modify contact/party/agent"}
+	ErrIPNotAllowed       = &Error{ErrCode: ErrCodeIPNotAllowed, ErrMsg: "not allow to access from your ip"}
+	ErrUserExists         = &Error{ErrCode: ErrCodeUserExists, ErrMsg: "userid existed"}
+	ErrUserNotFound       = &Error{ErrCode: ErrCodeUserNotFound, ErrMsg: "userid not found"}
+	ErrInvalidDepartment  = &Error{ErrCode: ErrCodeInvalidDepartment, ErrMsg: "invalid party id"}
+	ErrInvalidRecipients  = &Error{ErrCode: ErrCodeInvalidRecipients, ErrMsg: "all recipients are invalid"}
+	ErrAppChatNotFound    = &Error{ErrCode: ErrCodeAppChatNotFound, ErrMsg: "chat not exists"}
 )
 
+var hintPattern = regexp.MustCompile(`hint: \[([^\]]+)\]`)
+
 // Error 为 API 调用失败的响应内容
 type Error struct {
 	ErrCode int    `json:"errcode"`
@@ -21,3 +63,44 @@ type Error struct {
 func (e *Error) Error() string {
 	return fmt.Sprintf("errcode: %d, errmsg: %s", e.ErrCode, e.ErrMsg)
 }
+
+// Is 方法使 errors.Is 可以按错误码匹配，如 errors.Is(err, base.ErrUserNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.ErrCode == e.ErrCode
+}
+
+// Hint 方法返回 errmsg 中的 hint，即企业微信本次请求的唯一标识，可用于向企业微信反馈问题
+func (e *Error) Hint() string {
+	if m := hintPattern.FindStringSubmatch(e.ErrMsg); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// BaseResp 为接口响应的公共字段
+type BaseResp struct {
+	Errcode int    `json:"errcode"`
+	Errmsg  string `json:"errmsg"`
+}
+
+// Err 方法在 errcode 不为 0 时返回对应的 *Error，否则返回 nil
+func (r BaseResp) Err() error {
+	if r.Errcode == ErrCodeOk {
+		return nil
+	}
+	return &Error{ErrCode: r.Errcode, ErrMsg: r.Errmsg}
+}
+
+// CheckError 方法用于检查接口响应内容中的 errcode，不为 0 时返回对应的 *Error，
+// 响应内容不是 JSON 或不包含 errcode 时返回 nil
+func CheckError(body []byte) error {
+	result := &struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	if err := json.Unmarshal(body, result); err != nil || result.ErrCode == nil {
+		return nil
+	}
+	return BaseResp{Errcode: *result.ErrCode, Errmsg: result.ErrMsg}.Err()
+}
@@ -0,0 +1,130 @@
+package base
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sensitiveParams 为日志中需要脱敏的查询参数，key 为群机器人 webhook 的密钥
+var sensitiveParams = []string{"access_token", "suite_access_token", "provider_access_token", "corpsecret", "provider_secret", "key"}
+
+// CallInfo 描述一次 HTTP 调用，BeforeRequest 时仅请求相关字段有值，
+// AfterResponse 时包含响应状态、errcode、耗时及错误
+type CallInfo struct {
+	Method   string
+	URL      string // 请求地址，包含 access_token 等敏感参数，输出日志时请使用 RedactURL
+	Endpoint string // 接口路径，如 /cgi-bin/user/get
+	Attempt  int    // 第几次尝试，token 失效或按重试策略重试时递增
+
+	StatusCode int
+	ErrCode    int
+	ErrMsg     string
+	Body       []byte // 响应内容，获取媒体文件时为 nil
+	Duration   time.Duration
+	Err        error // 网络错误或非 200 的状态码，其中的 URL 已脱敏，errcode 不为 0 不视为 Err
+}
+
+// Interceptor 为 Client 的拦截器，可用于日志、监控、链路追踪、请求签名等
+type Interceptor interface {
+	// BeforeRequest 在请求发出前调用，返回的请求会传给后续拦截器并最终发出，
+	// 需要携带 span 等信息时应通过 req.WithContext 派生新请求而不是修改 req；
+	// 返回 nil 时沿用 req，返回错误时中止本次调用
+	BeforeRequest(req *http.Request, info *CallInfo) (*http.Request, error)
+	// AfterResponse 在收到响应或请求失败后调用，req 为该拦截器的 BeforeRequest 返回的请求
+	AfterResponse(req *http.Request, info *CallInfo)
+}
+
+// InterceptorFuncs 使用函数实现 Interceptor，未设置的函数会被忽略
+type InterceptorFuncs struct {
+	Before func(req *http.Request, info *CallInfo) (*http.Request, error)
+	After  func(req *http.Request, info *CallInfo)
+}
+
+// BeforeRequest 方法实现了 Interceptor 接口
+func (f InterceptorFuncs) BeforeRequest(req *http.Request, info *CallInfo) (*http.Request, error) {
+	if f.Before == nil {
+		return req, nil
+	}
+	return f.Before(req, info)
+}
+
+// AfterResponse 方法实现了 Interceptor 接口
+func (f InterceptorFuncs) AfterResponse(req *http.Request, info *CallInfo) {
+	if f.After != nil {
+		f.After(req, info)
+	}
+}
+
+// RedactURL 方法用于将 URL 中 access_token、corpsecret 等敏感参数的值替换为 ***，便于输出日志
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	redacted := false
+	for _, key := range sensitiveParams {
+		if q.Get(key) != "" {
+			q.Set(key, "***")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawURL
+	}
+
+	u.RawQuery = strings.ReplaceAll(q.Encode(), "%2A%2A%2A", "***")
+	return u.String()
+}
+
+// redactError 方法用于脱敏错误信息中的 URL，*url.Error 会在错误信息中带上完整的请求地址
+func redactError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return &url.Error{Op: urlErr.Op, URL: RedactURL(urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
+
+// LoggingInterceptor 方法返回记录每次调用的拦截器，URL 中的敏感参数会被脱敏，logger 为 nil 时使用全局 Logger
+func LoggingInterceptor(logger Logger) Interceptor {
+	return InterceptorFuncs{
+		After: func(req *http.Request, info *CallInfo) {
+			l := logger
+			if l == nil {
+				l = GetLogger()
+			}
+			if info.Err != nil {
+				l.Printf("%s %s attempt=%d duration=%s error=%v", info.Method, RedactURL(info.URL), info.Attempt, info.Duration, info.Err)
+				return
+			}
+			l.Printf("%s %s attempt=%d duration=%s status=%d errcode=%d errmsg=%s",
+				info.Method, RedactURL(info.URL), info.Attempt, info.Duration, info.StatusCode, info.ErrCode, info.ErrMsg)
+		},
+	}
+}
+
+// HeaderInterceptor 方法返回为每个请求设置固定请求头的拦截器，可用于经过代理时的鉴权或签名
+func HeaderInterceptor(headers map[string]string) Interceptor {
+	return InterceptorFuncs{
+		Before: func(req *http.Request, info *CallInfo) (*http.Request, error) {
+			req = req.Clone(req.Context())
+			for key, val := range headers {
+				req.Header.Set(key, val)
+			}
+			return req, nil
+		},
+	}
+}
+
+// parseErrCode 方法用于从响应内容中解析 errcode 与 errmsg，非 JSON 响应时返回零值
+func parseErrCode(body []byte) (int, string) {
+	result := &Error{}
+	if len(body) == 0 || body[0] != '{' || json.Unmarshal(body, result) != nil {
+		return 0, ""
+	}
+	return result.ErrCode, result.ErrMsg
+}
@@ -0,0 +1,135 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type ctxKey struct{}
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *recordLogger) Println(v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintln(v...))
+}
+
+func TestRedactURL(t *testing.T) {
+	got := RedactURL("https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid=ww1&corpsecret=secret")
+	if strings.Contains(got, "secret=secret") || !strings.Contains(got, "corpsecret=***") || !strings.Contains(got, "corpid=ww1") {
+		t.Errorf("Unexpected redacted url: %s", got)
+	}
+
+	got = RedactURL("https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=robot-key")
+	if strings.Contains(got, "robot-key") {
+		t.Errorf("Unexpected redacted url: %s", got)
+	}
+
+	raw := "https://qyapi.weixin.qq.com/cgi-bin/user/get?userid=zhangsan"
+	if got = RedactURL(raw); got != raw {
+		t.Errorf("Expected url unchanged, got %s", got)
+	}
+}
+
+func TestClient_Interceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "signed" {
+			t.Errorf("Expected X-Signature header, got %q", r.Header.Get("X-Signature"))
+		}
+		w.Write([]byte(`{"errcode":60111,"errmsg":"userid not found"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(nil)
+	c.SetBaseURI(server.URL)
+
+	var order []string
+	var info *CallInfo
+	logger := &recordLogger{}
+	c.Use(
+		InterceptorFuncs{
+			Before: func(req *http.Request, ci *CallInfo) (*http.Request, error) {
+				order = append(order, "before:outer")
+				return req.WithContext(context.WithValue(req.Context(), ctxKey{}, "outer")), nil
+			},
+			After: func(req *http.Request, ci *CallInfo) {
+				order = append(order, "after:outer:"+fmt.Sprint(req.Context().Value(ctxKey{})))
+				info = ci
+			},
+		},
+		InterceptorFuncs{
+			Before: func(req *http.Request, ci *CallInfo) (*http.Request, error) {
+				order = append(order, "before:inner:"+fmt.Sprint(req.Context().Value(ctxKey{})))
+				return nil, nil
+			},
+			After: func(req *http.Request, ci *CallInfo) {
+				order = append(order, "after:inner")
+			},
+		},
+		HeaderInterceptor(map[string]string{"X-Signature": "signed"}),
+		LoggingInterceptor(logger),
+	)
+
+	_, err := c.GetJSON("https://qyapi.weixin.qq.com/cgi-bin/user/get?access_token=secret-token&userid=nobody")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	if strings.Join(order, ",") != "before:outer,before:inner:outer,after:inner,after:outer:outer" {
+		t.Errorf("Unexpected interceptor order: %v", order)
+	}
+	if info.Endpoint != "/cgi-bin/user/get" || info.ErrCode != 60111 || info.StatusCode != http.StatusOK || info.Attempt != 1 {
+		t.Errorf("Unexpected call info: %+v", info)
+	}
+	if len(logger.lines) != 1 || strings.Contains(logger.lines[0], "secret-token") || !strings.Contains(logger.lines[0], "errcode=60111") {
+		t.Errorf("Unexpected log: %v", logger.lines)
+	}
+}
+
+func TestClient_InterceptorAbort(t *testing.T) {
+	c := NewClient(nil)
+	abort := errors.New("aborted")
+
+	var after error
+	c.Use(
+		InterceptorFuncs{After: func(req *http.Request, ci *CallInfo) { after = ci.Err }},
+		InterceptorFuncs{Before: func(req *http.Request, ci *CallInfo) (*http.Request, error) { return nil, abort }},
+	)
+
+	if _, err := c.GetJSON("https://qyapi.weixin.qq.com/cgi-bin/user/get"); !errors.Is(err, abort) {
+		t.Fatalf("Expected aborted, got %v", err)
+	}
+	if !errors.Is(after, abort) {
+		t.Errorf("Expected outer interceptor to observe abort, got %v", after)
+	}
+}
+
+func TestClient_InterceptorRedactsTransportError(t *testing.T) {
+	c := NewClient(nil)
+	c.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	}})
+	c.SetRetryPolicy(nil)
+
+	logger := &recordLogger{}
+	c.Use(LoggingInterceptor(logger))
+
+	if _, err := c.GetJSON("https://qyapi.weixin.qq.com/cgi-bin/user/get?access_token=secret-token&userid=zhangsan"); err == nil {
+		t.Fatal("Expected transport error")
+	}
+	if len(logger.lines) != 1 || strings.Contains(logger.lines[0], "secret-token") || !strings.Contains(logger.lines[0], "connection refused") {
+		t.Errorf("Unexpected log: %v", logger.lines)
+	}
+}
@@ -0,0 +1,273 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RateLimitScope 描述频率限制的统计维度
+type RateLimitScope int
+
+// 频率限制的统计维度
+const (
+	// ScopeCorp 表示同一企业下所有应用共用配额
+	ScopeCorp RateLimitScope = iota
+	// ScopeApp 表示每个应用单独计算配额
+	ScopeApp
+)
+
+// RateLimitMode 描述超出频率限制时的处理方式
+type RateLimitMode int
+
+// 超出频率限制时的处理方式
+const (
+	// RateLimitBlock 表示等待直到配额可用或 ctx 被取消
+	RateLimitBlock RateLimitMode = iota
+	// RateLimitFailFast 表示立即返回 ErrRateLimited
+	RateLimitFailFast
+)
+
+// ErrRateLimited 为 RateLimitFailFast 模式下超出频率限制时返回的错误
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit 描述一条频率限制：在 Per 时间内最多调用 Limit 次
+type RateLimit struct {
+	Limit int
+	Per   time.Duration
+	Scope RateLimitScope
+}
+
+// DefaultRateLimitPath 为未单独配置的接口使用的频率限制的 key
+const DefaultRateLimitPath = "*"
+
+// baseRateLimits 为企业微信文档中的基础频率限制：每企业调用单个接口不可超过 1 万次/分、15 万次/小时
+var baseRateLimits = []RateLimit{
+	{Limit: 10000, Per: time.Minute},
+	{Limit: 150000, Per: time.Hour},
+}
+
+// DefaultRateLimits 为内置的频率限制，key 为接口路径，未单独配置的接口使用 DefaultRateLimitPath 的基础限制。
+// 发送应用消息与获取客户详情在基础限制之外按应用单独计算配额，实际上限与企业的帐号规模有关，
+// 此处为保守的默认值，可通过 RateLimiter.SetLimit 覆盖
+var DefaultRateLimits = map[string][]RateLimit{
+	DefaultRateLimitPath: baseRateLimits,
+	"/cgi-bin/message/send": withBaseRateLimits(
+		RateLimit{Limit: 1000, Per: time.Minute, Scope: ScopeApp},
+	),
+	"/cgi-bin/externalcontact/get": withBaseRateLimits(
+		RateLimit{Limit: 100000, Per: 24 * time.Hour, Scope: ScopeApp},
+	),
+}
+
+func withBaseRateLimits(limits ...RateLimit) []RateLimit {
+	return append(append([]RateLimit(nil), baseRateLimits...), limits...)
+}
+
+// RateLimitStats 为某个接口的限流统计信息
+type RateLimitStats struct {
+	Requests   int64         // 通过限流的请求数
+	Waits      int64         // 需要等待的请求数
+	WaitTime   time.Duration // 累计等待时间
+	Rejected   int64         // 被拒绝的请求数（fail-fast 模式或 ctx 被取消）
+	LastWaitAt time.Time     // 最近一次等待的时间
+}
+
+// RateLimiter 为客户端的频率限制器，按接口路径及企业（应用）分别使用令牌桶计算配额，
+// 同一个 RateLimiter 可以在多个 Client 间共享
+type RateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string][]RateLimit
+	mode    RateLimitMode
+	buckets map[bucketKey]*bucket
+	stats   map[string]*RateLimitStats
+	onWait  []func(path string, wait time.Duration)
+}
+
+type bucketKey struct {
+	path  string
+	scope string
+	index int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  RateLimit
+}
+
+// NewRateLimiter 方法用于创建 RateLimiter 实例，limits 为 nil 时使用 DefaultRateLimits
+func NewRateLimiter(limits map[string][]RateLimit) *RateLimiter {
+	if limits == nil {
+		limits = DefaultRateLimits
+	}
+
+	l := &RateLimiter{
+		limits:  make(map[string][]RateLimit, len(limits)),
+		buckets: make(map[bucketKey]*bucket),
+		stats:   make(map[string]*RateLimitStats),
+	}
+	for path, pathLimits := range limits {
+		l.limits[path] = append([]RateLimit(nil), pathLimits...)
+	}
+	return l
+}
+
+// SetLimit 方法用于覆盖某个接口路径的频率限制，path 为 DefaultRateLimitPath 时修改默认限制，
+// 不传 limits 时表示该接口不限流
+func (l *RateLimiter) SetLimit(path string, limits ...RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits[path] = limits
+	for key := range l.buckets {
+		if key.path == path {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// SetMode 方法用于设置超出频率限制时的处理方式
+func (l *RateLimiter) SetMode(mode RateLimitMode) {
+	l.mu.Lock()
+	l.mode = mode
+	l.mu.Unlock()
+}
+
+// OnWait 方法用于添加请求因限流而等待时的回调，可用于上报监控指标，多个回调按添加顺序调用
+func (l *RateLimiter) OnWait(fn func(path string, wait time.Duration)) {
+	l.mu.Lock()
+	l.onWait = append(l.onWait, fn)
+	l.mu.Unlock()
+}
+
+// Stats 方法返回各接口路径的限流统计信息
+func (l *RateLimiter) Stats() map[string]RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]RateLimitStats, len(l.stats))
+	for path, s := range l.stats {
+		stats[path] = *s
+	}
+	return stats
+}
+
+// Wait 方法用于在调用接口前获取配额，corp 与 app 分别为企业与应用的标识，
+// RateLimitBlock 模式下会等待直到配额可用，RateLimitFailFast 模式下配额不足时返回 ErrRateLimited
+func (l *RateLimiter) Wait(ctx context.Context, corp, app, rawURL string) error {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+
+	l.mu.Lock()
+	limits, ok := l.limits[path]
+	if !ok {
+		limits = l.limits[DefaultRateLimitPath]
+	}
+	if len(limits) == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	stats := l.statsOf(path)
+	buckets := make([]*bucket, len(limits))
+	for i, limit := range limits {
+		buckets[i] = l.bucketOf(path, corp, app, i, limit, now)
+	}
+
+	var wait time.Duration
+	for _, b := range buckets {
+		if d := b.reserve(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait > 0 && l.mode == RateLimitFailFast {
+		for _, b := range buckets {
+			b.tokens++
+		}
+		stats.Rejected++
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+
+	stats.Requests++
+	if wait > 0 {
+		stats.Waits++
+		stats.WaitTime += wait
+		stats.LastWaitAt = now
+	}
+	onWait := l.onWait
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	for _, fn := range onWait {
+		fn(path, wait)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, b := range buckets {
+			b.tokens++
+		}
+		stats.Requests--
+		stats.Rejected++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (l *RateLimiter) statsOf(path string) *RateLimitStats {
+	s, ok := l.stats[path]
+	if !ok {
+		s = &RateLimitStats{}
+		l.stats[path] = s
+	}
+	return s
+}
+
+func (l *RateLimiter) bucketOf(path, corp, app string, index int, limit RateLimit, now time.Time) *bucket {
+	scope := corp
+	if limit.Scope == ScopeApp {
+		scope = corp + "/" + app
+	}
+	key := bucketKey{path: path, scope: scope, index: index}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Limit), last: now, limit: limit}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// reserve 方法从令牌桶中预留一个配额，返回需要等待的时间，配额允许透支以保证等待的请求依次通过
+func (b *bucket) reserve(now time.Time) time.Duration {
+	rate := float64(b.limit.Limit) / float64(b.limit.Per)
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) * rate
+		if b.tokens > float64(b.limit.Limit) {
+			b.tokens = float64(b.limit.Limit)
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate)
+}
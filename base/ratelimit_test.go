@@ -0,0 +1,125 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const sendURL = "https://qyapi.weixin.qq.com/cgi-bin/message/send?access_token=token"
+
+func TestRateLimiter_FailFast(t *testing.T) {
+	l := NewRateLimiter(map[string][]RateLimit{
+		"/cgi-bin/message/send": {{Limit: 2, Per: time.Hour}},
+	})
+	l.SetMode(RateLimitFailFast)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, "corp1", "app1", sendURL); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := l.Wait(ctx, "corp1", "app1", sendURL); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	// 不同企业分别计算配额，未配置的接口不限流
+	if err := l.Wait(ctx, "corp2", "app1", sendURL); err != nil {
+		t.Errorf("Unexpected error for another corp: %v", err)
+	}
+	if err := l.Wait(ctx, "corp1", "app1", "https://qyapi.weixin.qq.com/cgi-bin/user/get"); err != nil {
+		t.Errorf("Unexpected error for unlimited path: %v", err)
+	}
+
+	stats := l.Stats()["/cgi-bin/message/send"]
+	if stats.Requests != 3 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimiter_AppScope(t *testing.T) {
+	l := NewRateLimiter(map[string][]RateLimit{
+		DefaultRateLimitPath: {{Limit: 1, Per: time.Hour, Scope: ScopeApp}},
+	})
+	l.SetMode(RateLimitFailFast)
+
+	ctx := context.Background()
+	if err := l.Wait(ctx, "corp1", "app1", sendURL); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.Wait(ctx, "corp1", "app2", sendURL); err != nil {
+		t.Fatalf("Expected app2 to have its own quota, got %v", err)
+	}
+	if err := l.Wait(ctx, "corp1", "app1", sendURL); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestRateLimiter_Block(t *testing.T) {
+	l := NewRateLimiter(map[string][]RateLimit{
+		DefaultRateLimitPath: {{Limit: 1, Per: 50 * time.Millisecond}},
+	})
+
+	var waited time.Duration
+	var calls int
+	l.OnWait(func(path string, wait time.Duration) {
+		waited = wait
+	})
+	// 多个回调均会被调用
+	l.OnWait(func(path string, wait time.Duration) {
+		calls++
+	})
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "corp1", "app1", sendURL); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected requests to be throttled, elapsed %v", elapsed)
+	}
+	if waited <= 0 || calls != 2 {
+		t.Errorf("Expected OnWait callbacks to be called, waited %v, calls %d", waited, calls)
+	}
+	if stats := l.Stats()["/cgi-bin/message/send"]; stats.Waits != 2 || stats.WaitTime <= 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "corp1", "app1", sendURL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRateLimiter_DefaultLimits(t *testing.T) {
+	l := NewRateLimiter(nil)
+	l.SetMode(RateLimitFailFast)
+
+	// 发送应用消息按应用单独计算配额
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		if err := l.Wait(ctx, "corp1", "app1", sendURL); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := l.Wait(ctx, "corp1", "app1", sendURL); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if err := l.Wait(ctx, "corp1", "app2", sendURL); err != nil {
+		t.Errorf("Expected app2 to have its own quota, got %v", err)
+	}
+
+	// 获取客户详情按天计算配额，同时受基础限制约束
+	limits := l.limits["/cgi-bin/externalcontact/get"]
+	if len(limits) != 3 || limits[2].Per != 24*time.Hour || limits[2].Scope != ScopeApp || limits[0].Limit != 10000 {
+		t.Errorf("Unexpected externalcontact/get limits: %+v", limits)
+	}
+	if limits := l.limits[DefaultRateLimitPath]; len(limits) != 2 {
+		t.Errorf("Unexpected default limits: %+v", limits)
+	}
+}
@@ -0,0 +1,126 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultRetryPolicy 为 Client 默认使用的重试策略：
+// 幂等请求在网络错误、HTTP 5xx 及系统繁忙（errcode -1）时最多尝试 3 次
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts:   3,
+	BaseDelay:     200 * time.Millisecond,
+	MaxDelay:      2 * time.Second,
+	Jitter:        0.2,
+	RetryErrCodes: []int{ErrCodeSystemBusy},
+}
+
+// RetryPolicy 描述请求失败时的重试策略，token 失效导致的重试不受其限制
+type RetryPolicy struct {
+	// MaxAttempts 为最大尝试次数（含首次请求），小于等于 1 时不重试
+	MaxAttempts int
+	// BaseDelay 为首次重试前的等待时间，之后每次翻倍
+	BaseDelay time.Duration
+	// MaxDelay 为单次等待时间的上限，为 0 时不限制
+	MaxDelay time.Duration
+	// Jitter 为等待时间的随机抖动比例，取值 [0, 1]，用于避免多实例同时重试
+	Jitter float64
+	// RetryErrCodes 为需要重试的 errcode
+	RetryErrCodes []int
+	// IdempotentPaths 为可安全重试的 POST 接口路径，如 /cgi-bin/user/list_id，
+	// 其余 POST 请求（如 /cgi-bin/message/send）默认不重试，以免重复提交
+	IdempotentPaths []string
+}
+
+// StatusError 为 HTTP 状态码不为 200 时返回的错误
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed, status[%d]", e.StatusCode)
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent 方法用于将 ctx 标记为幂等请求，使用该 ctx 的 POST 请求也会按重试策略重试
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func (p *RetryPolicy) idempotent(ctx context.Context, method, rawURL string) bool {
+	if method == http.MethodGet {
+		return true
+	}
+	if v, _ := ctx.Value(idempotentKey{}).(bool); v {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, path := range p.IdempotentPaths {
+		if u.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRetry 方法判断第 attempt 次尝试失败后是否需要重试
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, idempotent bool, err error) bool {
+	if p == nil || !idempotent || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		for _, code := range p.RetryErrCodes {
+			if apiErr.ErrCode == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// backoff 方法返回第 attempt 次尝试失败后的等待时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
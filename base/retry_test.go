@@ -0,0 +1,129 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type mockRoundTripper struct {
+	roundTripFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.roundTripFunc(req)
+}
+
+var testRetryPolicy = &RetryPolicy{
+	MaxAttempts:   3,
+	BaseDelay:     time.Millisecond,
+	RetryErrCodes: []int{ErrCodeSystemBusy},
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	var calls int32
+	c := NewClient(nil)
+	c.SetRetryPolicy(testRetryPolicy)
+	c.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			status, respBody := http.StatusOK, `{"errcode":0,"errmsg":"ok"}`
+			switch atomic.AddInt32(&calls, 1) {
+			case 1:
+				status, respBody = http.StatusBadGateway, ""
+			case 2:
+				respBody = `{"errcode":-1,"errmsg":"system busy"}`
+			}
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}})
+
+	if _, err := c.GetJSON("https://qyapi.weixin.qq.com/cgi-bin/user/get"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestClient_RetryPolicy_NonIdempotentPost(t *testing.T) {
+	var calls int32
+	c := NewClient(nil)
+	c.SetRetryPolicy(testRetryPolicy)
+	c.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"errcode":-1,"errmsg":"system busy"}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}})
+
+	_, err := c.PostJSON("https://qyapi.weixin.qq.com/cgi-bin/message/send", []byte(`{}`))
+	if !errors.Is(err, ErrSystemBusy) {
+		t.Fatalf("Expected ErrSystemBusy, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected non-idempotent POST to be sent once, got %d", got)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.PostJSONContext(WithIdempotent(context.Background()), "https://qyapi.weixin.qq.com/cgi-bin/user/list_id", []byte(`{}`))
+	if !errors.Is(err, ErrSystemBusy) {
+		t.Fatalf("Expected ErrSystemBusy, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("Expected idempotent POST to be attempted 3 times, got %d", got)
+	}
+}
+
+func TestClient_RetryPolicy_NonRetryableErrCode(t *testing.T) {
+	var calls int32
+	c := NewClient(nil)
+	c.SetRetryPolicy(testRetryPolicy)
+	c.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"errcode":60111,"errmsg":"userid not found"}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}})
+
+	if _, err := c.GetJSON("https://qyapi.weixin.qq.com/cgi-bin/user/get"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := p.backoff(i + 1); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Backoff with jitter out of range: %v", got)
+		}
+	}
+}
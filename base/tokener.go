@@ -1,30 +1,107 @@
 package base
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
+const (
+	// DefaultRefreshMargin 为默认的提前刷新时间，令牌在过期前该时长内即视为失效
+	DefaultRefreshMargin = time.Minute
+
+	autoRefreshRetryInterval = 10 * time.Second
+	refreshTimeout           = time.Minute
+)
+
 // TokenFetcher 包含向 API 服务器获取令牌信息的操作
 type TokenFetcher interface {
 	FetchToken() (token string, expiresIn int64, err error)
 }
 
+// ContextTokenFetcher 为支持 context 的 TokenFetcher，Tokener 会优先使用该接口获取令牌
+type ContextTokenFetcher interface {
+	FetchTokenContext(ctx context.Context) (token string, expiresIn int64, err error)
+}
+
+// refreshCall 表示一次正在进行的令牌刷新，并发的刷新请求会等待并共用其结果
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// RefreshInfo 描述一次令牌刷新，可用于监控令牌的获取次数、耗时及失败情况
+type RefreshInfo struct {
+	Start     time.Time
+	Duration  time.Duration
+	Forced    bool  // 是否为 token 失效等原因导致的强制刷新
+	FromStore bool  // 令牌是否来自共享存储（由其他实例刷新）
+	Err       error // 刷新失败的错误，其中的 URL 已脱敏
+}
+
 // Tokener 用于管理应用套件或企业号的令牌信息
 type Tokener struct {
-	mu           sync.RWMutex
-	token        string
-	expiresIn    int64
-	tokenFetcher TokenFetcher
+	mu              sync.RWMutex
+	token           string
+	expiresIn       int64
+	refreshAt       int64
+	refreshMargin   time.Duration
+	tokenFetcher    TokenFetcher
+	store           TokenStore
+	storeKey        string
+	refreshing      *refreshCall
+	lastRefreshErr  error
+	stopAutoRefresh context.CancelFunc
+	autoRefreshDone chan struct{}
+	onRefresh       []func(ctx context.Context, info *RefreshInfo)
 }
 
 // NewTokener 方法用于创建 Tokener 实例
 func NewTokener(tokenFetcher TokenFetcher) *Tokener {
-	return &Tokener{tokenFetcher: tokenFetcher}
+	return &Tokener{
+		tokenFetcher:  tokenFetcher,
+		refreshMargin: DefaultRefreshMargin,
+	}
+}
+
+// SetStore 方法用于设置令牌的共享存储，key 用于区分不同企业或应用的令牌，
+// 设置后 Tokener 会优先从 store 读取令牌，并在刷新时借助 store 的锁避免多实例重复获取
+func (t *Tokener) SetStore(store TokenStore, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store = store
+	t.storeKey = key
+}
+
+// SetRefreshMargin 方法用于设置令牌的提前刷新时间，对之后获取的令牌生效，
+// 为避免有效期较短的令牌被频繁刷新，实际生效的时长不超过令牌有效期的一半
+func (t *Tokener) SetRefreshMargin(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshMargin = d
+}
+
+// LastRefreshError 方法返回最近一次刷新令牌的错误，最近一次刷新成功时返回 nil，可用于健康检查
+func (t *Tokener) LastRefreshError() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.lastRefreshErr
+}
+
+// OnRefresh 方法用于添加每次刷新令牌后的回调，可用于上报监控指标，多个回调按添加顺序在刷新令牌的协程中调用
+func (t *Tokener) OnRefresh(fn func(ctx context.Context, info *RefreshInfo)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRefresh = append(t.onRefresh, fn)
 }
 
 // Token 方法用于获取应用套件令牌
 func (t *Tokener) Token() (token string, err error) {
+	return t.TokenContext(context.Background())
+}
+
+// TokenContext 为 Token 的 context 版本
+func (t *Tokener) TokenContext(ctx context.Context) (token string, err error) {
 	t.mu.RLock()
 	if t.isValidToken() {
 		tok := t.token
@@ -33,46 +110,232 @@ func (t *Tokener) Token() (token string, err error) {
 	}
 	t.mu.RUnlock()
 
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	// Double-check after acquiring write lock
-	if t.isValidToken() {
-		return t.token, nil
-	}
-
-	if err = t.refreshTokenLocked(); err != nil {
+	if err = t.refresh(ctx, false); err != nil {
 		return "", err
 	}
 
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.token, nil
 }
 
 // RefreshToken 方法用于刷新令牌信息
 func (t *Tokener) RefreshToken() error {
+	return t.RefreshTokenContext(context.Background())
+}
+
+// RefreshTokenContext 为 RefreshToken 的 context 版本
+func (t *Tokener) RefreshTokenContext(ctx context.Context) error {
+	return t.refresh(ctx, true)
+}
+
+// StartAutoRefresh 方法用于启动后台刷新协程，在令牌进入提前刷新时间前主动续期，
+// 刷新失败时会定期重试，错误可通过 LastRefreshError 获取
+func (t *Tokener) StartAutoRefresh() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return t.refreshTokenLocked()
+
+	if t.stopAutoRefresh != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stopAutoRefresh = cancel
+	t.autoRefreshDone = make(chan struct{})
+
+	go t.autoRefresh(ctx, t.autoRefreshDone)
 }
 
-func (t *Tokener) refreshTokenLocked() error {
-	token, expiresIn, err := t.tokenFetcher.FetchToken()
-	if err != nil {
+// StopAutoRefresh 方法用于停止后台刷新协程，并等待其退出
+func (t *Tokener) StopAutoRefresh() {
+	t.mu.Lock()
+	cancel, done := t.stopAutoRefresh, t.autoRefreshDone
+	t.stopAutoRefresh, t.autoRefreshDone = nil, nil
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (t *Tokener) autoRefresh(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		var wait time.Duration
+		if err := t.refresh(ctx, false); err != nil {
+			GetLogger().Printf("auto refresh token failed: %v", err)
+			wait = autoRefreshRetryInterval
+		} else {
+			t.mu.RLock()
+			wait = time.Until(time.Unix(t.refreshAt, 0))
+			t.mu.RUnlock()
+			if wait < time.Second {
+				wait = time.Second
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh 方法用于刷新令牌，同一时刻只有一个刷新在进行，其余调用等待并共用其结果，
+// force 为 false 时若当前令牌仍然有效则直接返回。获取令牌不随发起刷新的调用取消，
+// 各调用只在自己的 ctx 结束时提前返回，不影响其他等待中的调用
+func (t *Tokener) refresh(ctx context.Context, force bool) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	expiresIn = time.Now().Add(time.Second * time.Duration(expiresIn)).Unix()
+	t.mu.Lock()
+	if !force && t.isValidToken() {
+		t.mu.Unlock()
+		return nil
+	}
+
+	call := t.refreshing
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		t.refreshing = call
+		go t.doRefresh(detachedContext{ctx}, call, force, t.token, t.onRefresh)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doRefresh 方法用于获取令牌并保存结果，最长耗时为 refreshTimeout
+func (t *Tokener) doRefresh(ctx context.Context, call *refreshCall, force bool, current string, onRefresh []func(context.Context, *RefreshInfo)) {
+	fetchCtx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	start := time.Now()
+	token, expiresAt, fromStore, err := t.fetchToken(fetchCtx, current)
 
+	t.mu.Lock()
+	if err == nil {
+		t.setTokenLocked(token, expiresAt)
+	}
+	t.lastRefreshErr = err
+	t.refreshing = nil
+	t.mu.Unlock()
+
+	if len(onRefresh) > 0 {
+		info := &RefreshInfo{
+			Start:     start,
+			Duration:  time.Since(start),
+			Forced:    force,
+			FromStore: fromStore,
+			Err:       redactError(err),
+		}
+		for _, fn := range onRefresh {
+			fn(ctx, info)
+		}
+	}
+
+	call.err = err
+	close(call.done)
+}
+
+// detachedContext 保留 parent 中的值（如链路追踪信息），但不随 parent 取消或超时
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// fetchToken 方法用于获取新的令牌，设置了 store 时优先使用 store 中与 current 不同的有效令牌
+func (t *Tokener) fetchToken(ctx context.Context, current string) (token string, expiresAt int64, fromStore bool, err error) {
+	t.mu.RLock()
+	store, key, margin := t.store, t.storeKey, t.refreshMargin
+	t.mu.RUnlock()
+
+	if store != nil {
+		if token, expiresAt, ok := loadStoreToken(ctx, store, key, current, margin); ok {
+			return token, expiresAt, true, nil
+		}
+
+		unlock, err := store.Lock(ctx, key)
+		if err != nil {
+			return "", 0, false, err
+		}
+		defer unlock()
+
+		// 获得锁后再次检查，其他实例可能已经刷新了令牌
+		if token, expiresAt, ok := loadStoreToken(ctx, store, key, current, margin); ok {
+			return token, expiresAt, true, nil
+		}
+	}
+
+	var expiresIn int64
+	if f, ok := t.tokenFetcher.(ContextTokenFetcher); ok {
+		token, expiresIn, err = f.FetchTokenContext(ctx)
+	} else {
+		token, expiresIn, err = t.tokenFetcher.FetchToken()
+	}
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	expiresAt = time.Now().Add(time.Second * time.Duration(expiresIn)).Unix()
+
+	if store != nil {
+		if err := store.Set(ctx, key, token, expiresAt); err != nil {
+			GetLogger().Printf("save token to store failed, key[%s]: %v", key, err)
+		}
+	}
+
+	return token, expiresAt, false, nil
+}
+
+// loadStoreToken 用于从 store 中读取与 current 不同且未进入提前刷新时间的令牌
+func loadStoreToken(ctx context.Context, store TokenStore, key, current string, margin time.Duration) (string, int64, bool) {
+	token, expiresAt, err := store.Get(ctx, key)
+	if err != nil {
+		GetLogger().Printf("load token from store failed, key[%s]: %v", key, err)
+		return "", 0, false
+	}
+
+	if token == "" || token == current || time.Now().Unix() >= refreshTime(expiresAt, margin) {
+		return "", 0, false
+	}
+
+	return token, expiresAt, true
+}
+
+func (t *Tokener) setTokenLocked(token string, expiresAt int64) {
 	t.token = token
-	t.expiresIn = expiresIn
+	t.expiresIn = expiresAt
+	t.refreshAt = refreshTime(expiresAt, t.refreshMargin)
+}
 
-	return nil
+// refreshTime 用于根据过期时间计算令牌需要刷新的时间点，提前量不超过剩余有效期的一半
+func refreshTime(expiresAt int64, margin time.Duration) int64 {
+	m := int64(margin / time.Second)
+	if half := (expiresAt - time.Now().Unix()) / 2; m > half {
+		m = half
+	}
+	return expiresAt - m
 }
 
 func (t *Tokener) isValidToken() bool {
 	now := time.Now().Unix()
 
-	if now >= t.expiresIn || t.token == "" {
+	if now >= t.refreshAt || t.token == "" {
 		return false
 	}
 
@@ -83,4 +346,3 @@ type Ticket struct {
 	Ticket    string `json:"ticket"`
 	ExpiresIn int64  `json:"expires_in"`
 }
-
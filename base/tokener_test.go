@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -62,3 +63,168 @@ func TestTokener_FetchError(t *testing.T) {
 		t.Error("Expected error from Tokener.Token(), got nil")
 	}
 }
+
+func TestTokener_TokenContextCanceled(t *testing.T) {
+	fetcher := &mockTokenFetcher{}
+	tokener := NewTokener(fetcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := tokener.TokenContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&fetcher.count); calls != 0 {
+		t.Errorf("Expected FetchToken not to be called, got %d", calls)
+	}
+}
+
+type blockingTokenFetcher struct {
+	count   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingTokenFetcher) FetchToken() (string, int64, error) {
+	if atomic.AddInt32(&f.count, 1) == 1 {
+		close(f.started)
+	}
+	<-f.release
+	return "mock-token", 7200, nil
+}
+
+func TestTokener_RefreshSingleflight(t *testing.T) {
+	fetcher := &blockingTokenFetcher{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	tokener := NewTokener(fetcher)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tokener.RefreshToken()
+	}()
+	<-fetcher.started
+
+	// 刷新进行中时发起的强制刷新应当等待并共用同一次获取结果
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tokener.RefreshToken(); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&fetcher.count); calls != 1 {
+		t.Errorf("Expected FetchToken to be called exactly 1 time, got %d", calls)
+	}
+}
+
+func TestTokener_RefreshLeaderCanceled(t *testing.T) {
+	fetcher := &blockingTokenFetcher{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	tokener := NewTokener(fetcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := tokener.TokenContext(ctx)
+		leader <- err
+	}()
+	<-fetcher.started
+
+	waiter := make(chan error, 1)
+	go func() {
+		token, err := tokener.Token()
+		if err == nil && token != "mock-token" {
+			err = errors.New("unexpected token " + token)
+		}
+		waiter <- err
+	}()
+
+	// 发起刷新的调用被取消时立即返回，不影响其他等待中的调用
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	close(fetcher.release)
+	if err := <-waiter; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := tokener.LastRefreshError(); err != nil {
+		t.Errorf("Unexpected refresh error: %v", err)
+	}
+	if calls := atomic.LoadInt32(&fetcher.count); calls != 1 {
+		t.Errorf("Expected FetchToken to be called exactly 1 time, got %d", calls)
+	}
+}
+
+func TestTokener_OnRefresh(t *testing.T) {
+	tokener := NewTokener(&errTokenFetcher{})
+
+	var got []string
+	tokener.OnRefresh(func(ctx context.Context, info *RefreshInfo) {
+		got = append(got, "first:"+info.Err.Error())
+	})
+	tokener.OnRefresh(func(ctx context.Context, info *RefreshInfo) {
+		got = append(got, "second:"+info.Err.Error())
+	})
+
+	if _, err := tokener.Token(); err == nil {
+		t.Fatal("Expected error")
+	}
+	if len(got) != 2 || got[0] != "first:network error" || got[1] != "second:network error" {
+		t.Errorf("Unexpected callbacks: %v", got)
+	}
+}
+
+func TestTokener_RefreshTime(t *testing.T) {
+	now := time.Now().Unix()
+
+	if got := refreshTime(now+7200, time.Minute); got != now+7140 {
+		t.Errorf("Expected refresh at %d, got %d", now+7140, got)
+	}
+
+	// 提前量不超过剩余有效期的一半
+	if got := refreshTime(now+2, time.Minute); got != now+1 {
+		t.Errorf("Expected refresh at %d, got %d", now+1, got)
+	}
+}
+
+func TestTokener_LastRefreshError(t *testing.T) {
+	tokener := NewTokener(&errTokenFetcher{})
+	tokener.Token()
+	if tokener.LastRefreshError() == nil {
+		t.Error("Expected LastRefreshError to be set")
+	}
+}
+
+func TestTokener_AutoRefresh(t *testing.T) {
+	fetcher := &mockTokenFetcher{}
+	tokener := NewTokener(fetcher)
+
+	tokener.StartAutoRefresh()
+	defer tokener.StopAutoRefresh()
+
+	// 令牌有效期为 2 秒，后台协程应在过期前主动刷新
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&fetcher.count) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected background refresh, got %d fetches", atomic.LoadInt32(&fetcher.count))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := tokener.LastRefreshError(); err != nil {
+		t.Errorf("Unexpected refresh error: %v", err)
+	}
+}
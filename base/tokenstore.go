@@ -0,0 +1,75 @@
+package base
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenStore 为令牌的共享存储接口，可基于 Redis、数据库等实现，使多个实例共用同一份令牌，
+// 避免各自向 API 服务器获取令牌而触发频率限制或相互失效
+type TokenStore interface {
+	// Get 方法用于读取令牌及其过期时间（Unix 秒），令牌不存在时返回空字符串且 err 为 nil
+	Get(ctx context.Context, key string) (token string, expiresAt int64, err error)
+	// Set 方法用于保存令牌及其过期时间（Unix 秒）
+	Set(ctx context.Context, key, token string, expiresAt int64) error
+	// Lock 方法用于在刷新令牌前获取锁，返回的 unlock 用于释放锁，分布式实现应保证跨实例互斥
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+type memoryToken struct {
+	token     string
+	expiresAt int64
+}
+
+// MemoryTokenStore 为基于进程内存的 TokenStore 实现，适用于同一进程内多个实例共享令牌
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]memoryToken
+	locks  map[string]chan struct{}
+}
+
+// NewMemoryTokenStore 方法用于创建 MemoryTokenStore 实例
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]memoryToken),
+		locks:  make(map[string]chan struct{}),
+	}
+}
+
+// Get 方法用于读取令牌
+func (s *MemoryTokenStore) Get(ctx context.Context, key string) (string, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tokens[key]
+	return t.token, t.expiresAt, nil
+}
+
+// Set 方法用于保存令牌
+func (s *MemoryTokenStore) Set(ctx context.Context, key, token string, expiresAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[key] = memoryToken{token, expiresAt}
+	return nil
+}
+
+// Lock 方法用于获取 key 对应的锁，等待期间 ctx 被取消时返回错误
+func (s *MemoryTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	s.mu.Lock()
+	lock, ok := s.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		s.locks[key] = lock
+	}
+	s.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-lock })
+	}, nil
+}
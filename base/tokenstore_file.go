@@ -0,0 +1,110 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultFileLockStale = 30 * time.Second
+	fileLockPollInterval = 50 * time.Millisecond
+)
+
+// FileTokenStore 为基于本地文件的 TokenStore 实现，适用于同一主机上的多个进程共享令牌，
+// 每个 key 对应目录下的一个 JSON 文件，锁通过独占创建 .lock 文件实现
+type FileTokenStore struct {
+	dir string
+	// LockStale 为锁文件的过期时间，持锁进程异常退出后超过该时间的锁文件将被清理
+	LockStale time.Duration
+}
+
+type fileToken struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// NewFileTokenStore 方法用于创建 FileTokenStore 实例，dir 不存在时会自动创建
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileTokenStore{dir: dir, LockStale: defaultFileLockStale}, nil
+}
+
+func (s *FileTokenStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Get 方法用于读取令牌
+func (s *FileTokenStore) Get(ctx context.Context, key string) (string, int64, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	t := fileToken{}
+	if err = json.Unmarshal(data, &t); err != nil {
+		return "", 0, err
+	}
+
+	return t.Token, t.ExpiresAt, nil
+}
+
+// Set 方法用于保存令牌，先写入临时文件再重命名，避免其他进程读到不完整的内容
+func (s *FileTokenStore) Set(ctx context.Context, key, token string, expiresAt int64) error {
+	data, err := json.Marshal(fileToken{Token: token, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// Lock 方法用于获取 key 对应的文件锁，等待期间 ctx 被取消时返回错误
+func (s *FileTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	lockPath := s.path(key) + ".lock"
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && s.LockStale > 0 && time.Since(info.ModTime()) > s.LockStale {
+			os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fileLockPollInterval):
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package base
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokener_SharedMemoryStore(t *testing.T) {
+	fetcher := &mockTokenFetcher{}
+	store := NewMemoryTokenStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		tokener := NewTokener(fetcher)
+		tokener.SetStore(store, "access_token:corp")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := tokener.Token()
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if token != "mock-token" {
+				t.Errorf("Unexpected token value: %s", token)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 多个 Tokener 共享同一个 store 时，令牌只应获取一次
+	if calls := atomic.LoadInt32(&fetcher.count); calls != 1 {
+		t.Errorf("Expected FetchToken to be called exactly 1 time, got %d", calls)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	store, err := NewFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	token, _, err := store.Get(ctx, "access_token:corp")
+	if err != nil || token != "" {
+		t.Fatalf("Expected empty token, got %q, %v", token, err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	if err = store.Set(ctx, "access_token:corp", "file-token", expiresAt); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, gotExpiresAt, err := store.Get(ctx, "access_token:corp")
+	if err != nil || token != "file-token" || gotExpiresAt != expiresAt {
+		t.Errorf("Unexpected token: %q, %d, %v", token, gotExpiresAt, err)
+	}
+
+	unlock, err := store.Lock(ctx, "access_token:corp")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err = store.Lock(waitCtx, "access_token:corp"); err == nil {
+		t.Error("Expected lock to be held")
+	}
+
+	unlock()
+	unlock2, err := store.Lock(ctx, "access_token:corp")
+	if err != nil {
+		t.Fatalf("Unexpected error after unlock: %v", err)
+	}
+	unlock2()
+}
+
+func TestTokener_RefreshAdoptsStoreToken(t *testing.T) {
+	store := NewMemoryTokenStore()
+	fetcher := &mockTokenFetcher{}
+
+	tokener := NewTokener(fetcher)
+	tokener.SetStore(store, "access_token:corp")
+	if _, err := tokener.Token(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 模拟其他实例已经刷新了令牌
+	store.Set(context.Background(), "access_token:corp", "other-token", time.Now().Add(time.Hour).Unix())
+
+	if err := tokener.RefreshToken(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, _ := tokener.Token()
+	if token != "other-token" {
+		t.Errorf("Expected token refreshed by other instance, got %s", token)
+	}
+	if calls := atomic.LoadInt32(&fetcher.count); calls != 1 {
+		t.Errorf("Expected FetchToken to be called exactly 1 time, got %d", calls)
+	}
+}
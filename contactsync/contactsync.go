@@ -0,0 +1,507 @@
+// Package contactsync 用于将外部系统（如 HR 系统）中的组织架构与成员同步到企业微信通讯录。
+//
+// Reconciler 会读取通讯录的当前状态并与期望状态比较，生成按依赖顺序排列的变更计划：
+// 先由上到下创建或更新部门，再创建或更新成员（成员会在部门删除前移出），
+// 然后删除多余的成员，最后由下到上删除多余的部门。计划可以仅打印（dry-run），也可以并发执行：
+//
+//	r := contactsync.NewReconciler(wechatAPI)
+//	plan, err := r.Plan(ctx, &contactsync.State{Departments: departments, Users: users})
+//	fmt.Print(plan)
+//	result, err := r.Execute(ctx, plan)
+//
+// 执行时的调用频率由 API 的限流器控制，可通过 Reconciler.SetRateLimiter 或 API.SetRateLimiter 设置。
+package contactsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// DefaultConcurrency 为执行计划时同一阶段内的默认并发数
+const DefaultConcurrency = 4
+
+// State 为通讯录的期望状态，部门需指定 ID，成员需指定 UserID
+type State struct {
+	Departments []*api.Department
+	Users       []*api.User
+}
+
+// OpType 为变更操作的类型
+type OpType string
+
+// 变更操作的类型
+const (
+	CreateDepartment OpType = "create department"
+	UpdateDepartment OpType = "update department"
+	DeleteDepartment OpType = "delete department"
+	CreateUser       OpType = "create user"
+	UpdateUser       OpType = "update user"
+	DeleteUser       OpType = "delete user"
+)
+
+// Operation 为计划中的一项变更，Fields 为更新操作中发生变化的字段，更新成员时仅发送这些字段
+type Operation struct {
+	Type       OpType
+	Stage      int
+	Department *api.Department
+	User       *api.User
+	Fields     []string
+}
+
+func (op *Operation) String() string {
+	var target string
+	if op.Department != nil {
+		target = fmt.Sprintf("%d %s (parent %d)", op.Department.ID, op.Department.Name, op.Department.ParentID)
+	} else {
+		target = fmt.Sprintf("%s %s", op.User.UserID, op.User.Name)
+	}
+	if len(op.Fields) > 0 {
+		target += " [" + strings.Join(op.Fields, ", ") + "]"
+	}
+	return string(op.Type) + " " + strings.TrimSpace(target)
+}
+
+// Plan 为变更计划，Operations 按 Stage 排序，同一阶段内的操作互不依赖，可以并发执行
+type Plan struct {
+	Operations []*Operation
+}
+
+// Empty 方法判断计划是否没有任何变更
+func (p *Plan) Empty() bool {
+	return len(p.Operations) == 0
+}
+
+// String 方法返回计划的文本形式，每行一项变更，可用于 dry-run 输出
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, op := range p.Operations {
+		fmt.Fprintf(&b, "[%d] %s\n", op.Stage, op)
+	}
+	return b.String()
+}
+
+func (p *Plan) stages() [][]*Operation {
+	var stages [][]*Operation
+	for i, op := range p.Operations {
+		if i == 0 || op.Stage != p.Operations[i-1].Stage {
+			stages = append(stages, nil)
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], op)
+	}
+	return stages
+}
+
+// OperationError 为执行某项变更失败的错误
+type OperationError struct {
+	Op  *Operation
+	Err error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Result 为计划的执行结果，某一阶段有操作失败时后续阶段不再执行，其操作记录在 Skipped 中
+type Result struct {
+	Succeeded []*Operation
+	Failed    []*OperationError
+	Skipped   []*Operation
+}
+
+// Reconciler 用于计算并执行通讯录的变更计划
+type Reconciler struct {
+	api             *api.API
+	rootID          int64
+	concurrency     int
+	deleteUnmanaged bool
+}
+
+// NewReconciler 方法用于创建 Reconciler 实例，默认同步根部门（ID 为 1）下的全部部门与成员，
+// 并删除期望状态中不存在的部门与成员
+func NewReconciler(a *api.API) *Reconciler {
+	return &Reconciler{
+		api:             a,
+		rootID:          1,
+		concurrency:     DefaultConcurrency,
+		deleteUnmanaged: true,
+	}
+}
+
+// SetRootDepartment 方法用于设置同步的范围，仅同步该部门及其下级部门，根部门本身不会被创建或删除
+func (r *Reconciler) SetRootDepartment(id int64) {
+	r.rootID = id
+}
+
+// SetConcurrency 方法用于设置同一阶段内的并发数
+func (r *Reconciler) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	r.concurrency = n
+}
+
+// SetDeleteUnmanaged 方法用于设置是否删除期望状态中不存在的部门与成员，关闭后仅创建与更新
+func (r *Reconciler) SetDeleteUnmanaged(enable bool) {
+	r.deleteUnmanaged = enable
+}
+
+// SetRateLimiter 方法用于为执行计划的 API 设置限流器
+func (r *Reconciler) SetRateLimiter(limiter *base.RateLimiter) {
+	r.api.SetRateLimiter(limiter)
+}
+
+// Plan 方法用于获取通讯录的当前状态，并计算达到期望状态所需的变更计划
+func (r *Reconciler) Plan(ctx context.Context, desired *State) (*Plan, error) {
+	departments, err := r.api.ListDepartmentContext(ctx, r.rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchChild := 1
+	users, err := r.api.ListUserContext(ctx, r.rootID, &fetchChild, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Diff(&State{Departments: departments, Users: users}, desired)
+}
+
+// Diff 方法用于比较当前状态与期望状态并生成变更计划，不调用任何接口
+func (r *Reconciler) Diff(current, desired *State) (*Plan, error) {
+	currentTree, err := api.NewDepartmentTree(current.Departments)
+	if err != nil {
+		return nil, err
+	}
+	if currentTree.Node(r.rootID) == nil {
+		currentTree, err = api.NewDepartmentTree(append([]*api.Department{{ID: r.rootID}}, current.Departments...))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	desiredTree, err := r.desiredTree(desired.Departments)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	stage := 0
+
+	// 由上到下创建或更新部门，同一层级的部门为同一阶段
+	depths := make(map[int][]*Operation)
+	maxDepth := 0
+	desiredTree.Walk(func(node *api.DepartmentNode) error {
+		if node.ID == r.rootID {
+			return nil
+		}
+
+		op := &Operation{Department: node.Department}
+		if cur := currentTree.Node(node.ID); cur == nil {
+			op.Type = CreateDepartment
+		} else if op.Fields = changedFields(cur.Department, node.Department, "id"); len(op.Fields) > 0 {
+			op.Type = UpdateDepartment
+		} else {
+			return nil
+		}
+
+		depth := node.Depth()
+		depths[depth] = append(depths[depth], op)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return nil
+	})
+	for depth := 0; depth <= maxDepth; depth++ {
+		if ops := depths[depth]; len(ops) > 0 {
+			stage = plan.add(stage, ops)
+		}
+	}
+
+	// 创建或更新成员
+	currentUsers := make(map[string]*api.User, len(current.Users))
+	for _, user := range current.Users {
+		currentUsers[user.UserID] = user
+	}
+	desiredUsers := make(map[string]bool, len(desired.Users))
+	var userOps []*Operation
+	for _, user := range desired.Users {
+		if user.UserID == "" {
+			return nil, fmt.Errorf("user %q has no userid", user.Name)
+		}
+		if desiredUsers[user.UserID] {
+			return nil, fmt.Errorf("duplicate userid: %s", user.UserID)
+		}
+		desiredUsers[user.UserID] = true
+
+		// 成员所在部门需在执行前存在且不会被删除，否则会在前面的阶段已修改通讯录后才失败
+		for _, ids := range [][]int64{user.DepartmentIds, {user.MainDepartment}} {
+			for _, id := range ids {
+				if id == 0 || desiredTree.Node(id) != nil || (!r.deleteUnmanaged && currentTree.Node(id) != nil) {
+					continue
+				}
+				return nil, fmt.Errorf("user %s: department %d is not in desired state", user.UserID, id)
+			}
+		}
+
+		if cur, ok := currentUsers[user.UserID]; !ok {
+			userOps = append(userOps, &Operation{Type: CreateUser, User: user})
+		} else if fields := changedFields(cur, user, "userid"); len(fields) > 0 {
+			userOps = append(userOps, &Operation{Type: UpdateUser, User: user, Fields: fields})
+		}
+	}
+	stage = plan.add(stage, userOps)
+
+	if !r.deleteUnmanaged {
+		return plan, nil
+	}
+
+	// 删除多余的成员
+	var deleteUserOps []*Operation
+	for _, user := range current.Users {
+		if !desiredUsers[user.UserID] {
+			deleteUserOps = append(deleteUserOps, &Operation{Type: DeleteUser, User: user})
+		}
+	}
+	stage = plan.add(stage, deleteUserOps)
+
+	// 由下到上删除多余的部门
+	depths = make(map[int][]*Operation)
+	maxDepth = 0
+	currentTree.Walk(func(node *api.DepartmentNode) error {
+		if node.ID == r.rootID || desiredTree.Node(node.ID) != nil {
+			return nil
+		}
+		depth := node.Depth()
+		depths[depth] = append(depths[depth], &Operation{Type: DeleteDepartment, Department: node.Department})
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return nil
+	})
+	for depth := maxDepth; depth >= 0; depth-- {
+		if ops := depths[depth]; len(ops) > 0 {
+			stage = plan.add(stage, ops)
+		}
+	}
+
+	return plan, nil
+}
+
+// desiredTree 方法用于构建期望的部门树，上级部门不在期望状态中时须为同步的根部门
+func (r *Reconciler) desiredTree(departments []*api.Department) (*api.DepartmentTree, error) {
+	for _, d := range departments {
+		if d.ID == 0 {
+			return nil, fmt.Errorf("department %q has no id", d.Name)
+		}
+	}
+
+	tree, err := api.NewDepartmentTree(departments)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range tree.Roots {
+		if root.ID != r.rootID && root.ParentID != r.rootID {
+			return nil, fmt.Errorf("department %d: parent %d is not in desired state", root.ID, root.ParentID)
+		}
+	}
+	if tree.Node(r.rootID) != nil {
+		return tree, nil
+	}
+	return api.NewDepartmentTree(append([]*api.Department{{ID: r.rootID}}, departments...))
+}
+
+// add 方法用于将一组操作作为新的阶段加入计划，返回下一阶段的序号
+func (p *Plan) add(stage int, ops []*Operation) int {
+	if len(ops) == 0 {
+		return stage
+	}
+	for _, op := range ops {
+		op.Stage = stage
+	}
+	p.Operations = append(p.Operations, ops...)
+	return stage + 1
+}
+
+// changedFields 方法返回 desired 中已设置且与 current 不同的 JSON 字段，忽略 key 字段，
+// 成员所在部门列表的比较不考虑顺序
+func changedFields(current, desired interface{}, key string) []string {
+	cur, want := toFields(current), toFields(desired)
+
+	var fields []string
+	for name, value := range want {
+		if name == key {
+			continue
+		}
+		if name == "department" {
+			if !sameSet(cur[name], value) {
+				fields = append(fields, name)
+			}
+		} else if !reflect.DeepEqual(cur[name], value) {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// toFields 方法返回 v 的 JSON 字段，去除空对象与空数组（如未设置的 extattr），使其不参与比较
+func toFields(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, _ := json.Marshal(v)
+	json.Unmarshal(data, &fields)
+	prune(fields)
+	return fields
+}
+
+// prune 方法递归去除对象中的空对象与空数组
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if v[name] = prune(value); isEmptyValue(v[name]) {
+				delete(v, name)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = prune(value)
+		}
+	}
+	return v
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// sameSet 方法用于比较两个 JSON 数组的元素是否相同，不考虑顺序
+func sameSet(a, b interface{}) bool {
+	x, _ := a.([]interface{})
+	y, _ := b.([]interface{})
+	if len(x) != len(y) {
+		return false
+	}
+	counts := make(map[interface{}]int, len(x))
+	for _, v := range x {
+		counts[v]++
+	}
+	for _, v := range y {
+		if counts[v]--; counts[v] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Execute 方法用于按阶段执行变更计划，同一阶段内的操作并发执行；
+// 某一阶段有操作失败时不再执行后续阶段，并返回第一个失败的 *OperationError
+func (r *Reconciler) Execute(ctx context.Context, plan *Plan) (*Result, error) {
+	result := &Result{}
+	stages := plan.stages()
+	for i, ops := range stages {
+		failed := r.executeStage(ctx, ops, result)
+		if len(failed) == 0 {
+			continue
+		}
+
+		for _, rest := range stages[i+1:] {
+			result.Skipped = append(result.Skipped, rest...)
+		}
+		return result, failed[0]
+	}
+	return result, nil
+}
+
+func (r *Reconciler) executeStage(ctx context.Context, ops []*Operation, result *Result) []*OperationError {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed []*OperationError
+	)
+
+	queue := make(chan *Operation)
+	for i := 0; i < r.concurrency && i < len(ops); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range queue {
+				err := r.apply(ctx, op)
+
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, &OperationError{Op: op, Err: err})
+				} else {
+					result.Succeeded = append(result.Succeeded, op)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, op := range ops {
+		queue <- op
+	}
+	close(queue)
+	wg.Wait()
+
+	// 保持与计划中相同的顺序，便于查看
+	sort.SliceStable(failed, func(i, j int) bool { return indexOf(ops, failed[i].Op) < indexOf(ops, failed[j].Op) })
+	result.Failed = append(result.Failed, failed...)
+	return failed
+}
+
+func indexOf(ops []*Operation, op *Operation) int {
+	for i, o := range ops {
+		if o == op {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Reconciler) apply(ctx context.Context, op *Operation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch op.Type {
+	case CreateDepartment:
+		department := *op.Department
+		return r.api.CreateDepartmentContext(ctx, &department)
+	case UpdateDepartment:
+		return r.api.UpdateDepartmentContext(ctx, op.Department)
+	case DeleteDepartment:
+		return r.api.DeleteDepartmentContext(ctx, op.Department.ID)
+	case CreateUser:
+		return r.api.CreateUserContext(ctx, op.User)
+	case UpdateUser:
+		// 仅发送变更的字段，避免未设置的字段（如 extattr）覆盖成员的现有信息
+		fields := toFields(op.User)
+		patch := make(map[string]interface{}, len(op.Fields))
+		for _, name := range op.Fields {
+			patch[name] = fields[name]
+		}
+		return r.api.UpdateUserFieldsContext(ctx, op.User.UserID, patch)
+	case DeleteUser:
+		return r.api.DeleteUserContext(ctx, op.User.UserID)
+	}
+	return fmt.Errorf("unknown operation: %s", op.Type)
+}
@@ -0,0 +1,191 @@
+package contactsync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"github.com/shengbox/wechat-qy/wecomtest"
+)
+
+func TestReconciler_Sync(t *testing.T) {
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+
+	// 当前：市场部(2) 下有 lisi，研发部(3) 下有 zhangsan
+	srv.AddDepartment(&api.Department{ID: 2, Name: "市场部", ParentID: 1})
+	srv.AddDepartment(&api.Department{ID: 3, Name: "研发部", ParentID: 1})
+	srv.AddUser(&api.User{UserID: "lisi", Name: "李四", DepartmentIds: []int64{2}})
+	srv.AddUser(&api.User{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{3}, Position: "工程师",
+		ExtAttr: api.UserAttributes{Attrs: []*api.UserAttribute{api.NewTextAttr("工号", "1001")}}})
+
+	a := srv.NewAPI("ww-corp", "secret")
+	r := NewReconciler(a)
+
+	// 期望：撤销市场部并删除 lisi，研发部下新增后端组(4)，zhangsan 调入后端组，新增 wangwu
+	desired := &State{
+		Departments: []*api.Department{
+			{ID: 4, Name: "后端组", ParentID: 3},
+			{ID: 3, Name: "研发中心", ParentID: 1},
+		},
+		Users: []*api.User{
+			{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{4}},
+			{UserID: "wangwu", Name: "王五", DepartmentIds: []int64{3}},
+		},
+	}
+
+	ctx := context.Background()
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		"[0] update department 3 研发中心 (parent 1) [name]",
+		"[1] create department 4 后端组 (parent 3)",
+		"[2] update user zhangsan 张三 [department]",
+		"[2] create user wangwu 王五",
+		"[3] delete user lisi 李四",
+		"[4] delete department 2 市场部 (parent 1)",
+	}
+	lines := strings.Split(strings.TrimSpace(plan.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Unexpected plan:\n%s", plan)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Unexpected plan line %d: %q, want %q", i, lines[i], want[i])
+		}
+	}
+
+	r.SetConcurrency(2)
+	result, err := r.Execute(ctx, plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Succeeded) != len(want) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if d := srv.Department(2); d != nil {
+		t.Errorf("Expected department 2 to be deleted, got %+v", d)
+	}
+	if u := srv.User("zhangsan"); u == nil || u.DepartmentIds[0] != 4 || u.Position != "工程师" || len(u.ExtAttr.Attrs) != 1 {
+		t.Errorf("Unexpected user: %+v", u)
+	}
+
+	// 再次同步时没有变更
+	plan, err = r.Plan(ctx, desired)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("Expected empty plan, got:\n%s", plan)
+	}
+}
+
+func TestReconciler_ExecuteStopsOnFailure(t *testing.T) {
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+
+	a := srv.NewAPI("ww-corp", "secret")
+	r := NewReconciler(a)
+	r.SetDeleteUnmanaged(false)
+
+	desired := &State{
+		Departments: []*api.Department{{ID: 2, Name: "研发部", ParentID: 1}},
+		Users:       []*api.User{{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{2}}},
+	}
+	plan, err := r.Plan(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	srv.InjectError("/cgi-bin/department/create", base.ErrCodeNoPrivilege, 1)
+	result, err := r.Execute(context.Background(), plan)
+
+	var opErr *OperationError
+	if !errors.As(err, &opErr) || opErr.Op.Type != CreateDepartment || !errors.Is(err, base.ErrNoPrivilege) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Type != CreateUser {
+		t.Errorf("Unexpected skipped: %+v", result.Skipped)
+	}
+	if srv.User("zhangsan") != nil {
+		t.Error("Expected user not to be created")
+	}
+}
+
+func TestReconciler_DiffRejectsCycle(t *testing.T) {
+	r := NewReconciler(api.New("ww-corp", "secret", "", ""))
+	_, err := r.Diff(&State{}, &State{Departments: []*api.Department{
+		{ID: 2, Name: "A", ParentID: 3},
+		{ID: 3, Name: "B", ParentID: 2},
+	}})
+
+	var cycleErr *api.DepartmentCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Errorf("Expected *api.DepartmentCycleError, got %v", err)
+	}
+}
+
+func TestReconciler_DiffRejectsMissingUserDepartment(t *testing.T) {
+	r := NewReconciler(api.New("ww-corp", "secret", "", ""))
+	current := &State{
+		Departments: []*api.Department{{ID: 1, Name: "root"}, {ID: 2, Name: "A", ParentID: 1}},
+	}
+	desired := &State{
+		Departments: []*api.Department{{ID: 3, Name: "B", ParentID: 1}},
+		Users: []*api.User{
+			{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{3}},
+			{UserID: "lisi", Name: "李四", DepartmentIds: []int64{1, 2}},
+		},
+	}
+
+	// 部门 2 将被删除，不能作为成员所在部门
+	if _, err := r.Diff(current, desired); err == nil || !strings.Contains(err.Error(), "department 2") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	desired.Users[1].DepartmentIds = []int64{4}
+	if _, err := r.Diff(current, desired); err == nil || !strings.Contains(err.Error(), "department 4") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// 不删除未管理的部门时，可使用当前已存在的部门
+	desired.Users[1].DepartmentIds = []int64{1, 2}
+	r.SetDeleteUnmanaged(false)
+	if _, err := r.Diff(current, desired); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReconciler_DiffIgnoresUnsetFields(t *testing.T) {
+	r := NewReconciler(api.New("ww-corp", "secret", "", ""))
+	current := &State{
+		Departments: []*api.Department{{ID: 1, Name: "root"}, {ID: 2, Name: "A", ParentID: 1}},
+		Users: []*api.User{{UserID: "u1", Name: "A", DepartmentIds: []int64{2, 1},
+			ExtAttr: api.UserAttributes{Attrs: []*api.UserAttribute{api.NewTextAttr("工号", "1001")}}}},
+	}
+	desired := &State{
+		Departments: []*api.Department{{ID: 2, Name: "A", ParentID: 1}},
+		Users:       []*api.User{{UserID: "u1", Name: "A", DepartmentIds: []int64{1, 2}}},
+	}
+
+	// 未设置的 extattr 不参与比较，部门列表不考虑顺序
+	plan, err := r.Diff(current, desired)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("Expected empty plan, got:\n%s", plan)
+	}
+
+	desired.Users[0].DepartmentIds = []int64{2}
+	if plan, _ = r.Diff(current, desired); strings.TrimSpace(plan.String()) != "[0] update user u1 A [department]" {
+		t.Errorf("Unexpected plan:\n%s", plan)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/shengbox/wechat-qy/otelqy
+
+go 1.20
+
+require (
+	github.com/shengbox/wechat-qy v0.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-resty/resty/v2 v2.6.0 // indirect
+	github.com/heroicyang/wechat-crypter v0.0.0-20150326022142-2d3eafa552ae // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+replace github.com/shengbox/wechat-qy => ../
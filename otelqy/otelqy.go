@@ -0,0 +1,157 @@
+// Package otelqy 为 base.Client 与 base.Tokener 提供 OpenTelemetry 链路追踪，
+// 每次 HTTP 调用生成一个 span，URL 中的 access_token 等敏感参数会被脱敏
+package otelqy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName 为创建 Tracer 时使用的 instrumentation scope 名称
+const ScopeName = "github.com/shengbox/wechat-qy/otelqy"
+
+// span 的属性
+const (
+	AttrCorpID    = attribute.Key("wecom.corp_id")
+	AttrAgentID   = attribute.Key("wecom.agent_id")
+	AttrEndpoint  = attribute.Key("wecom.endpoint")
+	AttrErrCode   = attribute.Key("wecom.errcode")
+	AttrAttempt   = attribute.Key("wecom.attempt")
+	AttrForced    = attribute.Key("wecom.token.forced")
+	AttrFromStore = attribute.Key("wecom.token.from_store")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	corpID         string
+	agentID        string
+}
+
+// Option 为链路追踪的配置项
+type Option func(*config)
+
+// WithTracerProvider 用于指定 TracerProvider，未指定时使用 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithCorpID 用于在 span 中记录企业 ID
+func WithCorpID(corpID string) Option {
+	return func(c *config) {
+		c.corpID = corpID
+	}
+}
+
+// WithAgentID 用于在 span 中记录应用 ID
+func WithAgentID(agentID int64) Option {
+	return func(c *config) {
+		c.agentID = strconv.FormatInt(agentID, 10)
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	return c
+}
+
+func (c *config) tracer() trace.Tracer {
+	return c.tracerProvider.Tracer(ScopeName)
+}
+
+func (c *config) attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if c.corpID != "" {
+		attrs = append(attrs, AttrCorpID.String(c.corpID))
+	}
+	if c.agentID != "" {
+		attrs = append(attrs, AttrAgentID.String(c.agentID))
+	}
+	return attrs
+}
+
+// InstrumentAPI 方法用于为 API 的 Client 与 Tokener 开启链路追踪，企业 ID 取自 a.CorpID
+func InstrumentAPI(a *api.API, opts ...Option) {
+	opts = append([]Option{WithCorpID(a.CorpID)}, opts...)
+	InstrumentClient(a.Client, opts...)
+	InstrumentTokener(a.Tokener, opts...)
+}
+
+// InstrumentClient 方法用于为 Client 添加链路追踪的拦截器
+func InstrumentClient(c *base.Client, opts ...Option) {
+	c.Use(NewInterceptor(opts...))
+}
+
+// NewInterceptor 方法用于创建链路追踪的拦截器，每次 HTTP 调用（含重试）生成一个 span
+func NewInterceptor(opts ...Option) base.Interceptor {
+	cfg := newConfig(opts)
+	tracer := cfg.tracer()
+
+	return base.InterceptorFuncs{
+		Before: func(req *http.Request, info *base.CallInfo) (*http.Request, error) {
+			attrs := append(cfg.attributes(),
+				AttrEndpoint.String(info.Endpoint),
+				AttrAttempt.Int(info.Attempt),
+				attribute.String("http.request.method", info.Method),
+				attribute.String("url.full", base.RedactURL(info.URL)),
+			)
+			ctx, _ := tracer.Start(req.Context(), "wecom "+info.Endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			return req.WithContext(ctx), nil
+		},
+		After: func(req *http.Request, info *base.CallInfo) {
+			span := trace.SpanFromContext(req.Context())
+			if info.StatusCode != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", info.StatusCode))
+			}
+			span.SetAttributes(AttrErrCode.Int(info.ErrCode))
+
+			switch {
+			case info.Err != nil:
+				span.RecordError(info.Err)
+				span.SetStatus(codes.Error, info.Err.Error())
+			case info.ErrCode != base.ErrCodeOk:
+				span.SetStatus(codes.Error, info.ErrMsg)
+			}
+			span.End()
+		},
+	}
+}
+
+// InstrumentTokener 方法用于为 Tokener 的每次刷新生成 span
+func InstrumentTokener(t *base.Tokener, opts ...Option) {
+	cfg := newConfig(opts)
+	tracer := cfg.tracer()
+
+	t.OnRefresh(func(ctx context.Context, info *base.RefreshInfo) {
+		_, span := tracer.Start(ctx, "wecom token refresh",
+			trace.WithTimestamp(info.Start),
+			trace.WithAttributes(append(cfg.attributes(),
+				AttrForced.Bool(info.Forced),
+				AttrFromStore.Bool(info.FromStore),
+			)...),
+		)
+		if info.Err != nil {
+			span.RecordError(info.Err)
+			span.SetStatus(codes.Error, info.Err.Error())
+		}
+		span.End(trace.WithTimestamp(info.Start.Add(info.Duration)))
+	})
+}
@@ -0,0 +1,141 @@
+package otelqy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"github.com/shengbox/wechat-qy/wecomtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestInstrumentAPI(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+	a := srv.NewAPI("mockCorpID", "mockCorpSecret")
+	InstrumentAPI(a, WithTracerProvider(tp), WithAgentID(1000002))
+
+	if _, err := a.GetUser("nobody"); !errors.Is(err, base.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	for _, name := range []string{"wecom /cgi-bin/gettoken", "wecom token refresh", "wecom /cgi-bin/user/get"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("Expected span %q, got %v", name, spans)
+		}
+	}
+
+	span := spans["wecom /cgi-bin/user/get"]
+	attrs := span.Attributes()
+	if v, _ := attrValue(attrs, AttrCorpID); v.AsString() != "mockCorpID" {
+		t.Errorf("Unexpected corp id: %v", v.Emit())
+	}
+	if v, _ := attrValue(attrs, AttrAgentID); v.AsString() != "1000002" {
+		t.Errorf("Unexpected agent id: %v", v.Emit())
+	}
+	if v, _ := attrValue(attrs, AttrErrCode); v.AsInt64() != 60111 {
+		t.Errorf("Unexpected errcode: %v", v.Emit())
+	}
+	if v, _ := attrValue(attrs, "url.full"); !strings.Contains(v.AsString(), "access_token=***") {
+		t.Errorf("Expected access_token to be redacted, got %s", v.AsString())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status())
+	}
+
+	if v, _ := attrValue(spans["wecom /cgi-bin/gettoken"].Attributes(), "url.full"); strings.Contains(v.AsString(), "mockCorpSecret") {
+		t.Errorf("Expected corpsecret to be redacted, got %s", v.AsString())
+	}
+}
+
+type mockRoundTripper struct {
+	roundTripFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.roundTripFunc(req)
+}
+
+func TestInstrumentAPI_RedactsErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	// 获取 token 成功后其他请求均失败，错误信息中会带上含 access_token 的 URL
+	a := api.New("mockCorpID", "mockCorpSecret", "", "")
+	a.Client.SetRetryPolicy(nil)
+	a.Client.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/cgi-bin/gettoken" {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"access_token":"secret-token","expires_in":7200}`)),
+					Header:     make(http.Header),
+				}, nil
+			}
+			return nil, errors.New("connection refused")
+		},
+	}})
+	InstrumentAPI(a, WithTracerProvider(tp))
+	if _, err := a.GetUser("zhangsan"); err == nil {
+		t.Fatal("Expected transport error")
+	}
+
+	// 获取 token 失败，错误信息中会带上含 corpsecret 的 URL
+	b := api.New("mockCorpID", "mockCorpSecret", "", "")
+	b.Client.SetRetryPolicy(nil)
+	b.Client.SetHTTPClient(&http.Client{Transport: &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	}})
+	InstrumentAPI(b, WithTracerProvider(tp))
+	if _, err := b.GetUser("zhangsan"); err == nil {
+		t.Fatal("Expected transport error")
+	}
+
+	spans := recorder.Ended()
+	var failed []string
+	for _, span := range spans {
+		if span.Status().Code != codes.Error {
+			continue
+		}
+		failed = append(failed, span.Name())
+		recorded := fmt.Sprint(span.Status().Description, span.Events())
+		if !strings.Contains(recorded, "connection refused") {
+			t.Errorf("Expected error recorded in span %q, got %s", span.Name(), recorded)
+		}
+		if strings.Contains(recorded, "secret-token") || strings.Contains(recorded, "mockCorpSecret") {
+			t.Errorf("Expected error in span %q to be redacted, got %s", span.Name(), recorded)
+		}
+	}
+	want := "wecom /cgi-bin/user/get,wecom /cgi-bin/gettoken,wecom token refresh"
+	if strings.Join(failed, ",") != want {
+		t.Errorf("Unexpected failed spans: %v", failed)
+	}
+}
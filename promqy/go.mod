@@ -0,0 +1,34 @@
+module github.com/shengbox/wechat-qy/promqy
+
+go 1.20
+
+require (
+	github.com/prometheus/client_golang v1.19.0
+	github.com/shengbox/wechat-qy v0.0.0
+	github.com/shengbox/wechat-qy/otelqy v0.0.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-resty/resty/v2 v2.6.0 // indirect
+	github.com/heroicyang/wechat-crypter v0.0.0-20150326022142-2d3eafa552ae // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	go.opentelemetry.io/otel v1.24.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	go.opentelemetry.io/otel/trace v1.24.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
+
+replace (
+	github.com/shengbox/wechat-qy => ../
+	github.com/shengbox/wechat-qy/otelqy => ../otelqy
+)
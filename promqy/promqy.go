@@ -0,0 +1,118 @@
+// Package promqy 为 base.Client、base.Tokener 与 base.RateLimiter 提供 Prometheus 监控指标，
+// 包括接口调用次数及耗时、重试次数、令牌刷新次数以及限流等待时间
+package promqy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// Namespace 为指标名称的前缀
+const Namespace = "wecom"
+
+// Metrics 包含了所有的监控指标，同一个 Metrics 可以用于多个 Client 与 Tokener
+type Metrics struct {
+	Calls          *prometheus.CounterVec   // wecom_api_calls_total{endpoint,corp_id,errcode}
+	CallDuration   *prometheus.HistogramVec // wecom_api_call_duration_seconds{endpoint}
+	Retries        *prometheus.CounterVec   // wecom_api_retries_total{endpoint,corp_id}
+	TokenRefreshes *prometheus.CounterVec   // wecom_token_refreshes_total{corp_id,result}
+	RateLimitWaits *prometheus.HistogramVec // wecom_rate_limit_wait_seconds{endpoint}
+}
+
+// NewMetrics 方法用于创建 Metrics 实例并注册到 reg，reg 为 nil 时不注册
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		Calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "api_calls_total",
+			Help:      "Total number of WeCom API calls, labeled by errcode (\"error\" for network errors).",
+		}, []string{"endpoint", "corp_id", "errcode"}),
+		CallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "api_call_duration_seconds",
+			Help:      "Latency of WeCom API calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		Retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "api_retries_total",
+			Help:      "Total number of retried WeCom API calls.",
+		}, []string{"endpoint", "corp_id"}),
+		TokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Total number of access token refreshes, labeled by result (fetched, store, error).",
+		}, []string{"corp_id", "result"}),
+		RateLimitWaits: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time spent waiting for the client-side rate limiter.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60},
+		}, []string{"endpoint"}),
+	}
+
+	if reg != nil {
+		for _, c := range []prometheus.Collector{m.Calls, m.CallDuration, m.Retries, m.TokenRefreshes, m.RateLimitWaits} {
+			if err := reg.Register(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// InstrumentAPI 方法用于采集 API 的接口调用与令牌刷新指标
+func (m *Metrics) InstrumentAPI(a *api.API) {
+	m.InstrumentClient(a.Client, a.CorpID)
+	m.InstrumentTokener(a.Tokener, a.CorpID)
+}
+
+// InstrumentClient 方法用于为 Client 添加采集接口调用指标的拦截器
+func (m *Metrics) InstrumentClient(c *base.Client, corpID string) {
+	c.Use(m.NewInterceptor(corpID))
+}
+
+// NewInterceptor 方法用于创建采集接口调用指标的拦截器
+func (m *Metrics) NewInterceptor(corpID string) base.Interceptor {
+	return base.InterceptorFuncs{
+		After: func(req *http.Request, info *base.CallInfo) {
+			errcode := strconv.Itoa(info.ErrCode)
+			if info.Err != nil {
+				errcode = "error"
+			}
+			m.Calls.WithLabelValues(info.Endpoint, corpID, errcode).Inc()
+			m.CallDuration.WithLabelValues(info.Endpoint).Observe(info.Duration.Seconds())
+			if info.Attempt > 1 {
+				m.Retries.WithLabelValues(info.Endpoint, corpID).Inc()
+			}
+		},
+	}
+}
+
+// InstrumentTokener 方法用于采集 Tokener 的令牌刷新指标
+func (m *Metrics) InstrumentTokener(t *base.Tokener, corpID string) {
+	t.OnRefresh(func(ctx context.Context, info *base.RefreshInfo) {
+		result := "fetched"
+		switch {
+		case info.Err != nil:
+			result = "error"
+		case info.FromStore:
+			result = "store"
+		}
+		m.TokenRefreshes.WithLabelValues(corpID, result).Inc()
+	})
+}
+
+// InstrumentRateLimiter 方法用于采集 RateLimiter 的限流等待时间
+func (m *Metrics) InstrumentRateLimiter(l *base.RateLimiter) {
+	l.OnWait(func(endpoint string, wait time.Duration) {
+		m.RateLimitWaits.WithLabelValues(endpoint).Observe(wait.Seconds())
+	})
+}
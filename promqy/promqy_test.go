@@ -0,0 +1,119 @@
+package promqy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"github.com/shengbox/wechat-qy/otelqy"
+	"github.com/shengbox/wechat-qy/wecomtest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMetrics_InstrumentAPI(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewMetrics(reg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+	srv.AddUser(&api.User{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{1}})
+	// 第一次调用返回 token 失效，触发刷新 token 后重试
+	srv.InjectError("/cgi-bin/user/get", base.ErrCodeTokenInvalid, 1)
+	a := srv.NewAPI("mockCorpID", "mockCorpSecret")
+	m.InstrumentAPI(a)
+
+	if _, err := a.GetUser("zhangsan"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.Calls.WithLabelValues("/cgi-bin/user/get", "mockCorpID", "40001")); got != 1 {
+		t.Errorf("Expected 1 call with errcode 40001, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.Calls.WithLabelValues("/cgi-bin/user/get", "mockCorpID", "0")); got != 1 {
+		t.Errorf("Expected 1 successful call, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.Retries.WithLabelValues("/cgi-bin/user/get", "mockCorpID")); got != 1 {
+		t.Errorf("Expected 1 retry, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.TokenRefreshes.WithLabelValues("mockCorpID", "fetched")); got != 2 {
+		t.Errorf("Expected 2 token refreshes, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.CallDuration); got != 2 {
+		t.Errorf("Expected duration series for 2 endpoints, got %d", got)
+	}
+}
+
+func TestMetrics_InstrumentRateLimiter(t *testing.T) {
+	m, err := NewMetrics(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	limiter := base.NewRateLimiter(map[string][]base.RateLimit{
+		base.DefaultRateLimitPath: {{Limit: 1, Per: 20 * time.Millisecond}},
+	})
+	var waits int
+	limiter.OnWait(func(path string, wait time.Duration) {
+		waits++
+	})
+	m.InstrumentRateLimiter(limiter)
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background(), "corp", "app", "https://qyapi.weixin.qq.com/cgi-bin/message/send"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if got := testutil.CollectAndCount(m.RateLimitWaits, "wecom_rate_limit_wait_seconds"); got != 1 {
+		t.Errorf("Expected rate limit wait to be observed, got %d series", got)
+	}
+	if waits != 1 {
+		t.Errorf("Expected callback set before InstrumentRateLimiter to be kept, got %d calls", waits)
+	}
+}
+
+func TestMetrics_WithOtelqy(t *testing.T) {
+	m, err := NewMetrics(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+	srv.AddUser(&api.User{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{1}})
+	a := srv.NewAPI("mockCorpID", "mockCorpSecret")
+
+	// 先后开启链路追踪与指标采集，两者的回调均会被调用
+	otelqy.InstrumentAPI(a, otelqy.WithTracerProvider(tp))
+	m.InstrumentAPI(a)
+
+	if _, err := a.GetUser("zhangsan"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshSpans := 0
+	for _, span := range recorder.Ended() {
+		if span.Name() == "wecom token refresh" {
+			refreshSpans++
+		}
+	}
+	if refreshSpans != 1 {
+		t.Errorf("Expected 1 token refresh span, got %d", refreshSpans)
+	}
+	if got := testutil.ToFloat64(m.TokenRefreshes.WithLabelValues("mockCorpID", "fetched")); got != 1 {
+		t.Errorf("Expected 1 token refresh, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.Calls.WithLabelValues("/cgi-bin/user/get", "mockCorpID", "0")); got != 1 {
+		t.Errorf("Expected 1 successful call, got %v", got)
+	}
+}
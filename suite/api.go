@@ -1,6 +1,8 @@
 package suite
 
 import (
+	"context"
+
 	"github.com/shengbox/wechat-qy/api"
 	"github.com/shengbox/wechat-qy/base"
 )
@@ -24,13 +26,34 @@ func (s *Suite) NewAPI(corpID, permanentCode string) *API {
 
 	suiteAPI.Client = base.NewClient(suiteAPI)
 	suiteAPI.Tokener = base.NewTokener(suiteAPI)
+	if s.tokenStore != nil {
+		suiteAPI.SetTokenStore(s.tokenStore)
+	}
+	if s.rateLimiter != nil {
+		suiteAPI.SetRateLimiter(s.rateLimiter)
+	}
 
 	return suiteAPI
 }
 
+// SetTokenStore 方法用于设置授权企业 access_token 的共享存储
+func (a *API) SetTokenStore(store base.TokenStore) {
+	a.Tokener.SetStore(store, "corp_access_token:"+a.suite.id+":"+a.CorpID)
+}
+
+// SetRateLimiter 方法用于设置频率限制器，企业级配额按授权企业的 corpid 统计，应用级配额按 suite_id 区分
+func (a *API) SetRateLimiter(limiter *base.RateLimiter) {
+	a.Client.SetRateLimiter(limiter, a.CorpID, a.suite.id)
+}
+
 // FetchToken 方法用于向 API 服务器获取授权该套件的企业号的令牌信息
 func (a *API) FetchToken() (token string, expiresIn int64, err error) {
-	corpTokenInfo, err := a.suite.fetchCorpToken(a.CorpID, a.permanentCode)
+	return a.FetchTokenContext(context.Background())
+}
+
+// FetchTokenContext 为 FetchToken 的 context 版本
+func (a *API) FetchTokenContext(ctx context.Context) (token string, expiresIn int64, err error) {
+	corpTokenInfo, err := a.suite.fetchCorpToken(ctx, a.CorpID, a.permanentCode)
 	if err != nil {
 		return
 	}
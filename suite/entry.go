@@ -6,10 +6,9 @@ import (
 	"github.com/shengbox/wechat-qy/base"
 )
 
-type BaseResp struct {
-	Errcode int64  `json:"errcode"`
-	Errmsg  string `json:"errmsg"`
-}
+// BaseResp 为接口响应的公共字段
+type BaseResp = base.BaseResp
+
 type preAuthCodeInfo struct {
 	Code      string `json:"pre_auth_code"`
 	ExpiresIn int64  `json:"expires_in"`
@@ -350,8 +349,7 @@ type AuthUserInfo struct {
 // Generated by https://quicktype.io
 
 type UserInfo3RD struct {
-	Errcode    int64  `json:"errcode"`
-	Errmsg     string `json:"errmsg"`
+	BaseResp
 	CorpID     string `json:"CorpId"`
 	UserID     string `json:"UserId"`
 	DeviceID   string `json:"DeviceId"`
@@ -361,8 +359,7 @@ type UserInfo3RD struct {
 }
 
 type UserInfo3RDAuth struct {
-	Errcode    int64  `json:"errcode"`
-	Errmsg     string `json:"errmsg"`
+	BaseResp
 	CorpID     string `json:"corpid"`
 	UserID     string `json:"userid"`
 	DeviceID   string `json:"DeviceId"`
@@ -374,21 +371,19 @@ type UserInfo3RDAuth struct {
 // Generated by https://quicktype.io
 
 type UserInfoDetail3RD struct {
-	Errcode int64  `json:"errcode"`
-	Errmsg  string `json:"errmsg"`
-	Corpid  string `json:"corpid"`
-	Userid  string `json:"userid"`
-	Name    string `json:"name"`
-	Gender  string `json:"gender"`
-	Avatar  string `json:"avatar"`
-	QrCode  string `json:"qr_code"`
+	BaseResp
+	Corpid string `json:"corpid"`
+	Userid string `json:"userid"`
+	Name   string `json:"name"`
+	Gender string `json:"gender"`
+	Avatar string `json:"avatar"`
+	QrCode string `json:"qr_code"`
 }
 
 // Generated by https://quicktype.io
 
 type LoginInfo struct {
-	Errcode  int64    `json:"errcode"`
-	Errmsg   string   `json:"errmsg"`
+	BaseResp
 	Usertype int64    `json:"usertype"`
 	UserInfo UserInfo `json:"user_info"`
 	CorpInfo CorpInfo `json:"corp_info"`
@@ -413,25 +408,22 @@ type UserInfo struct {
 }
 
 type MediaInfo struct {
-	Errcode   int64  `json:"errcode"`
-	Errmsg    string `json:"errmsg"`
+	BaseResp
 	Type      string `json:"type"`
 	MediaID   string `json:"media_id"`
 	CreatedAt string `json:"created_at"`
 }
 
 type JobInfo struct {
-	Errcode int64  `json:"errcode"`
-	Errmsg  string `json:"errmsg"`
-	Jobid   string `json:"jobid"`
+	BaseResp
+	Jobid string `json:"jobid"`
 }
 
 type JobResult struct {
-	Errcode int64  `json:"errcode"`
-	Errmsg  string `json:"errmsg"`
-	Status  int64  `json:"status"`
-	Type    string `json:"type"`
-	Result  struct {
+	BaseResp
+	Status int64  `json:"status"`
+	Type   string `json:"type"`
+	Result struct {
 		ContactIDTranslate struct {
 			URL string `json:"url"`
 		} `json:"contact_id_translate"`
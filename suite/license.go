@@ -1,8 +1,8 @@
 package suite
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/url"
 )
 
@@ -21,7 +21,12 @@ const (
 
 // 获取订单列表
 func (s *Suite) ListOrder(corpId string) (*OrderListRes, error) {
-	token, err := s.providerToken()
+	return s.ListOrderContext(context.Background(), corpId)
+}
+
+// ListOrderContext 为 ListOrder 的 context 版本
+func (s *Suite) ListOrderContext(ctx context.Context, corpId string) (*OrderListRes, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +38,7 @@ func (s *Suite) ListOrder(corpId string) (*OrderListRes, error) {
 		params["corpid"] = corpId
 	}
 	buf, _ := json.Marshal(params)
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +49,12 @@ func (s *Suite) ListOrder(corpId string) (*OrderListRes, error) {
 
 // 获取订单详情
 func (s *Suite) GetOrder(orderID string) (*GetOrderResp, error) {
-	token, err := s.providerToken()
+	return s.GetOrderContext(context.Background(), orderID)
+}
+
+// GetOrderContext 为 GetOrder 的 context 版本
+func (s *Suite) GetOrderContext(ctx context.Context, orderID string) (*GetOrderResp, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +64,7 @@ func (s *Suite) GetOrder(orderID string) (*GetOrderResp, error) {
 	buf, _ := json.Marshal(map[string]any{
 		"order_id": orderID,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +75,12 @@ func (s *Suite) GetOrder(orderID string) (*GetOrderResp, error) {
 
 // 获取订单中的账号列表
 func (s *Suite) ListOrderAccount(orderID string) (*OrderAccountRes, error) {
-	token, err := s.providerToken()
+	return s.ListOrderAccountContext(context.Background(), orderID)
+}
+
+// ListOrderAccountContext 为 ListOrderAccount 的 context 版本
+func (s *Suite) ListOrderAccountContext(ctx context.Context, orderID string) (*OrderAccountRes, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +90,7 @@ func (s *Suite) ListOrderAccount(orderID string) (*OrderAccountRes, error) {
 	buf, _ := json.Marshal(map[string]any{
 		"order_id": orderID,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +101,12 @@ func (s *Suite) ListOrderAccount(orderID string) (*OrderAccountRes, error) {
 
 // 获取企业的账号列表
 func (s *Suite) ListActivedAccount(corpID string) (*ActivedList, error) {
-	token, err := s.providerToken()
+	return s.ListActivedAccountContext(context.Background(), corpID)
+}
+
+// ListActivedAccountContext 为 ListActivedAccount 的 context 版本
+func (s *Suite) ListActivedAccountContext(ctx context.Context, corpID string) (*ActivedList, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +117,7 @@ func (s *Suite) ListActivedAccount(corpID string) (*ActivedList, error) {
 		"corpid": corpID,
 		"limit":  1000,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +128,12 @@ func (s *Suite) ListActivedAccount(corpID string) (*ActivedList, error) {
 
 // 获取成员的激活详情
 func (s *Suite) GetActiveInfoByUser(corpID, userID string) (*ActiveInfoRes, error) {
-	token, err := s.providerToken()
+	return s.GetActiveInfoByUserContext(context.Background(), corpID, userID)
+}
+
+// GetActiveInfoByUserContext 为 GetActiveInfoByUser 的 context 版本
+func (s *Suite) GetActiveInfoByUserContext(ctx context.Context, corpID, userID string) (*ActiveInfoRes, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +144,7 @@ func (s *Suite) GetActiveInfoByUser(corpID, userID string) (*ActiveInfoRes, erro
 		"corpid": corpID,
 		"userid": userID,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +155,12 @@ func (s *Suite) GetActiveInfoByUser(corpID, userID string) (*ActiveInfoRes, erro
 
 // 获取激活码详情
 func (s *Suite) GetActiveInfoByCode(corpID, activeCode string) (*CodeActiveInfoRes, error) {
-	token, err := s.providerToken()
+	return s.GetActiveInfoByCodeContext(context.Background(), corpID, activeCode)
+}
+
+// GetActiveInfoByCodeContext 为 GetActiveInfoByCode 的 context 版本
+func (s *Suite) GetActiveInfoByCodeContext(ctx context.Context, corpID, activeCode string) (*CodeActiveInfoRes, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +171,7 @@ func (s *Suite) GetActiveInfoByCode(corpID, activeCode string) (*CodeActiveInfoR
 		"corpid":      corpID,
 		"active_code": activeCode,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +182,12 @@ func (s *Suite) GetActiveInfoByCode(corpID, activeCode string) (*CodeActiveInfoR
 
 // 转移激活码
 func (s *Suite) TransferLicense(corpID, handoverUserid, takeoverUserid string) (*[]TransferResult, error) {
-	token, err := s.providerToken()
+	return s.TransferLicenseContext(context.Background(), corpID, handoverUserid, takeoverUserid)
+}
+
+// TransferLicenseContext 为 TransferLicense 的 context 版本
+func (s *Suite) TransferLicenseContext(ctx context.Context, corpID, handoverUserid, takeoverUserid string) (*[]TransferResult, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -166,20 +201,25 @@ func (s *Suite) TransferLicense(corpID, handoverUserid, takeoverUserid string) (
 			"takeover_userid": takeoverUserid,
 		}},
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
 	result := TransferResultRes{}
 	err = json.Unmarshal(body, &result)
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result.TransferResult, err
 }
 
 func (s *Suite) GetAppLicenseInfo(suiteID, corpID string) (*AppLicenseInfoResp, error) {
-	token, err := s.providerToken()
+	return s.GetAppLicenseInfoContext(context.Background(), suiteID, corpID)
+}
+
+// GetAppLicenseInfoContext 为 GetAppLicenseInfo 的 context 版本
+func (s *Suite) GetAppLicenseInfoContext(ctx context.Context, suiteID, corpID string) (*AppLicenseInfoResp, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -190,20 +230,25 @@ func (s *Suite) GetAppLicenseInfo(suiteID, corpID string) (*AppLicenseInfoResp,
 		"corpid":   corpID,
 		"suite_id": suiteID,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
 	result := AppLicenseInfoResp{}
 	err = json.Unmarshal(body, &result)
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 func (s *Suite) GetAuthInfo(corpID, permanentCode string) (*GetAuthInfoRes, error) {
-	token, err := s.tokener.Token()
+	return s.GetAuthInfoContext(context.Background(), corpID, permanentCode)
+}
+
+// GetAuthInfoContext 为 GetAuthInfo 的 context 版本
+func (s *Suite) GetAuthInfoContext(ctx context.Context, corpID, permanentCode string) (*GetAuthInfoRes, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +259,7 @@ func (s *Suite) GetAuthInfo(corpID, permanentCode string) (*GetAuthInfoRes, erro
 		"auth_corpid":    corpID,
 		"permanent_code": permanentCode,
 	})
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -2,9 +2,9 @@ package suite
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"net/url"
 
@@ -51,6 +51,9 @@ type Suite struct {
 	providerTokener *base.Tokener
 	client          *base.Client
 	restyClient     *resty.Client
+	tokenStore      base.TokenStore
+	rateLimiter     *base.RateLimiter
+	providerCorpID  string
 }
 
 // New 方法用于创建 Suite 实例
@@ -74,18 +77,39 @@ func New(suiteID, suiteSecret, suiteToken, suiteEncodingAESKey string) *Suite {
 
 // SetProvider 方法用于设置服务商信息，以获取正确的 provider_access_token
 func (s *Suite) SetProvider(corpID, providerSecret string) {
+	s.providerCorpID = corpID
 	s.providerTokener = base.NewTokener(&providerTokenFetcher{
 		suite:          s,
 		corpID:         corpID,
 		providerSecret: providerSecret,
 	})
+	if s.tokenStore != nil {
+		s.providerTokener.SetStore(s.tokenStore, "provider_access_token:"+corpID)
+	}
+}
+
+// SetTokenStore 方法用于设置令牌的共享存储，suite_access_token、provider_access_token
+// 以及之后通过 NewAPI 创建的授权企业 access_token 都将通过该存储在多个实例间共享
+func (s *Suite) SetTokenStore(store base.TokenStore) {
+	s.tokenStore = store
+	s.tokener.SetStore(store, "suite_access_token:"+s.id)
+	if s.providerTokener != nil {
+		s.providerTokener.SetStore(store, "provider_access_token:"+s.providerCorpID)
+	}
 }
 
-func (s *Suite) providerToken() (string, error) {
+// SetRateLimiter 方法用于设置频率限制器，套件自身的接口按 suite_id 统计配额，
+// 之后通过 NewAPI 创建的授权企业 API 也会使用该限制器
+func (s *Suite) SetRateLimiter(limiter *base.RateLimiter) {
+	s.rateLimiter = limiter
+	s.client.SetRateLimiter(limiter, s.id, s.id)
+}
+
+func (s *Suite) providerToken(ctx context.Context) (string, error) {
 	if s.providerTokener != nil {
-		return s.providerTokener.Token()
+		return s.providerTokener.TokenContext(ctx)
 	}
-	return s.tokener.Token()
+	return s.tokener.TokenContext(ctx)
 }
 
 type providerTokenFetcher struct {
@@ -95,12 +119,16 @@ type providerTokenFetcher struct {
 }
 
 func (f *providerTokenFetcher) FetchToken() (token string, expiresIn int64, err error) {
+	return f.FetchTokenContext(context.Background())
+}
+
+func (f *providerTokenFetcher) FetchTokenContext(ctx context.Context) (token string, expiresIn int64, err error) {
 	buf, _ := json.Marshal(map[string]string{
 		"corpid":          f.corpID,
 		"provider_secret": f.providerSecret,
 	})
 
-	body, err := f.suite.client.PostJSON("https://qyapi.weixin.qq.com/cgi-bin/service/get_provider_token", buf)
+	body, err := f.suite.client.PostJSONContext(ctx, "https://qyapi.weixin.qq.com/cgi-bin/service/get_provider_token", buf)
 	if err != nil {
 		return
 	}
@@ -120,9 +148,13 @@ func (f *providerTokenFetcher) FetchToken() (token string, expiresIn int64, err
 	return
 }
 
-
 // Retriable 方法实现了套件在发起请求遇到 token 错误时，先刷新 token 然后再次发起请求的逻辑
 func (s *Suite) Retriable(reqURL string, body []byte) (bool, string, error) {
+	return s.RetriableContext(context.Background(), reqURL, body)
+}
+
+// RetriableContext 为 Retriable 的 context 版本，刷新 token 时沿用请求的 ctx
+func (s *Suite) RetriableContext(ctx context.Context, reqURL string, body []byte) (bool, string, error) {
 	u, err := url.Parse(reqURL)
 	if err != nil {
 		return false, "", nil
@@ -142,11 +174,11 @@ func (s *Suite) Retriable(reqURL string, body []byte) (bool, string, error) {
 	case base.ErrCodeOk:
 		return false, "", nil
 	case base.ErrCodeSuiteTokenInvalid, base.ErrCodeSuiteTokenTimeout, base.ErrCodeSuiteTokenFailure:
-		if err := s.tokener.RefreshToken(); err != nil {
+		if err := s.tokener.RefreshTokenContext(ctx); err != nil {
 			return false, "", err
 		}
 
-		token, err := s.tokener.Token()
+		token, err := s.tokener.TokenContext(ctx)
 		if err != nil {
 			return false, "", err
 		}
@@ -247,6 +279,26 @@ func (s *Suite) Parse(body []byte, signature, timestamp, nonce string) (interfac
 	return data, nil
 }
 
+// VerifyURL 方法用于验证应用套件的回调 URL，校验签名并解密 echostr，返回需要原样响应的明文，
+// 数据回调与指令回调的 receiveid 分别为企业 corpid 与 suite_id，因此这里不校验 receiveid
+func (s *Suite) VerifyURL(signature, timestamp, nonce, echostr string) (string, error) {
+	if signature != s.msgCrypter.GetSignature(timestamp, nonce, echostr) {
+		return "", fmt.Errorf("validate signature error")
+	}
+
+	echo, _, err := s.msgCrypter.Decrypt(echostr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(echo), nil
+}
+
+// NewCallbackHandler 方法用于创建应用套件回调地址的 http.Handler，同一路径下处理 URL 验证与消息接收
+func (s *Suite) NewCallbackHandler(onMessage base.RecvMessageFunc) *base.CallbackHandler {
+	return base.NewCallbackHandler(s, onMessage)
+}
+
 // Response 方法用于生成应用套件的被动响应消息
 func (s *Suite) Response(message []byte) ([]byte, error) {
 	msgEncrypt, err := s.msgCrypter.Encrypt(string(message))
@@ -275,13 +327,18 @@ func (s *Suite) SetTicket(suiteTicket string) {
 
 // FetchToken 方法用于向 API 服务器获取套件的令牌信息
 func (s *Suite) FetchToken() (token string, expiresIn int64, err error) {
+	return s.FetchTokenContext(context.Background())
+}
+
+// FetchTokenContext 为 FetchToken 的 context 版本
+func (s *Suite) FetchTokenContext(ctx context.Context) (token string, expiresIn int64, err error) {
 	buf, _ := json.Marshal(map[string]string{
 		"suite_id":     s.id,
 		"suite_secret": s.secret,
 		"suite_ticket": s.ticket,
 	})
 
-	body, err := s.client.PostJSON(suiteTokenURI, buf)
+	body, err := s.client.PostJSONContext(ctx, suiteTokenURI, buf)
 	if err != nil {
 		return
 	}
@@ -305,8 +362,8 @@ func (s *Suite) FetchToken() (token string, expiresIn int64, err error) {
 	return
 }
 
-func (s *Suite) getPreAuthCode(appIDs []int) (*preAuthCodeInfo, error) {
-	token, err := s.tokener.Token()
+func (s *Suite) getPreAuthCode(ctx context.Context, appIDs []int) (*preAuthCodeInfo, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -320,7 +377,7 @@ func (s *Suite) getPreAuthCode(appIDs []int) (*preAuthCodeInfo, error) {
 		"appid":    appIDs,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +390,12 @@ func (s *Suite) getPreAuthCode(appIDs []int) (*preAuthCodeInfo, error) {
 
 // GetAuthURI 方法用于获取应用套件的授权地址
 func (s *Suite) GetAuthURI(appIDs []int, redirectURI, state string) (string, error) {
-	preAuthCodeInfo, err := s.getPreAuthCode(appIDs)
+	return s.GetAuthURIContext(context.Background(), appIDs, redirectURI, state)
+}
+
+// GetAuthURIContext 为 GetAuthURI 的 context 版本
+func (s *Suite) GetAuthURIContext(ctx context.Context, appIDs []int, redirectURI, state string) (string, error) {
+	preAuthCodeInfo, err := s.getPreAuthCode(ctx, appIDs)
 	if err != nil {
 		return "", err
 	}
@@ -348,29 +410,44 @@ func (s *Suite) GetAuthURI(appIDs []int, redirectURI, state string) (string, err
 }
 
 func (s *Suite) GetPreAuthCode() (*preAuthCodeInfo, error) {
+	return s.GetPreAuthCodeContext(context.Background())
+}
+
+// GetPreAuthCodeContext 为 GetPreAuthCode 的 context 版本
+func (s *Suite) GetPreAuthCodeContext(ctx context.Context) (*preAuthCodeInfo, error) {
 	result := &preAuthCodeInfo{}
-	err := s.GetJSON(preAuthCodeURI, nil, result)
+	err := s.GetJSONContext(ctx, preAuthCodeURI, nil, result)
 	return result, err
 }
 
 // SetSessionInfo 设置授权配置
 func (s *Suite) SetSessionInfo(PreAuthCode string) error {
+	return s.SetSessionInfoContext(context.Background(), PreAuthCode)
+}
+
+// SetSessionInfoContext 为 SetSessionInfo 的 context 版本
+func (s *Suite) SetSessionInfoContext(ctx context.Context, PreAuthCode string) error {
 	body := map[string]interface{}{
 		"pre_auth_code": PreAuthCode,
 		"session_info":  map[string]interface{}{"auth_type": 1},
 	}
 	var result BaseResp
-	return s.PostJSON(setSessionInfoURI, nil, body, &result)
+	return s.PostJSONContext(ctx, setSessionInfoURI, nil, body, &result)
 }
 
 // GetInstallURI 方法用于获取应用套件的授权地址
 func (s *Suite) GetInstallURI(redirectURI, state string, isTest bool) (string, error) {
-	preAuthCodeInfo, err := s.GetPreAuthCode()
+	return s.GetInstallURIContext(context.Background(), redirectURI, state, isTest)
+}
+
+// GetInstallURIContext 为 GetInstallURI 的 context 版本
+func (s *Suite) GetInstallURIContext(ctx context.Context, redirectURI, state string, isTest bool) (string, error) {
+	preAuthCodeInfo, err := s.GetPreAuthCodeContext(ctx)
 	if err != nil {
 		return "", err
 	}
 	if isTest {
-		s.SetSessionInfo(preAuthCodeInfo.Code)
+		s.SetSessionInfoContext(ctx, preAuthCodeInfo.Code)
 	}
 
 	qs := url.Values{}
@@ -384,7 +461,12 @@ func (s *Suite) GetInstallURI(redirectURI, state string, isTest bool) (string, e
 
 // GetPermanentCode 方法用于获取企业的永久授权码
 func (s *Suite) GetPermanentCode(authCode string) (PermanentCodeInfo, error) {
-	token, err := s.tokener.Token()
+	return s.GetPermanentCodeContext(context.Background(), authCode)
+}
+
+// GetPermanentCodeContext 为 GetPermanentCode 的 context 版本
+func (s *Suite) GetPermanentCodeContext(ctx context.Context, authCode string) (PermanentCodeInfo, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return PermanentCodeInfo{}, err
 	}
@@ -398,7 +480,7 @@ func (s *Suite) GetPermanentCode(authCode string) (PermanentCodeInfo, error) {
 		"auth_code": authCode,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return PermanentCodeInfo{}, err
 	}
@@ -414,7 +496,12 @@ func (s *Suite) GetPermanentCode(authCode string) (PermanentCodeInfo, error) {
 
 // GetCorpAuthInfo 方法用于获取已授权当前套件的企业号的授权信息
 func (s *Suite) GetCorpAuthInfo(corpID, permanentCode string) (CorpAuthInfo, error) {
-	token, err := s.tokener.Token()
+	return s.GetCorpAuthInfoContext(context.Background(), corpID, permanentCode)
+}
+
+// GetCorpAuthInfoContext 为 GetCorpAuthInfo 的 context 版本
+func (s *Suite) GetCorpAuthInfoContext(ctx context.Context, corpID, permanentCode string) (CorpAuthInfo, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return CorpAuthInfo{}, err
 	}
@@ -429,7 +516,7 @@ func (s *Suite) GetCorpAuthInfo(corpID, permanentCode string) (CorpAuthInfo, err
 		"permanent_code": permanentCode,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return CorpAuthInfo{}, err
 	}
@@ -442,7 +529,12 @@ func (s *Suite) GetCorpAuthInfo(corpID, permanentCode string) (CorpAuthInfo, err
 
 // GetCropAgent 方法用于获取已授权当前套件的企业号的某个应用信息
 func (s *Suite) GetCropAgent(corpID, permanentCode, agentID string) (CorpAgent, error) {
-	token, err := s.tokener.Token()
+	return s.GetCropAgentContext(context.Background(), corpID, permanentCode, agentID)
+}
+
+// GetCropAgentContext 为 GetCropAgent 的 context 版本
+func (s *Suite) GetCropAgentContext(ctx context.Context, corpID, permanentCode, agentID string) (CorpAgent, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return CorpAgent{}, err
 	}
@@ -458,7 +550,7 @@ func (s *Suite) GetCropAgent(corpID, permanentCode, agentID string) (CorpAgent,
 		"agentid":        agentID,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return CorpAgent{}, err
 	}
@@ -471,7 +563,12 @@ func (s *Suite) GetCropAgent(corpID, permanentCode, agentID string) (CorpAgent,
 
 // UpdateCorpAgent 方法用于设置已授权当前套件的企业号的某个应用信息
 func (s *Suite) UpdateCorpAgent(corpID, permanentCode string, agent AgentEditInfo) error {
-	token, err := s.tokener.Token()
+	return s.UpdateCorpAgentContext(context.Background(), corpID, permanentCode, agent)
+}
+
+// UpdateCorpAgentContext 为 UpdateCorpAgent 的 context 版本
+func (s *Suite) UpdateCorpAgentContext(ctx context.Context, corpID, permanentCode string, agent AgentEditInfo) error {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -497,12 +594,12 @@ func (s *Suite) UpdateCorpAgent(corpID, permanentCode string, agent AgentEditInf
 		return err
 	}
 
-	_, err = s.client.PostJSON(uri, buf)
+	_, err = s.client.PostJSONContext(ctx, uri, buf)
 	return err
 }
 
-func (s *Suite) fetchCorpToken(corpID, permanentCode string) (*corpTokenInfo, error) {
-	token, err := s.tokener.Token()
+func (s *Suite) fetchCorpToken(ctx context.Context, corpID, permanentCode string) (*corpTokenInfo, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -517,7 +614,7 @@ func (s *Suite) fetchCorpToken(corpID, permanentCode string) (*corpTokenInfo, er
 		"permanent_code": permanentCode,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -529,7 +626,12 @@ func (s *Suite) fetchCorpToken(corpID, permanentCode string) (*corpTokenInfo, er
 }
 
 func (s *Suite) ActiveAccount(corpID, activeCode, userid string) (*BaseResp, error) {
-	token, err := s.providerToken()
+	return s.ActiveAccountContext(context.Background(), corpID, activeCode, userid)
+}
+
+// ActiveAccountContext 为 ActiveAccount 的 context 版本
+func (s *Suite) ActiveAccountContext(ctx context.Context, corpID, activeCode, userid string) (*BaseResp, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -544,7 +646,7 @@ func (s *Suite) ActiveAccount(corpID, activeCode, userid string) (*BaseResp, err
 		"userid":      userid,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -554,8 +656,8 @@ func (s *Suite) ActiveAccount(corpID, activeCode, userid string) (*BaseResp, err
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, err
@@ -563,7 +665,12 @@ func (s *Suite) ActiveAccount(corpID, activeCode, userid string) (*BaseResp, err
 
 // GetAdminList 获取应用的管理员列表
 func (s *Suite) GetAdminList(corpID, agentId string) ([]*Admin, error) {
-	token, err := s.tokener.Token()
+	return s.GetAdminListContext(context.Background(), corpID, agentId)
+}
+
+// GetAdminListContext 为 GetAdminList 的 context 版本
+func (s *Suite) GetAdminListContext(ctx context.Context, corpID, agentId string) ([]*Admin, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -577,7 +684,7 @@ func (s *Suite) GetAdminList(corpID, agentId string) ([]*Admin, error) {
 		"agentid":     agentId,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -593,7 +700,12 @@ func (s *Suite) GetAdminList(corpID, agentId string) ([]*Admin, error) {
 
 // GetRegisterCode 获取注册码
 func (s *Suite) GetRegisterCode(templateId string) (*RegisterCodeInfo, error) {
-	token, err := s.providerToken()
+	return s.GetRegisterCodeContext(context.Background(), templateId)
+}
+
+// GetRegisterCodeContext 为 GetRegisterCode 的 context 版本
+func (s *Suite) GetRegisterCodeContext(ctx context.Context, templateId string) (*RegisterCodeInfo, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -606,7 +718,7 @@ func (s *Suite) GetRegisterCode(templateId string) (*RegisterCodeInfo, error) {
 		"template_id": templateId,
 	})
 
-	body, err := s.client.PostJSON(uri, buf)
+	body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -619,7 +731,12 @@ func (s *Suite) GetRegisterCode(templateId string) (*RegisterCodeInfo, error) {
 
 // GetRegisterURI 方法用于获取应用套件的授权地址
 func (s *Suite) GetRegisterURI(templateId string) (string, error) {
-	registerCodeInfo, err := s.GetRegisterCode(templateId)
+	return s.GetRegisterURIContext(context.Background(), templateId)
+}
+
+// GetRegisterURIContext 为 GetRegisterURI 的 context 版本
+func (s *Suite) GetRegisterURIContext(ctx context.Context, templateId string) (string, error) {
+	registerCodeInfo, err := s.GetRegisterCodeContext(ctx, templateId)
 	if err != nil {
 		base.GetLogger().Println(err)
 		return "", err
@@ -633,11 +750,16 @@ func (s *Suite) GetRegisterURI(templateId string) (string, error) {
 
 // ContactSyncSuccess 设置通讯录同步完成
 func (s *Suite) ContactSyncSuccess(accessToken string) error {
+	return s.ContactSyncSuccessContext(context.Background(), accessToken)
+}
+
+// ContactSyncSuccessContext 为 ContactSyncSuccess 的 context 版本
+func (s *Suite) ContactSyncSuccessContext(ctx context.Context, accessToken string) error {
 	qs := url.Values{}
 	qs.Add("access_token", accessToken)
 	uri := contactSyncSuccessURI + "?" + qs.Encode()
 
-	body, err := s.client.GetJSON(uri)
+	body, err := s.client.GetJSONContext(ctx, uri)
 	if err != nil {
 		return err
 	}
@@ -651,95 +773,120 @@ func (s *Suite) ContactSyncSuccess(accessToken string) error {
 
 // Getuserinfo3rd 获取访问用户身份
 func (s *Suite) Getuserinfo3rd(code string) (*UserInfo3RD, error) {
-	token, err := s.tokener.Token()
+	return s.Getuserinfo3rdContext(context.Background(), code)
+}
+
+// Getuserinfo3rdContext 为 Getuserinfo3rd 的 context 版本
+func (s *Suite) Getuserinfo3rdContext(ctx context.Context, code string) (*UserInfo3RD, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result UserInfo3RD
-	_, err = s.restyClient.R().SetResult(&result).SetQueryParams(map[string]string{
+	_, err = s.restyClient.R().SetContext(ctx).SetResult(&result).SetQueryParams(map[string]string{
 		"suite_access_token": token,
 		"code":               code,
 	}).Get(getuserinfo3rdURI)
 
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 func (s *Suite) Getuserinfo3rdAuth(code string) (*UserInfo3RDAuth, error) {
-	token, err := s.tokener.Token()
+	return s.Getuserinfo3rdAuthContext(context.Background(), code)
+}
+
+// Getuserinfo3rdAuthContext 为 Getuserinfo3rdAuth 的 context 版本
+func (s *Suite) Getuserinfo3rdAuthContext(ctx context.Context, code string) (*UserInfo3RDAuth, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result UserInfo3RDAuth
-	resp, err := s.restyClient.R().SetResult(&result).SetQueryParams(map[string]string{
+	resp, err := s.restyClient.R().SetContext(ctx).SetResult(&result).SetQueryParams(map[string]string{
 		"suite_access_token": token,
 		"code":               code,
 	}).Get(getuserinfo3rdAuthURI)
 	fmt.Println(getuserinfo3rdAuthURI, resp.String())
 
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 // Getuserdetail3rd 获取访问用户敏感信息
 func (s *Suite) Getuserdetail3rd(userTicket string) (*UserInfoDetail3RD, error) {
-	token, err := s.tokener.Token()
+	return s.Getuserdetail3rdContext(context.Background(), userTicket)
+}
+
+// Getuserdetail3rdContext 为 Getuserdetail3rd 的 context 版本
+func (s *Suite) Getuserdetail3rdContext(ctx context.Context, userTicket string) (*UserInfoDetail3RD, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result UserInfoDetail3RD
-	_, err = s.restyClient.R().SetResult(&result).SetQueryParam("suite_access_token", token).SetBody(map[string]string{
+	_, err = s.restyClient.R().SetContext(ctx).SetResult(&result).SetQueryParam("suite_access_token", token).SetBody(map[string]string{
 		"user_ticket": userTicket,
 	}).Post(getuserdetail3rdURI)
 
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 // GetLoginInfo 获取登录用户信息
 func (s *Suite) GetLoginInfo(authCode string) (*LoginInfo, error) {
-	token, err := s.tokener.Token()
+	return s.GetLoginInfoContext(context.Background(), authCode)
+}
+
+// GetLoginInfoContext 为 GetLoginInfo 的 context 版本
+func (s *Suite) GetLoginInfoContext(ctx context.Context, authCode string) (*LoginInfo, error) {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result LoginInfo
-	_, err = s.restyClient.R().SetResult(&result).SetQueryParam("access_token", token).SetBody(map[string]string{
+	_, err = s.restyClient.R().SetContext(ctx).SetResult(&result).SetQueryParam("access_token", token).SetBody(map[string]string{
 		"auth_code": authCode,
 	}).Post(getLoginInfoURI)
 
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 // ContactIdTranslate 获取转义文件url
 func (s *Suite) ContactIdTranslate(corpid string, fileByte []byte) (string, error) {
-	token, err := s.providerToken()
+	return s.ContactIdTranslateContext(context.Background(), corpid, fileByte)
+}
+
+// ContactIdTranslateContext 为 ContactIdTranslate 的 context 版本
+func (s *Suite) ContactIdTranslateContext(ctx context.Context, corpid string, fileByte []byte) (string, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return "", err
 	}
 	client := s.restyClient
 	var media MediaInfo
-	_, err = client.R().SetResult(&media).
+	_, err = client.R().SetContext(ctx).SetResult(&media).
 		SetQueryParam("provider_access_token", token).SetQueryParam("type", "file").
 		SetFileReader("media", "员工列表.csv", bytes.NewReader(fileByte)).
 		Post(uploadURI)
 	if err != nil {
 		return "", err
 	}
-	if media.Errcode > 0 {
-		return "", errors.New(media.Errmsg)
+	if err := media.Err(); err != nil {
+		return "", err
 	}
 
 	var job JobInfo
-	_, err = client.R().SetResult(&job).SetQueryParam("provider_access_token", token).
+	_, err = client.R().SetContext(ctx).SetResult(&job).SetQueryParam("provider_access_token", token).
 		SetBody(map[string]interface{}{
 			"auth_corpid":   corpid,
 			"media_id_list": []string{media.MediaID},
@@ -749,33 +896,43 @@ func (s *Suite) ContactIdTranslate(corpid string, fileByte []byte) (string, erro
 	if err != nil {
 		return "", err
 	}
-	if job.Errcode > 0 {
-		return "", errors.New(job.Errmsg)
+	if err := job.Err(); err != nil {
+		return "", err
 	}
 	return job.Jobid, nil
 }
 
 // GetJobResultURI 获取任务结果
 func (s *Suite) GetJobResultURI(jobID string) (*JobResult, error) {
-	token, err := s.providerToken()
+	return s.GetJobResultURIContext(context.Background(), jobID)
+}
+
+// GetJobResultURIContext 为 GetJobResultURI 的 context 版本
+func (s *Suite) GetJobResultURIContext(ctx context.Context, jobID string) (*JobResult, error) {
+	token, err := s.providerToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result JobResult
-	_, err = s.restyClient.R().SetResult(&result).
+	_, err = s.restyClient.R().SetContext(ctx).SetResult(&result).
 		SetQueryParam("provider_access_token", token).
 		SetQueryParam("jobid", jobID).Get(getJobResultURI)
 	if err != nil {
 		return nil, err
 	}
-	if result.Errcode > 0 {
-		return nil, errors.New(result.Errmsg)
+	if err := result.Err(); err != nil {
+		return nil, err
 	}
 	return &result, err
 }
 
 func (s *Suite) PostJSON(uri string, param url.Values, body, result interface{}) error {
-	token, err := s.tokener.Token()
+	return s.PostJSONContext(context.Background(), uri, param, body, result)
+}
+
+// PostJSONContext 为 PostJSON 的 context 版本
+func (s *Suite) PostJSONContext(ctx context.Context, uri string, param url.Values, body, result interface{}) error {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -786,7 +943,7 @@ func (s *Suite) PostJSON(uri string, param url.Values, body, result interface{})
 	uri = uri + "?" + param.Encode()
 
 	buf, _ := json.Marshal(body)
-	_body, err := s.client.PostJSON(uri, buf)
+	_body, err := s.client.PostJSONContext(ctx, uri, buf)
 	if err != nil {
 		return err
 	}
@@ -794,7 +951,12 @@ func (s *Suite) PostJSON(uri string, param url.Values, body, result interface{})
 }
 
 func (s *Suite) GetJSON(uri string, qs url.Values, result interface{}) error {
-	token, err := s.tokener.Token()
+	return s.GetJSONContext(context.Background(), uri, qs, result)
+}
+
+// GetJSONContext 为 GetJSON 的 context 版本
+func (s *Suite) GetJSONContext(ctx context.Context, uri string, qs url.Values, result interface{}) error {
+	token, err := s.tokener.TokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -802,7 +964,7 @@ func (s *Suite) GetJSON(uri string, qs url.Values, result interface{}) error {
 		qs = url.Values{}
 	}
 	qs.Add("suite_access_token", token)
-	body, err := s.client.GetJSON(uri + "?" + qs.Encode())
+	body, err := s.client.GetJSONContext(ctx, uri+"?"+qs.Encode())
 	if err != nil {
 		return err
 	}
@@ -109,3 +109,25 @@ func TestSuite_ProviderTokenConfigured(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 }
+
+func TestSuite_VerifyURL(t *testing.T) {
+	s := New("mockSuiteID", "mockSuiteSecret", "mockSuiteToken", "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG")
+
+	echostr, err := s.msgCrypter.Encrypt("echo-12345")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	signature := s.msgCrypter.GetSignature("1409659813", "1372623149", echostr)
+
+	echo, err := s.VerifyURL(signature, "1409659813", "1372623149", echostr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if echo != "echo-12345" {
+		t.Errorf("Expected echo-12345, got %s", echo)
+	}
+
+	if _, err = s.VerifyURL("bad-signature", "1409659813", "1372623149", echostr); err == nil {
+		t.Error("Expected signature error, got nil")
+	}
+}
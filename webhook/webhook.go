@@ -0,0 +1,329 @@
+// Package webhook 为企业微信群机器人的 webhook 客户端。
+//
+// 群机器人通过 webhook 地址中的 key 鉴权，不需要 access_token，因此独立于 api.API：
+//
+//	robot := webhook.New("ROBOT_KEY")
+//	err := robot.Send(webhook.NewTextMessage("db-01 CPU 使用率超过 90%", []string{webhook.MentionAll}, nil))
+//
+// 接口错误与 api 包一致，返回 *base.Error；每个机器人默认限制为每分钟 20 条消息，
+// 超出时等待配额可用，可通过 SetRateLimiter 调整。
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+const (
+	sendURI        = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send"
+	uploadMediaURI = "https://qyapi.weixin.qq.com/cgi-bin/webhook/upload_media"
+)
+
+// MentionAll 用于在文本消息中提醒群中所有人
+const MentionAll = "@all"
+
+// 上传文件的类型
+const (
+	MediaTypeFile  = "file"
+	MediaTypeVoice = "voice"
+)
+
+// 群机器人消息的长度与数量限制
+const (
+	maxTextContentBytes        = 2048
+	maxMarkdownContentBytes    = 4096
+	maxImageBytes              = 2 << 20
+	maxArticles                = 8
+	maxArticleTitleBytes       = 128
+	maxArticleDescriptionBytes = 512
+)
+
+// DefaultRateLimits 为群机器人的默认频率限制，每个机器人发送的消息不可超过 20 条/分钟
+var DefaultRateLimits = map[string][]base.RateLimit{
+	"/cgi-bin/webhook/send":   {{Limit: 20, Per: time.Minute}},
+	base.DefaultRateLimitPath: nil,
+}
+
+// Text 为文本消息的内容，MentionedList 与 MentionedMobileList 分别按 userid 与手机号提醒群成员
+type Text struct {
+	Content             string   `json:"content"`
+	MentionedList       []string `json:"mentioned_list,omitempty"`
+	MentionedMobileList []string `json:"mentioned_mobile_list,omitempty"`
+}
+
+// Markdown 为 markdown 消息的内容
+type Markdown struct {
+	Content string `json:"content"`
+}
+
+// Image 为图片消息的内容，Base64 为图片内容的 base64 编码，MD5 为图片内容（编码前）的 md5 值
+type Image struct {
+	Base64 string `json:"base64"`
+	MD5    string `json:"md5"`
+}
+
+// News 为图文消息的内容
+type News struct {
+	Articles []api.Article `json:"articles"`
+}
+
+// File 为文件或语音消息的内容，MediaID 通过 UploadMedia 获取
+type File struct {
+	MediaID string `json:"media_id"`
+}
+
+// Message 为群机器人消息，MsgType 决定其余字段中哪一项有效
+type Message struct {
+	MsgType      api.MessageType   `json:"msgtype"`
+	Text         *Text             `json:"text,omitempty"`
+	Markdown     *Markdown         `json:"markdown,omitempty"`
+	Image        *Image            `json:"image,omitempty"`
+	News         *News             `json:"news,omitempty"`
+	File         *File             `json:"file,omitempty"`
+	Voice        *File             `json:"voice,omitempty"`
+	TemplateCard *api.TemplateCard `json:"template_card,omitempty"`
+
+	size int // 图片编码前的大小
+}
+
+// NewTextMessage 方法用于创建文本消息，userIDs 与 mobiles 为需要提醒的成员，可使用 MentionAll
+func NewTextMessage(content string, userIDs, mobiles []string) *Message {
+	return &Message{
+		MsgType: api.TextMsg,
+		Text:    &Text{Content: content, MentionedList: userIDs, MentionedMobileList: mobiles},
+	}
+}
+
+// NewMarkdownMessage 方法用于创建 markdown 消息
+func NewMarkdownMessage(content string) *Message {
+	return &Message{MsgType: api.MarkdownMsg, Markdown: &Markdown{Content: content}}
+}
+
+// NewImageMessage 方法用于由图片内容（JPG 或 PNG）创建图片消息，并计算 base64 编码与 md5 值
+func NewImageMessage(data []byte) *Message {
+	sum := md5.Sum(data)
+	return &Message{
+		MsgType: api.ImageMsg,
+		Image: &Image{
+			Base64: base64.StdEncoding.EncodeToString(data),
+			MD5:    hex.EncodeToString(sum[:]),
+		},
+		size: len(data),
+	}
+}
+
+// NewNewsMessage 方法用于创建图文消息
+func NewNewsMessage(articles ...api.Article) *Message {
+	return &Message{MsgType: api.NewsMsg, News: &News{Articles: articles}}
+}
+
+// NewFileMessage 方法用于创建文件消息
+func NewFileMessage(mediaID string) *Message {
+	return &Message{MsgType: api.FileMsg, File: &File{MediaID: mediaID}}
+}
+
+// NewVoiceMessage 方法用于创建语音消息
+func NewVoiceMessage(mediaID string) *Message {
+	return &Message{MsgType: api.VoiceMsg, Voice: &File{MediaID: mediaID}}
+}
+
+// NewTemplateCardMessage 方法用于创建模板卡片消息，群机器人仅支持文本通知型与图文展示型卡片
+func NewTemplateCardMessage(card *api.TemplateCard) *Message {
+	return &Message{MsgType: api.TemplateCardMsg, TemplateCard: card}
+}
+
+// Validate 方法用于按群机器人的字段限制校验消息，返回 *api.MessageValidationError
+func (m *Message) Validate() error {
+	verr := &api.MessageValidationError{MsgType: m.MsgType}
+	add := func(field, format string, args ...interface{}) {
+		verr.Fields = append(verr.Fields, &api.MessageFieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	switch m.MsgType {
+	case api.TextMsg:
+		if m.Text == nil || m.Text.Content == "" {
+			add("text.content", "is required")
+		} else if len(m.Text.Content) > maxTextContentBytes {
+			add("text.content", "exceeds %d bytes", maxTextContentBytes)
+		}
+	case api.MarkdownMsg:
+		if m.Markdown == nil || m.Markdown.Content == "" {
+			add("markdown.content", "is required")
+		} else if len(m.Markdown.Content) > maxMarkdownContentBytes {
+			add("markdown.content", "exceeds %d bytes", maxMarkdownContentBytes)
+		}
+	case api.ImageMsg:
+		if m.Image == nil || m.Image.Base64 == "" || m.Image.MD5 == "" {
+			add("image", "base64 and md5 are required")
+		} else if m.size > maxImageBytes {
+			add("image", "exceeds %d bytes", maxImageBytes)
+		}
+	case api.NewsMsg:
+		var articles []api.Article
+		if m.News != nil {
+			articles = m.News.Articles
+		}
+		if len(articles) < 1 || len(articles) > maxArticles {
+			add("news.articles", "must have 1 to %d items", maxArticles)
+		}
+		for i, article := range articles {
+			field := fmt.Sprintf("news.articles[%d]", i)
+			if article.Title == "" {
+				add(field+".title", "is required")
+			} else if len(article.Title) > maxArticleTitleBytes {
+				add(field+".title", "exceeds %d bytes", maxArticleTitleBytes)
+			}
+			if len(article.Description) > maxArticleDescriptionBytes {
+				add(field+".description", "exceeds %d bytes", maxArticleDescriptionBytes)
+			}
+			if article.URL == "" {
+				add(field+".url", "is required")
+			}
+		}
+	case api.FileMsg:
+		if m.File == nil || m.File.MediaID == "" {
+			add("file.media_id", "is required")
+		}
+	case api.VoiceMsg:
+		if m.Voice == nil || m.Voice.MediaID == "" {
+			add("voice.media_id", "is required")
+		}
+	case api.TemplateCardMsg:
+		if m.TemplateCard == nil {
+			add("template_card", "is required")
+			break
+		}
+		if t := m.TemplateCard.CardType; t != api.TextNoticeCard && t != api.NewsNoticeCard {
+			add("template_card.card_type", "%q is not supported by group robots", t)
+			break
+		}
+		var cardErr *api.MessageValidationError
+		if err := m.TemplateCard.Validate(); errors.As(err, &cardErr) {
+			verr.Fields = append(verr.Fields, cardErr.Fields...)
+		} else if err != nil {
+			add("template_card", "%v", err)
+		}
+	default:
+		add("msgtype", "%q is not supported by group robots", m.MsgType)
+	}
+
+	if len(verr.Fields) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// Robot 为群机器人的客户端，同一 key 的多个 Robot 需通过 SetRateLimiter 共用限流器以共享配额
+type Robot struct {
+	Key    string
+	Client *base.Client
+}
+
+// New 方法用于创建群机器人客户端，key 为 webhook 地址中 key 参数的值
+func New(key string) *Robot {
+	r := &Robot{
+		Key:    key,
+		Client: base.NewClient(nil),
+	}
+	r.SetRateLimiter(base.NewRateLimiter(DefaultRateLimits))
+	return r
+}
+
+// NewFromURL 方法用于由完整的 webhook 地址创建群机器人客户端
+func NewFromURL(webhookURL string) (*Robot, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	key := u.Query().Get("key")
+	if key == "" {
+		return nil, fmt.Errorf("webhook: missing key in %s", base.RedactURL(webhookURL))
+	}
+	return New(key), nil
+}
+
+// SetRateLimiter 方法用于设置限流器，配额按机器人的 key 计算，为 nil 时不限流
+func (r *Robot) SetRateLimiter(limiter *base.RateLimiter) {
+	r.Client.SetRateLimiter(limiter, r.Key, "")
+}
+
+// Send 方法用于发送群机器人消息
+func (r *Robot) Send(message *Message) error {
+	return r.SendContext(context.Background(), message)
+}
+
+// SendContext 为 Send 的 context 版本
+func (r *Robot) SendContext(ctx context.Context, message *Message) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+
+	// 与应用消息一致，不对内容中的 <、> 等字符做 HTML 转义
+	bf := new(bytes.Buffer)
+	encoder := json.NewEncoder(bf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(message); err != nil {
+		return err
+	}
+
+	_, err := r.Client.PostJSONContext(ctx, r.uri(sendURI, nil), bf.Bytes())
+	return err
+}
+
+// UploadMedia 方法用于上传文件或语音，返回 3 天内有效的 media_id，mediaType 为 MediaTypeFile 或 MediaTypeVoice
+func (r *Robot) UploadMedia(mediaType, filename string, reader io.Reader) (string, error) {
+	return r.UploadMediaContext(context.Background(), mediaType, filename, reader)
+}
+
+// UploadMediaContext 为 UploadMedia 的 context 版本
+func (r *Robot) UploadMediaContext(ctx context.Context, mediaType, filename string, reader io.Reader) (string, error) {
+	qs := make(url.Values)
+	qs.Add("type", mediaType)
+
+	body, err := r.Client.PostMultipartContext(ctx, r.uri(uploadMediaURI, qs), "media", filename, reader)
+	if err != nil {
+		return "", err
+	}
+
+	result := &struct {
+		MediaID string `json:"media_id"`
+	}{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return "", err
+	}
+	return result.MediaID, nil
+}
+
+// SendFile 方法用于上传文件并发送文件消息
+func (r *Robot) SendFile(filename string, reader io.Reader) error {
+	return r.SendFileContext(context.Background(), filename, reader)
+}
+
+// SendFileContext 为 SendFile 的 context 版本
+func (r *Robot) SendFileContext(ctx context.Context, filename string, reader io.Reader) error {
+	mediaID, err := r.UploadMediaContext(ctx, MediaTypeFile, filename, reader)
+	if err != nil {
+		return err
+	}
+	return r.SendContext(ctx, NewFileMessage(mediaID))
+}
+
+func (r *Robot) uri(uri string, qs url.Values) string {
+	if qs == nil {
+		qs = make(url.Values)
+	}
+	qs.Set("key", r.Key)
+	return uri + "?" + qs.Encode()
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"github.com/shengbox/wechat-qy/wecomtest"
+)
+
+func newTestRobot(srv *wecomtest.Server, key string) *Robot {
+	r := New(key)
+	r.Client.SetBaseURI(srv.URL)
+	return r
+}
+
+func TestRobot_Send(t *testing.T) {
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+	srv.AddRobot("robot-key")
+	robot := newTestRobot(srv, "robot-key")
+
+	if err := robot.Send(NewTextMessage("CPU > 90% <db-01>", []string{"zhangsan", MentionAll}, []string{"13800000000"})); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := robot.Send(NewImageMessage([]byte("hello"))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := robot.SendFile("report.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		`{"msgtype":"text","text":{"content":"CPU > 90% <db-01>","mentioned_list":["zhangsan","@all"],"mentioned_mobile_list":["13800000000"]}}`,
+		`{"msgtype":"image","image":{"base64":"aGVsbG8=","md5":"5d41402abc4b2a76b9719d911017c592"}}`,
+		`{"msgtype":"file","file":{"media_id":"media1"}}`,
+	}
+	var got []string
+	for _, message := range srv.RobotMessages("robot-key") {
+		got = append(got, string(message))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected messages:\n%s", strings.Join(got, "\n"))
+	}
+	uploads := srv.RobotUploads("robot-key")
+	if len(uploads) != 1 || uploads[0].Type != MediaTypeFile || uploads[0].Filename != "report.csv" || string(uploads[0].Data) != "a,b\n" {
+		t.Errorf("Unexpected uploads: %+v", uploads)
+	}
+
+	// 接口错误与 api 包一致，返回 *base.Error
+	other := newTestRobot(srv, "wrong-key")
+	var apiErr *base.Error
+	if err := other.Send(NewMarkdownMessage("**hi**")); !errors.As(err, &apiErr) || apiErr.ErrCode != 93000 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRobot_RateLimit(t *testing.T) {
+	srv := wecomtest.NewServer()
+	defer srv.Close()
+	srv.AddRobot("robot-key")
+	robot := newTestRobot(srv, "robot-key")
+
+	limiter := base.NewRateLimiter(DefaultRateLimits)
+	limiter.SetMode(base.RateLimitFailFast)
+	robot.SetRateLimiter(limiter)
+
+	for i := 0; i < 20; i++ {
+		if err := robot.Send(NewTextMessage("ping", nil, nil)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := robot.Send(NewTextMessage("ping", nil, nil)); !errors.Is(err, base.ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	// 上传文件不占用发送消息的配额
+	if _, err := robot.UploadMedia(MediaTypeFile, "a.txt", strings.NewReader("a")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if stats := limiter.Stats()["/cgi-bin/webhook/send"]; stats.Requests != 20 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// 不同机器人分别计算配额
+	srv.AddRobot("other-key")
+	other := newTestRobot(srv, "other-key")
+	other.SetRateLimiter(limiter)
+	if err := other.Send(NewTextMessage("ping", nil, nil)); errors.Is(err, base.ErrRateLimited) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMessage_Validate(t *testing.T) {
+	card := api.NewButtonInteractionCard("task-1", "审批", "", api.TemplateCardButton{Text: "同意", Key: "ok"})
+	var verr *api.MessageValidationError
+	if err := NewTemplateCardMessage(card).Validate(); !errors.As(err, &verr) || verr.Fields[0].Field != "template_card.card_type" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	notice := api.NewTextNoticeCard("告警", "db-01", nil)
+	if err := NewTemplateCardMessage(notice).Validate(); err == nil || !strings.Contains(err.Error(), "card_action") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := NewImageMessage(make([]byte, maxImageBytes+1)).Validate(); err == nil {
+		t.Error("Expected image size error")
+	}
+	if err := NewNewsMessage(api.Article{Title: "周报"}).Validate(); err == nil || !strings.Contains(err.Error(), "news.articles[0].url") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := NewFromURL("https://qyapi.weixin.qq.com/cgi-bin/webhook/send"); err == nil {
+		t.Error("Expected missing key error")
+	}
+	robot, err := NewFromURL("https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=abc")
+	if err != nil || robot.Key != "abc" {
+		t.Errorf("Unexpected robot: %v, %v", robot, err)
+	}
+}
@@ -0,0 +1,137 @@
+package wecomtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// AppChat 方法返回群聊数据的副本，不存在时返回 nil
+func (s *Server) AppChat(chatID string) *api.AppChat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[chatID]
+	if !ok {
+		return nil
+	}
+	cp := *c
+	cp.UserList = append([]string(nil), c.UserList...)
+	return &cp
+}
+
+// AppChatMessages 方法返回通过 appchat/send 发送到群聊的所有消息的原始 JSON
+func (s *Server) AppChatMessages(chatID string) []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.chatMsgs[chatID]...)
+}
+
+func (s *Server) createAppChat(w http.ResponseWriter, r *http.Request) {
+	chat := &api.AppChat{}
+	if !decode(w, r, chat) {
+		return
+	}
+	if len(chat.UserList) < api.MinAppChatMembers {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	for _, userID := range chat.UserList {
+		if _, ok := s.users[userID]; !ok {
+			writeError(w, base.ErrCodeInvalidUserID, errmsg(base.ErrCodeInvalidUserID))
+			return
+		}
+	}
+	if chat.ChatID == "" {
+		chat.ChatID = "chat" + strconv.Itoa(len(s.chats)+1)
+	}
+	if _, ok := s.chats[chat.ChatID]; ok {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	if chat.Owner == "" {
+		chat.Owner = chat.UserList[0]
+	}
+
+	s.chats[chat.ChatID] = chat
+	writeJSON(w, map[string]interface{}{"chatid": chat.ChatID})
+}
+
+func (s *Server) updateAppChat(w http.ResponseWriter, r *http.Request) {
+	req := &api.AppChatUpdate{}
+	if !decode(w, r, req) {
+		return
+	}
+	chat, ok := s.chats[req.ChatID]
+	if !ok {
+		writeError(w, base.ErrCodeAppChatNotFound, errmsg(base.ErrCodeAppChatNotFound))
+		return
+	}
+
+	members := make(map[string]bool)
+	for _, userID := range chat.UserList {
+		members[userID] = true
+	}
+	for _, userID := range req.AddUserList {
+		if _, ok := s.users[userID]; !ok {
+			writeError(w, base.ErrCodeInvalidUserID, errmsg(base.ErrCodeInvalidUserID))
+			return
+		}
+		members[userID] = true
+	}
+	for _, userID := range req.DelUserList {
+		delete(members, userID)
+	}
+	owner := chat.Owner
+	if req.Owner != "" {
+		owner = req.Owner
+	}
+	if !members[owner] || len(members) < api.MinAppChatMembers {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+
+	// 保持原有成员顺序，新成员追加在末尾
+	userList := make([]string, 0, len(members))
+	for _, userID := range append(chat.UserList, req.AddUserList...) {
+		if members[userID] {
+			userList = append(userList, userID)
+			delete(members, userID)
+		}
+	}
+	chat.UserList = userList
+	chat.Owner = owner
+	if req.Name != "" {
+		chat.Name = req.Name
+	}
+	writeJSON(w, nil)
+}
+
+func (s *Server) getAppChat(w http.ResponseWriter, r *http.Request) {
+	chat, ok := s.chats[r.URL.Query().Get("chatid")]
+	if !ok {
+		writeError(w, base.ErrCodeAppChatNotFound, errmsg(base.ErrCodeAppChatNotFound))
+		return
+	}
+	writeJSON(w, map[string]interface{}{"chat_info": chat})
+}
+
+func (s *Server) sendAppChat(w http.ResponseWriter, r *http.Request) {
+	message := json.RawMessage{}
+	if !decode(w, r, &message) {
+		return
+	}
+	req := struct {
+		ChatID string `json:"chatid"`
+	}{}
+	_ = json.Unmarshal(message, &req)
+
+	if _, ok := s.chats[req.ChatID]; !ok {
+		writeError(w, base.ErrCodeAppChatNotFound, errmsg(base.ErrCodeAppChatNotFound))
+		return
+	}
+	s.chatMsgs[req.ChatID] = append(s.chatMsgs[req.ChatID], message)
+	writeJSON(w, nil)
+}
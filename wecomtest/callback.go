@@ -0,0 +1,158 @@
+package wecomtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	crypter "github.com/heroicyang/wechat-crypter"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// CallbackSimulator 用于模拟企业微信向回调地址推送消息，
+// 生成的请求使用与被测服务相同的 token 和 EncodingAESKey 加密并签名
+type CallbackSimulator struct {
+	msgCrypter crypter.MessageCrypter
+	receiverID string
+}
+
+// NewCallbackSimulator 方法用于创建回调模拟器，receiverID 为企业的 corpid 或第三方应用的 suite_id
+func NewCallbackSimulator(token, encodingAESKey, receiverID string) (*CallbackSimulator, error) {
+	msgCrypter, err := crypter.NewMessageCrypter(token, encodingAESKey, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackSimulator{msgCrypter: msgCrypter, receiverID: receiverID}, nil
+}
+
+// Marshal 方法用于将消息或事件结构（如 *api.RecvTextMessage、*suite.RecvSuiteTicket）序列化为以 <xml> 为根节点的明文，
+// event 为 []byte 或 string 时原样返回
+func (c *CallbackSimulator) Marshal(event interface{}) ([]byte, error) {
+	switch v := event.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	buf := new(bytes.Buffer)
+	if err := xml.NewEncoder(buf).EncodeElement(event, xml.StartElement{Name: xml.Name{Local: "xml"}}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encrypt 方法用于生成加密后的回调请求体及对应的签名参数
+func (c *CallbackSimulator) Encrypt(event interface{}) (body []byte, signature, timestamp, nonce string, err error) {
+	plain, err := c.Marshal(event)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	encrypt, err := c.msgCrypter.Encrypt(string(plain))
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	timestamp = strconv.Itoa(base.GenerateTimestamp())
+	nonce = base.GenerateNonce()
+	signature = c.msgCrypter.GetSignature(timestamp, nonce, encrypt)
+
+	body, err = xml.Marshal(&struct {
+		XMLName    xml.Name `xml:"xml"`
+		ToUserName base.CDATAText
+		Encrypt    base.CDATAText
+	}{
+		ToUserName: base.StringToCDATA(c.receiverID),
+		Encrypt:    base.StringToCDATA(encrypt),
+	})
+	return body, signature, timestamp, nonce, err
+}
+
+// NewRequest 方法用于生成推送消息或事件的 POST 请求，target 为回调地址的路径（如 /callback），
+// 返回的请求可直接交给被测的 http.Handler 处理
+func (c *CallbackSimulator) NewRequest(target string, event interface{}) (*http.Request, error) {
+	body, signature, timestamp, nonce, err := c.Encrypt(event)
+	if err != nil {
+		return nil, err
+	}
+
+	qs := make(url.Values)
+	qs.Set("msg_signature", signature)
+	qs.Set("timestamp", timestamp)
+	qs.Set("nonce", nonce)
+
+	req := httptest.NewRequest(http.MethodPost, withQuery(target, qs), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "text/xml")
+	return req, nil
+}
+
+// NewVerifyRequest 方法用于生成验证回调 URL 的 GET 请求，被测服务应原样响应 echo
+func (c *CallbackSimulator) NewVerifyRequest(target, echo string) (*http.Request, error) {
+	echostr, err := c.msgCrypter.Encrypt(echo)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.Itoa(base.GenerateTimestamp())
+	nonce := base.GenerateNonce()
+
+	qs := make(url.Values)
+	qs.Set("msg_signature", c.msgCrypter.GetSignature(timestamp, nonce, echostr))
+	qs.Set("timestamp", timestamp)
+	qs.Set("nonce", nonce)
+	qs.Set("echostr", echostr)
+
+	return httptest.NewRequest(http.MethodGet, withQuery(target, qs), nil), nil
+}
+
+// DecodeReply 方法用于校验并解密被动响应（如 Response 方法的返回值），返回明文 XML
+func (c *CallbackSimulator) DecodeReply(reply []byte) ([]byte, error) {
+	respBody := &struct {
+		Encrypt      string
+		MsgSignature string
+		TimeStamp    string
+		Nonce        string
+	}{}
+	if err := xml.Unmarshal(reply, respBody); err != nil {
+		return nil, err
+	}
+
+	if respBody.MsgSignature != c.msgCrypter.GetSignature(respBody.TimeStamp, respBody.Nonce, respBody.Encrypt) {
+		return nil, fmt.Errorf("validate signature error")
+	}
+
+	plain, receiverID, err := c.msgCrypter.Decrypt(respBody.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	if receiverID != c.receiverID {
+		return nil, fmt.Errorf("the reply is for [%s], not for [%s]", receiverID, c.receiverID)
+	}
+	return plain, nil
+}
+
+// DecodeReplyTo 方法用于校验并解密被动响应，并将明文 XML 解析到 v 中
+func (c *CallbackSimulator) DecodeReplyTo(reply []byte, v interface{}) error {
+	plain, err := c.DecodeReply(reply)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(plain, v)
+}
+
+func withQuery(target string, qs url.Values) string {
+	if target == "" {
+		target = "/"
+	}
+	sep := "?"
+	if strings.Contains(target, "?") {
+		sep = "&"
+	}
+	return target + sep + qs.Encode()
+}
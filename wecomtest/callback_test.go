@@ -0,0 +1,143 @@
+package wecomtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+	"github.com/shengbox/wechat-qy/suite"
+)
+
+const (
+	testToken          = "mockToken"
+	testEncodingAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+)
+
+func TestCallbackSimulator_Router(t *testing.T) {
+	a := api.New("ww-corp", "secret", testToken, testEncodingAESKey)
+	router := a.NewRouter()
+	router.OnText(func(msg *api.RecvTextMessage) api.Reply {
+		return &api.RespTextMessage{
+			RespBaseData: api.RespBaseData{
+				ToUserName:   base.StringToCDATA(msg.FromUserName),
+				FromUserName: base.StringToCDATA(msg.ToUserName),
+				MsgType:      base.StringToCDATA(string(api.TextMsg)),
+			},
+			Content: base.StringToCDATA("echo: " + msg.Content),
+		}
+	})
+
+	var event *api.RecChangeExternalContactEvent
+	router.OnChangeExternalContact("add_external_contact", func(e *api.RecChangeExternalContactEvent) api.Reply {
+		event = e
+		return nil
+	})
+
+	sim, err := NewCallbackSimulator(testToken, testEncodingAESKey, "ww-corp")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req, err := sim.NewRequest("/callback", &api.RecvTextMessage{
+		RecvBaseData: api.RecvBaseData{ToUserName: "ww-corp", FromUserName: "zhangsan", MsgType: api.TextMsg, AgentID: 1000002},
+		Content:      "hello",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body)
+	}
+
+	reply := &struct {
+		ToUserName string
+		Content    string
+	}{}
+	if err := sim.DecodeReplyTo(w.Body.Bytes(), reply); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply.Content != "echo: hello" || reply.ToUserName != "zhangsan" {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+
+	req, err = sim.NewRequest("/callback", &api.RecChangeExternalContactEvent{
+		RecvBaseData:   api.RecvBaseData{ToUserName: "ww-corp", FromUserName: "sys", MsgType: api.EventMsg},
+		Event:          api.ChangeExternalContactEvent,
+		ChangeType:     "add_external_contact",
+		UserID:         "zhangsan",
+		ExternalUserID: "wmXXX",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body)
+	}
+	if event == nil || event.ExternalUserID != "wmXXX" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	req, err = sim.NewVerifyRequest("/callback", "echo-123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "echo-123" {
+		t.Errorf("Expected echo-123, got %q", w.Body)
+	}
+}
+
+func TestCallbackSimulator_Suite(t *testing.T) {
+	s := suite.New("suite-id", "suite-secret", testToken, testEncodingAESKey)
+
+	sim, err := NewCallbackSimulator(testToken, testEncodingAESKey, "suite-id")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	body, signature, timestamp, nonce, err := sim.Encrypt(&suite.RecvSuiteTicket{
+		SuiteId:     "suite-id",
+		InfoType:    "suite_ticket",
+		SuiteTicket: "ticket-123",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := s.Parse(body, signature, timestamp, nonce)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ticket, ok := data.(*suite.RecvSuiteTicket)
+	if !ok || ticket.SuiteTicket != "ticket-123" {
+		t.Errorf("Unexpected data: %#v", data)
+	}
+
+	if _, err := s.Parse(body, "bad-signature", timestamp, nonce); err == nil {
+		t.Error("Expected signature error")
+	}
+
+	resp, err := s.Response([]byte("<xml><Content>ok</Content></xml>"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plain, err := sim.DecodeReply(resp)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(plain) != "<xml><Content>ok</Content></xml>" {
+		t.Errorf("Unexpected reply: %s", plain)
+	}
+
+	other, _ := NewCallbackSimulator(testToken, testEncodingAESKey, "other-suite")
+	if _, err := other.DecodeReply(resp); err == nil {
+		t.Error("Expected receiver id mismatch error")
+	}
+}
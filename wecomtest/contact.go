@@ -0,0 +1,944 @@
+package wecomtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// Tag 为模拟服务端中的通讯录标签
+type Tag struct {
+	ID       int
+	Name     string
+	UserIDs  []string
+	PartyIDs []int64
+}
+
+// ExternalContact 为模拟服务端中的客户，FollowUsers 为添加了该客户的成员
+type ExternalContact struct {
+	Contact     api.ExternalContact
+	FollowUsers []api.FollowUser
+}
+
+func (s *Server) registerHandlers() {
+	s.handlers = make(map[string]handlerFunc)
+
+	s.handle("/cgi-bin/gettoken", s.getToken)
+
+	s.handle("/cgi-bin/user/create", s.createUser)
+	s.handle("/cgi-bin/user/update", s.updateUser)
+	s.handle("/cgi-bin/user/delete", s.deleteUser)
+	s.handle("/cgi-bin/user/batchdelete", s.batchDeleteUser)
+	s.handle("/cgi-bin/user/get", s.getUser)
+	s.handle("/cgi-bin/user/simplelist", s.listUser)
+	s.handle("/cgi-bin/user/list", s.listUser)
+	s.handle("/cgi-bin/user/list_id", s.listUserID)
+	s.handle("/cgi-bin/user/getuserid", s.getUserID)
+	s.handle("/cgi-bin/user/get_userid_by_email", s.getUserID)
+	s.handle("/cgi-bin/user/convert_to_openid", s.convertToOpenID)
+	s.handle("/cgi-bin/user/convert_to_userid", s.convertToUserID)
+	s.handle("/cgi-bin/user/authsucc", s.authSucc)
+
+	s.handle("/cgi-bin/department/create", s.createDepartment)
+	s.handle("/cgi-bin/department/update", s.updateDepartment)
+	s.handle("/cgi-bin/department/delete", s.deleteDepartment)
+	s.handle("/cgi-bin/department/list", s.listDepartment)
+	s.handle("/cgi-bin/department/simplelist", s.listDepartment)
+	s.handle("/cgi-bin/department/get", s.getDepartment)
+
+	s.handle("/cgi-bin/tag/create", s.createTag)
+	s.handle("/cgi-bin/tag/update", s.updateTag)
+	s.handle("/cgi-bin/tag/delete", s.deleteTag)
+	s.handle("/cgi-bin/tag/get", s.getTag)
+	s.handle("/cgi-bin/tag/addtagusers", s.addTagUsers)
+	s.handle("/cgi-bin/tag/deltagusers", s.delTagUsers)
+	s.handle("/cgi-bin/tag/list", s.listTag)
+
+	s.handle("/cgi-bin/externalcontact/list", s.listExternalContact)
+	s.handle("/cgi-bin/externalcontact/get", s.getExternalContact)
+	s.handle("/cgi-bin/externalcontact/batch/get_by_user", s.batchExternalContact)
+
+	s.handle("/cgi-bin/message/send", s.sendMessage)
+	s.handle("/cgi-bin/message/recall", s.recallMessage)
+	s.handle("/cgi-bin/message/update_template_card", s.updateTemplateCard)
+
+	s.handle("/cgi-bin/appchat/create", s.createAppChat)
+	s.handle("/cgi-bin/appchat/update", s.updateAppChat)
+	s.handle("/cgi-bin/appchat/get", s.getAppChat)
+	s.handle("/cgi-bin/appchat/send", s.sendAppChat)
+
+	s.handle("/cgi-bin/webhook/send", s.sendWebhook)
+	s.handle("/cgi-bin/webhook/upload_media", s.uploadWebhookMedia)
+}
+
+// AddUser 方法用于直接添加成员数据，已存在时覆盖
+func (s *Server) AddUser(user *api.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := *user
+	s.users[u.UserID] = &u
+}
+
+// User 方法返回成员数据的副本，不存在时返回 nil
+func (s *Server) User(userID string) *api.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	cp := *u
+	return &cp
+}
+
+// AddDepartment 方法用于直接添加部门数据，已存在时覆盖
+func (s *Server) AddDepartment(department *api.Department) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := *department
+	s.departments[d.ID] = &d
+	if d.ID >= s.nextDeptID {
+		s.nextDeptID = d.ID + 1
+	}
+}
+
+// Department 方法返回部门数据的副本，不存在时返回 nil
+func (s *Server) Department(id int64) *api.Department {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.departments[id]
+	if !ok {
+		return nil
+	}
+	cp := *d
+	return &cp
+}
+
+// AddTag 方法用于直接添加标签数据，已存在时覆盖
+func (s *Server) AddTag(tag *Tag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *tag
+	s.tags[t.ID] = &t
+	if t.ID >= s.nextTagID {
+		s.nextTagID = t.ID + 1
+	}
+}
+
+// Tag 方法返回标签数据的副本，不存在时返回 nil
+func (s *Server) Tag(id int) *Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tags[id]
+	if !ok {
+		return nil
+	}
+	cp := *t
+	cp.UserIDs = append([]string(nil), t.UserIDs...)
+	cp.PartyIDs = append([]int64(nil), t.PartyIDs...)
+	return &cp
+}
+
+// AddExternalContact 方法用于直接添加客户数据，已存在时覆盖
+func (s *Server) AddExternalContact(contact *ExternalContact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *contact
+	s.contacts[c.Contact.ExternalUserid] = &c
+}
+
+// Messages 方法返回通过 message/send 发送的所有消息的原始 JSON
+func (s *Server) Messages() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.messages...)
+}
+
+// Recalled 方法返回 msgid 对应的消息是否已通过 message/recall 撤回
+func (s *Server) Recalled(msgID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recalled[msgID]
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	user := &api.User{}
+	if !decode(w, r, user) {
+		return
+	}
+	if user.UserID == "" {
+		writeError(w, base.ErrCodeInvalidUserID, errmsg(base.ErrCodeInvalidUserID))
+		return
+	}
+	if _, ok := s.users[user.UserID]; ok {
+		writeError(w, base.ErrCodeUserExists, errmsg(base.ErrCodeUserExists))
+		return
+	}
+	if !s.departmentsExist(user.DepartmentIds) {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+	if len(user.DepartmentIds) == 0 {
+		user.DepartmentIds = []int64{1}
+	}
+
+	s.users[user.UserID] = user
+	writeJSON(w, nil)
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	patch := make(map[string]json.RawMessage)
+	if !decode(w, r, &patch) {
+		return
+	}
+
+	var userID string
+	json.Unmarshal(patch["userid"], &userID)
+	user, ok := s.users[userID]
+	if !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+
+	// 仅更新请求中出现的字段
+	updated := &api.User{}
+	if !merge(user, patch, updated) {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	if !s.departmentsExist(updated.DepartmentIds) {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+
+	s.users[userID] = updated
+	writeJSON(w, nil)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("userid")
+	if _, ok := s.users[userID]; !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+	delete(s.users, userID)
+	writeJSON(w, nil)
+}
+
+func (s *Server) batchDeleteUser(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		UserIDList []string `json:"useridlist"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	for _, userID := range req.UserIDList {
+		if _, ok := s.users[userID]; !ok {
+			writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+			return
+		}
+	}
+	for _, userID := range req.UserIDList {
+		delete(s.users, userID)
+	}
+	writeJSON(w, nil)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.users[r.URL.Query().Get("userid")]
+	if !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+	writeJSON(w, toMap(user))
+}
+
+func (s *Server) listUser(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	departmentID, _ := strconv.ParseInt(q.Get("department_id"), 10, 64)
+	if _, ok := s.departments[departmentID]; !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+
+	departments := map[int64]bool{departmentID: true}
+	if q.Get("fetch_child") == "1" {
+		for _, id := range s.descendants(departmentID) {
+			departments[id] = true
+		}
+	}
+
+	simple := r.URL.Path == "/cgi-bin/user/simplelist"
+	userList := []interface{}{}
+	for _, user := range s.sortedUsers() {
+		for _, id := range user.DepartmentIds {
+			if departments[id] {
+				if simple {
+					userList = append(userList, map[string]interface{}{
+						"userid":     user.UserID,
+						"name":       user.Name,
+						"department": user.DepartmentIds,
+					})
+				} else {
+					userList = append(userList, toMap(user))
+				}
+				break
+			}
+		}
+	}
+	writeJSON(w, map[string]interface{}{"userlist": userList})
+}
+
+func (s *Server) listUserID(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 10000 {
+		req.Limit = 10000
+	}
+
+	start, _ := strconv.Atoi(req.Cursor)
+	users := s.sortedUsers()
+	if start > len(users) {
+		start = len(users)
+	}
+	end := start + req.Limit
+	if end > len(users) {
+		end = len(users)
+	}
+
+	deptUser := []interface{}{}
+	for _, user := range users[start:end] {
+		for _, id := range user.DepartmentIds {
+			deptUser = append(deptUser, map[string]interface{}{"userid": user.UserID, "department": id})
+		}
+	}
+
+	nextCursor := ""
+	if end < len(users) {
+		nextCursor = strconv.Itoa(end)
+	}
+	writeJSON(w, map[string]interface{}{"next_cursor": nextCursor, "dept_user": deptUser})
+}
+
+// getUserID 按手机号或邮箱查找成员
+func (s *Server) getUserID(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		Mobile string `json:"mobile"`
+		Email  string `json:"email"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+
+	for _, user := range s.sortedUsers() {
+		if (req.Mobile != "" && user.Mobile == req.Mobile) || (req.Email != "" && user.Email == req.Email) {
+			writeJSON(w, map[string]interface{}{"userid": user.UserID})
+			return
+		}
+	}
+	writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+}
+
+// openIDPrefix 为模拟的 openid 前缀，openid 由 userid 加前缀得到
+const openIDPrefix = "o-"
+
+func (s *Server) convertToOpenID(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		UserID string `json:"userid"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	if _, ok := s.users[req.UserID]; !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+	writeJSON(w, map[string]interface{}{"openid": openIDPrefix + req.UserID})
+}
+
+func (s *Server) convertToUserID(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		OpenID string `json:"openid"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	userID := strings.TrimPrefix(req.OpenID, openIDPrefix)
+	if _, ok := s.users[userID]; !ok || userID == req.OpenID {
+		writeError(w, base.ErrCodeInvalidParameter, "invalid openid")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"userid": userID})
+}
+
+// authSucc 将成员标记为已激活
+func (s *Server) authSucc(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.users[r.URL.Query().Get("userid")]
+	if !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+	status := 1
+	user.Status = &status
+	writeJSON(w, nil)
+}
+
+func (s *Server) createDepartment(w http.ResponseWriter, r *http.Request) {
+	department := &api.Department{}
+	if !decode(w, r, department) {
+		return
+	}
+	if _, ok := s.departments[department.ParentID]; !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+	if department.ID == 0 {
+		department.ID = s.nextDeptID
+	}
+	if _, ok := s.departments[department.ID]; ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+	if department.ID >= s.nextDeptID {
+		s.nextDeptID = department.ID + 1
+	}
+
+	s.departments[department.ID] = department
+	writeJSON(w, map[string]interface{}{"id": department.ID})
+}
+
+func (s *Server) updateDepartment(w http.ResponseWriter, r *http.Request) {
+	patch := make(map[string]json.RawMessage)
+	if !decode(w, r, &patch) {
+		return
+	}
+
+	var id int64
+	json.Unmarshal(patch["id"], &id)
+	department, ok := s.departments[id]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+
+	updated := &api.Department{}
+	if !merge(department, patch, updated) {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	if _, ok := s.departments[updated.ParentID]; id != 1 && !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+
+	s.departments[id] = updated
+	writeJSON(w, nil)
+}
+
+func (s *Server) deleteDepartment(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if _, ok := s.departments[id]; !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+	if len(s.descendants(id)) > 0 {
+		writeError(w, ErrCodeDepartmentHasChild, errmsg(ErrCodeDepartmentHasChild))
+		return
+	}
+	for _, user := range s.users {
+		for _, departmentID := range user.DepartmentIds {
+			if departmentID == id {
+				writeError(w, ErrCodeDepartmentHasUsers, errmsg(ErrCodeDepartmentHasUsers))
+				return
+			}
+		}
+	}
+
+	delete(s.departments, id)
+	writeJSON(w, nil)
+}
+
+func (s *Server) listDepartment(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	if idStr := r.URL.Query().Get("id"); idStr != "" {
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+		if _, ok := s.departments[id]; !ok {
+			writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+			return
+		}
+		ids = append([]int64{id}, s.descendants(id)...)
+	} else {
+		for id := range s.departments {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if r.URL.Path == "/cgi-bin/department/simplelist" {
+		list := []interface{}{}
+		for _, id := range ids {
+			d := s.departments[id]
+			list = append(list, map[string]interface{}{"id": d.ID, "parentid": d.ParentID, "order": d.Order})
+		}
+		writeJSON(w, map[string]interface{}{"department_id": list})
+		return
+	}
+
+	list := []interface{}{}
+	for _, id := range ids {
+		list = append(list, toMap(s.departments[id]))
+	}
+	writeJSON(w, map[string]interface{}{"department": list})
+}
+
+func (s *Server) getDepartment(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	department, ok := s.departments[id]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidDepartment, errmsg(base.ErrCodeInvalidDepartment))
+		return
+	}
+	writeJSON(w, map[string]interface{}{"department": toMap(department)})
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		TagName string `json:"tagname"`
+		TagID   int    `json:"tagid"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.TagName == "" {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	if req.TagID == 0 {
+		req.TagID = s.nextTagID
+	}
+	if _, ok := s.tags[req.TagID]; ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+	if req.TagID >= s.nextTagID {
+		s.nextTagID = req.TagID + 1
+	}
+
+	s.tags[req.TagID] = &Tag{ID: req.TagID, Name: req.TagName}
+	writeJSON(w, map[string]interface{}{"tagid": req.TagID})
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		TagName string `json:"tagname"`
+		TagID   int    `json:"tagid"`
+	}{}
+	if !decode(w, r, req) {
+		return
+	}
+	tag, ok := s.tags[req.TagID]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+	tag.Name = req.TagName
+	writeJSON(w, nil)
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.URL.Query().Get("tagid"))
+	if _, ok := s.tags[id]; !ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+	delete(s.tags, id)
+	writeJSON(w, nil)
+}
+
+func (s *Server) getTag(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.URL.Query().Get("tagid"))
+	tag, ok := s.tags[id]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+
+	userList := []interface{}{}
+	for _, userID := range tag.UserIDs {
+		name := ""
+		if user, ok := s.users[userID]; ok {
+			name = user.Name
+		}
+		userList = append(userList, map[string]interface{}{"userid": userID, "name": name})
+	}
+	partyList := append([]int64{}, tag.PartyIDs...)
+	writeJSON(w, map[string]interface{}{"tagname": tag.Name, "userlist": userList, "partylist": partyList})
+}
+
+type tagUsersReq struct {
+	TagID     int      `json:"tagid"`
+	UserList  []string `json:"userlist"`
+	PartyList []int64  `json:"partylist"`
+}
+
+func (s *Server) addTagUsers(w http.ResponseWriter, r *http.Request) {
+	req := &tagUsersReq{}
+	if !decode(w, r, req) {
+		return
+	}
+	tag, ok := s.tags[req.TagID]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+
+	var invalidUsers []string
+	for _, userID := range req.UserList {
+		if _, ok := s.users[userID]; !ok {
+			invalidUsers = append(invalidUsers, userID)
+			continue
+		}
+		if !containsString(tag.UserIDs, userID) {
+			tag.UserIDs = append(tag.UserIDs, userID)
+		}
+	}
+
+	var invalidParties []int64
+	for _, id := range req.PartyList {
+		if _, ok := s.departments[id]; !ok {
+			invalidParties = append(invalidParties, id)
+			continue
+		}
+		if !containsInt64(tag.PartyIDs, id) {
+			tag.PartyIDs = append(tag.PartyIDs, id)
+		}
+	}
+
+	writeJSON(w, invalidTagMembers(invalidUsers, invalidParties))
+}
+
+func (s *Server) delTagUsers(w http.ResponseWriter, r *http.Request) {
+	req := &tagUsersReq{}
+	if !decode(w, r, req) {
+		return
+	}
+	tag, ok := s.tags[req.TagID]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidTagID, errmsg(base.ErrCodeInvalidTagID))
+		return
+	}
+
+	var invalidUsers []string
+	for _, userID := range req.UserList {
+		if !containsString(tag.UserIDs, userID) {
+			invalidUsers = append(invalidUsers, userID)
+			continue
+		}
+		tag.UserIDs = removeString(tag.UserIDs, userID)
+	}
+
+	var invalidParties []int64
+	for _, id := range req.PartyList {
+		if !containsInt64(tag.PartyIDs, id) {
+			invalidParties = append(invalidParties, id)
+			continue
+		}
+		tag.PartyIDs = removeInt64(tag.PartyIDs, id)
+	}
+
+	writeJSON(w, invalidTagMembers(invalidUsers, invalidParties))
+}
+
+func invalidTagMembers(users []string, parties []int64) map[string]interface{} {
+	result := make(map[string]interface{})
+	if len(users) > 0 {
+		invalidList := ""
+		for i, userID := range users {
+			if i > 0 {
+				invalidList += "|"
+			}
+			invalidList += userID
+		}
+		result["invalidlist"] = invalidList
+	}
+	if len(parties) > 0 {
+		result["invalidparty"] = parties
+	}
+	return result
+}
+
+func (s *Server) listTag(w http.ResponseWriter, r *http.Request) {
+	ids := make([]int, 0, len(s.tags))
+	for id := range s.tags {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	tagList := []interface{}{}
+	for _, id := range ids {
+		tagList = append(tagList, map[string]interface{}{"tagid": id, "tagname": s.tags[id].Name})
+	}
+	writeJSON(w, map[string]interface{}{"taglist": tagList})
+}
+
+func (s *Server) listExternalContact(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("userid")
+	if _, ok := s.users[userID]; !ok {
+		writeError(w, base.ErrCodeUserNotFound, errmsg(base.ErrCodeUserNotFound))
+		return
+	}
+
+	externalUserIDs := []string{}
+	for _, c := range s.sortedContacts() {
+		for _, f := range c.FollowUsers {
+			if f.Userid == userID {
+				externalUserIDs = append(externalUserIDs, c.Contact.ExternalUserid)
+				break
+			}
+		}
+	}
+	writeJSON(w, map[string]interface{}{"external_userid": externalUserIDs})
+}
+
+func (s *Server) getExternalContact(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.contacts[r.URL.Query().Get("external_userid")]
+	if !ok {
+		writeError(w, base.ErrCodeInvalidParameter, "invalid external_userid")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"external_contact": c.Contact, "follow_user": c.FollowUsers})
+}
+
+func (s *Server) batchExternalContact(w http.ResponseWriter, r *http.Request) {
+	req := &api.BatchExternalContactReq{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 50
+	}
+
+	type item struct {
+		FollowInfo      map[string]interface{} `json:"follow_info"`
+		ExternalContact api.ExternalContact    `json:"external_contact"`
+	}
+	var items []item
+	for _, c := range s.sortedContacts() {
+		for _, f := range c.FollowUsers {
+			if !containsString(req.UseridList, f.Userid) {
+				continue
+			}
+			followInfo := toMap(f)
+			tagIDs := []string{}
+			for _, tag := range f.Tags {
+				tagIDs = append(tagIDs, tag.TagId)
+			}
+			followInfo["tag_id"] = tagIDs
+			delete(followInfo, "tags")
+			items = append(items, item{FollowInfo: followInfo, ExternalContact: c.Contact})
+		}
+	}
+
+	start, _ := strconv.Atoi(req.Cursor)
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + int(req.Limit)
+	if end > len(items) {
+		end = len(items)
+	}
+	nextCursor := ""
+	if end < len(items) {
+		nextCursor = strconv.Itoa(end)
+	}
+
+	writeJSON(w, map[string]interface{}{"external_contact_list": items[start:end], "next_cursor": nextCursor})
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	message := json.RawMessage{}
+	if !decode(w, r, &message) {
+		return
+	}
+
+	req := struct {
+		ToUser       string `json:"touser"`
+		MsgType      string `json:"msgtype"`
+		TemplateCard struct {
+			CardType string `json:"card_type"`
+		} `json:"template_card"`
+	}{}
+	_ = json.Unmarshal(message, &req)
+
+	// touser 中不存在的成员视为无效成员，部门与标签不做校验
+	var invalid []string
+	if req.ToUser != "@all" {
+		for _, userID := range strings.Split(req.ToUser, "|") {
+			if _, ok := s.users[userID]; userID != "" && !ok {
+				invalid = append(invalid, userID)
+			}
+		}
+	}
+
+	s.messages = append(s.messages, message)
+	msgID := "msg" + strconv.Itoa(len(s.messages))
+	resp := map[string]interface{}{"invaliduser": strings.Join(invalid, "|"), "invalidparty": "", "invalidtag": "", "msgid": msgID}
+
+	// 交互型模板卡片返回用于更新卡片的 response_code
+	switch req.TemplateCard.CardType {
+	case "button_interaction", "vote_interaction", "multiple_interaction":
+		code := "code-" + msgID
+		s.cardCodes[code] = true
+		resp["response_code"] = code
+	}
+
+	writeJSON(w, resp)
+}
+
+func (s *Server) recallMessage(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		MsgID string `json:"msgid"`
+	}{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(req.MsgID, "msg"))
+	if err != nil || !strings.HasPrefix(req.MsgID, "msg") || n < 1 || n > len(s.messages) || s.recalled[req.MsgID] {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	s.recalled[req.MsgID] = true
+	writeJSON(w, nil)
+}
+
+// updateTemplateCard 方法校验 response_code，每个 response_code 只能使用一次
+func (s *Server) updateTemplateCard(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		UserIDs      []string `json:"userids"`
+		ResponseCode string   `json:"response_code"`
+	}{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if !s.cardCodes[req.ResponseCode] {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	s.cardCodes[req.ResponseCode] = false
+
+	invalid := []string{}
+	for _, userID := range req.UserIDs {
+		if _, ok := s.users[userID]; !ok {
+			invalid = append(invalid, userID)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"invaliduser": invalid})
+}
+
+func (s *Server) departmentsExist(ids []int64) bool {
+	for _, id := range ids {
+		if _, ok := s.departments[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// descendants 方法返回部门 id 的所有子孙部门
+func (s *Server) descendants(id int64) []int64 {
+	var ids []int64
+	for _, d := range s.departments {
+		if d.ID != id && d.ParentID == id {
+			ids = append(ids, d.ID)
+			ids = append(ids, s.descendants(d.ID)...)
+		}
+	}
+	return ids
+}
+
+func (s *Server) sortedUsers() []*api.User {
+	users := make([]*api.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users
+}
+
+func (s *Server) sortedContacts() []*ExternalContact {
+	contacts := make([]*ExternalContact, 0, len(s.contacts))
+	for _, c := range s.contacts {
+		contacts = append(contacts, c)
+	}
+	sort.Slice(contacts, func(i, j int) bool { return contacts[i].Contact.ExternalUserid < contacts[j].Contact.ExternalUserid })
+	return contacts
+}
+
+// merge 方法将 patch 中的字段覆盖到 current 上并写入 result，用于模拟仅更新传入字段的接口
+func merge(current interface{}, patch map[string]json.RawMessage, result interface{}) bool {
+	fields := make(map[string]json.RawMessage)
+	data, _ := json.Marshal(current)
+	json.Unmarshal(data, &fields)
+	for key, val := range patch {
+		fields[key] = val
+	}
+	data, _ = json.Marshal(fields)
+	return json.Unmarshal(data, result) == nil
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	data, _ := json.Marshal(v)
+	json.Unmarshal(data, &m)
+	return m
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt64(list []int64, n int64) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	result := list[:0]
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func removeInt64(list []int64, n int64) []int64 {
+	result := list[:0]
+	for _, v := range list {
+		if v != n {
+			result = append(result, v)
+		}
+	}
+	return result
+}
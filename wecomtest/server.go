@@ -0,0 +1,328 @@
+// Package wecomtest 提供基于 httptest 的企业微信服务端模拟，用于在无网络环境下进行端到端测试。
+//
+// Server 会签发并过期 access_token，在内存中维护成员、部门、标签、客户及群机器人数据，
+// 并支持模拟 token 过期、频率限制等错误码，通过 Client.SetBaseURI 接入：
+//
+//	srv := wecomtest.NewServer()
+//	defer srv.Close()
+//
+//	a := srv.NewAPI("corpid", "secret")
+//	user, err := a.GetUser("zhangsan")
+package wecomtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// DefaultTokenTTL 为签发的 access_token 的默认有效期
+const DefaultTokenTTL = 2 * time.Hour
+
+// 模拟服务端会返回的错误码，base 中未定义的错误码在此补充
+const (
+	ErrCodeInvalidCorpID      = 40013
+	ErrCodeMissingAccessToken = 41001
+	ErrCodeDepartmentHasUsers = 60005
+	ErrCodeDepartmentHasChild = 60006
+	ErrCodeInvalidWebhookURL  = 93000
+)
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request)
+
+type accessToken struct {
+	corpID    string
+	expiresAt time.Time
+}
+
+type fault struct {
+	errcode   int
+	errmsg    string
+	remaining int
+}
+
+// Server 为企业微信服务端的模拟实现，所有方法都是并发安全的
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]handlerFunc
+	secrets  map[string]map[string]bool
+	tokens   map[string]*accessToken
+	tokenTTL time.Duration
+	faults   map[string][]*fault
+	limits   map[string]int
+	calls    map[string]int
+
+	users       map[string]*api.User
+	departments map[int64]*api.Department
+	tags        map[int]*Tag
+	contacts    map[string]*ExternalContact
+	messages    []json.RawMessage
+	recalled    map[string]bool
+	cardCodes   map[string]bool
+	chats       map[string]*api.AppChat
+	chatMsgs    map[string][]json.RawMessage
+	robots      map[string]*robot
+	nextDeptID  int64
+	nextTagID   int
+}
+
+// NewServer 方法用于创建并启动模拟服务端，初始包含 id 为 1 的根部门
+func NewServer() *Server {
+	s := &Server{
+		secrets:     make(map[string]map[string]bool),
+		tokens:      make(map[string]*accessToken),
+		tokenTTL:    DefaultTokenTTL,
+		faults:      make(map[string][]*fault),
+		limits:      make(map[string]int),
+		calls:       make(map[string]int),
+		users:       make(map[string]*api.User),
+		departments: map[int64]*api.Department{1: {ID: 1, Name: "root"}},
+		tags:        make(map[int]*Tag),
+		contacts:    make(map[string]*ExternalContact),
+		recalled:    make(map[string]bool),
+		cardCodes:   make(map[string]bool),
+		chats:       make(map[string]*api.AppChat),
+		chatMsgs:    make(map[string][]json.RawMessage),
+		robots:      make(map[string]*robot),
+		nextDeptID:  2,
+		nextTagID:   1,
+	}
+	s.registerHandlers()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddCorp 方法用于注册企业及其应用的 secret，同一企业可以注册多个 secret
+func (s *Server) AddCorp(corpID, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secrets[corpID] == nil {
+		s.secrets[corpID] = make(map[string]bool)
+	}
+	s.secrets[corpID][secret] = true
+}
+
+// NewAPI 方法用于注册企业并创建指向模拟服务端的 API 实例，重试策略的等待时间会被缩短以加快测试
+func (s *Server) NewAPI(corpID, secret string) *api.API {
+	s.AddCorp(corpID, secret)
+
+	a := api.New(corpID, secret, "", "")
+	a.Client.SetBaseURI(s.URL)
+	a.Client.SetRetryPolicy(&base.RetryPolicy{
+		MaxAttempts:   base.DefaultRetryPolicy.MaxAttempts,
+		BaseDelay:     time.Millisecond,
+		RetryErrCodes: base.DefaultRetryPolicy.RetryErrCodes,
+	})
+	return a
+}
+
+// SetTokenTTL 方法用于设置之后签发的 access_token 的有效期
+func (s *Server) SetTokenTTL(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenTTL = d
+}
+
+// ExpireTokens 方法用于使已签发的 access_token 全部过期，之后的调用将返回 42001
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		t.expiresAt = time.Now()
+	}
+}
+
+// InjectError 方法用于使接下来 times 次调用 path（如 /cgi-bin/user/get）时返回指定的错误码，
+// 可多次调用以按顺序返回不同的错误码
+func (s *Server) InjectError(path string, errcode, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], &fault{errcode: errcode, errmsg: errmsg(errcode), remaining: times})
+}
+
+// SetCallLimit 方法用于设置 path 的调用次数上限，超出后返回 45009 频率限制错误，limit 为 0 时取消限制
+func (s *Server) SetCallLimit(path string, limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit <= 0 {
+		delete(s.limits, path)
+		return
+	}
+	s.limits[path] = limit
+}
+
+// Calls 方法返回 path 被调用的次数，包括返回错误码的调用
+func (s *Server) Calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[path]
+}
+
+func (s *Server) handle(path string, h handlerFunc) {
+	s.handlers[path] = h
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.URL.Path
+	s.calls[path]++
+
+	h, ok := s.handlers[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if f := s.nextFault(path); f != nil {
+		writeError(w, f.errcode, f.errmsg)
+		return
+	}
+
+	if limit, ok := s.limits[path]; ok && s.calls[path] > limit {
+		writeError(w, base.ErrCodeFrequencyLimit, errmsg(base.ErrCodeFrequencyLimit))
+		return
+	}
+
+	// 群机器人通过 webhook 地址中的 key 鉴权，不需要 access_token
+	if path != "/cgi-bin/gettoken" && !strings.HasPrefix(path, "/cgi-bin/webhook/") {
+		if code := s.checkToken(r.URL.Query().Get("access_token")); code != base.ErrCodeOk {
+			writeError(w, code, errmsg(code))
+			return
+		}
+	}
+
+	h(w, r)
+}
+
+func (s *Server) nextFault(path string) *fault {
+	faults := s.faults[path]
+	if len(faults) == 0 {
+		return nil
+	}
+
+	f := faults[0]
+	if f.remaining--; f.remaining <= 0 {
+		s.faults[path] = faults[1:]
+	}
+	return f
+}
+
+func (s *Server) checkToken(token string) int {
+	if token == "" {
+		return ErrCodeMissingAccessToken
+	}
+	t, ok := s.tokens[token]
+	if !ok {
+		return base.ErrCodeAccessTokenInvalid
+	}
+	if !time.Now().Before(t.expiresAt) {
+		return base.ErrCodeTokenTimeout
+	}
+	return base.ErrCodeOk
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	secrets, ok := s.secrets[q.Get("corpid")]
+	if !ok {
+		writeError(w, ErrCodeInvalidCorpID, errmsg(ErrCodeInvalidCorpID))
+		return
+	}
+	if !secrets[q.Get("corpsecret")] {
+		writeError(w, base.ErrCodeTokenInvalid, errmsg(base.ErrCodeTokenInvalid))
+		return
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	s.tokens[token] = &accessToken{corpID: q.Get("corpid"), expiresAt: time.Now().Add(s.tokenTTL)}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"expires_in":   int64(s.tokenTTL / time.Second),
+	})
+}
+
+func errmsg(errcode int) string {
+	switch errcode {
+	case base.ErrCodeSystemBusy:
+		return "system busy"
+	case base.ErrCodeTokenInvalid:
+		return "invalid credential"
+	case base.ErrCodeInvalidUserID:
+		return "invalid userid"
+	case ErrCodeInvalidCorpID:
+		return "invalid corpid"
+	case base.ErrCodeAccessTokenInvalid:
+		return "invalid access_token"
+	case base.ErrCodeInvalidParameter:
+		return "invalid parameter"
+	case base.ErrCodeInvalidTagID:
+		return "invalid tagid"
+	case ErrCodeMissingAccessToken:
+		return "access_token missing"
+	case base.ErrCodeTokenTimeout:
+		return "access_token expired"
+	case base.ErrCodeFrequencyLimit:
+		return "api freq out of limit"
+	case ErrCodeDepartmentHasUsers:
+		return "department contains user"
+	case ErrCodeDepartmentHasChild:
+		return "department contains sub-department"
+	case base.ErrCodeUserExists:
+		return "userid existed"
+	case base.ErrCodeUserNotFound:
+		return "userid not found"
+	case base.ErrCodeAppChatNotFound:
+		return "chat not exists"
+	case base.ErrCodeInvalidDepartment:
+		return "invalid party id"
+	case ErrCodeInvalidWebhookURL:
+		return "invalid webhook url"
+	}
+	return "unknown error"
+}
+
+// decode 方法用于解析请求体，解析失败时返回 40058 并返回 false
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, data map[string]interface{}) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["errcode"] = base.ErrCodeOk
+	data["errmsg"] = "ok"
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, errcode int, errmsg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(&base.Error{ErrCode: errcode, ErrMsg: errmsg})
+}
@@ -0,0 +1,197 @@
+package wecomtest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shengbox/wechat-qy/api"
+	"github.com/shengbox/wechat-qy/base"
+)
+
+func TestServer_Contacts(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	a := srv.NewAPI("ww-corp", "secret")
+
+	department := &api.Department{Name: "研发部", ParentID: 1}
+	if err := a.CreateDepartment(department); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if department.ID != 2 {
+		t.Errorf("Expected department id 2, got %d", department.ID)
+	}
+
+	if err := a.CreateUser(&api.User{UserID: "zhangsan", Name: "张三", DepartmentIds: []int64{department.ID}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := a.CreateUser(&api.User{UserID: "zhangsan", Name: "张三"}); !errors.Is(err, base.ErrUserExists) {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+	if err := a.UpdateUser(&api.User{UserID: "zhangsan", Position: "工程师"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user, err := a.GetUser("zhangsan")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.Name != "张三" || user.Position != "工程师" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+
+	fetchChild := 1
+	users, err := a.ListUser(1, &fetchChild, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].UserID != "zhangsan" {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	if err := a.DeleteDepartment(department.ID); err == nil {
+		t.Error("Expected error when deleting department with users")
+	}
+	if err := a.DeleteUser("zhangsan"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := a.GetUser("zhangsan"); !errors.Is(err, base.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestServer_TokenExpiry(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddUser(&api.User{UserID: "lisi", Name: "李四", DepartmentIds: []int64{1}})
+	a := srv.NewAPI("ww-corp", "secret")
+
+	if _, err := a.GetUser("lisi"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// token 过期后客户端应自动刷新并重试
+	srv.ExpireTokens()
+	if _, err := a.GetUser("lisi"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := srv.Calls("/cgi-bin/gettoken"); calls != 2 {
+		t.Errorf("Expected token to be fetched twice, got %d", calls)
+	}
+
+	srv.AddCorp("ww-other", "other-secret")
+	bad := api.New("ww-other", "bad-secret", "", "")
+	bad.Client.SetBaseURI(srv.URL)
+	if _, _, err := bad.FetchToken(); !errors.Is(err, base.ErrTokenInvalid) {
+		t.Errorf("Expected ErrTokenInvalid, got %v", err)
+	}
+}
+
+func TestServer_InjectError(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddUser(&api.User{UserID: "lisi", Name: "李四", DepartmentIds: []int64{1}})
+	a := srv.NewAPI("ww-corp", "secret")
+
+	// 系统繁忙按重试策略重试，GET 请求最终成功
+	srv.InjectError("/cgi-bin/user/get", base.ErrCodeSystemBusy, 2)
+	if _, err := a.GetUser("lisi"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := srv.Calls("/cgi-bin/user/get"); calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+
+	srv.SetCallLimit("/cgi-bin/message/send", 1)
+	message := map[string]interface{}{"touser": "lisi", "msgtype": "text", "text": map[string]string{"content": "hi"}}
+	if _, err := a.SendMessage(message); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := a.SendMessage(message); !errors.Is(err, base.ErrFrequencyLimit) {
+		t.Errorf("Expected ErrFrequencyLimit, got %v", err)
+	}
+	if messages := srv.Messages(); len(messages) != 1 || !strings.Contains(string(messages[0]), `"content":"hi"`) {
+		t.Errorf("Unexpected messages: %s", messages)
+	}
+}
+
+func TestServer_TagsAndExternalContacts(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddUser(&api.User{UserID: "lisi", Name: "李四", DepartmentIds: []int64{1}})
+	srv.AddTag(&Tag{ID: 1, Name: "销售", UserIDs: []string{"lisi"}})
+	srv.AddExternalContact(&ExternalContact{
+		Contact:     api.ExternalContact{ExternalUserid: "wmXXX", Name: "客户"},
+		FollowUsers: []api.FollowUser{{Userid: "lisi", Remark: "老客户"}},
+	})
+	a := srv.NewAPI("ww-corp", "secret")
+
+	ids, err := a.ListExternalContact("lisi")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "wmXXX" {
+		t.Errorf("Unexpected external contacts: %v", ids)
+	}
+
+	contact, err := a.GetExternalContact("wmXXX")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contact.ExternalContact.Name != "客户" || len(contact.FollowUser) != 1 || contact.FollowUser[0].Remark != "老客户" {
+		t.Errorf("Unexpected external contact: %+v", contact)
+	}
+
+	if err := a.AddTagUsers(1, []string{"nobody"}, nil); err == nil {
+		t.Error("Expected error for invalid tag user")
+	}
+	tag, err := a.GetTag(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tag.Name != "销售" || len(tag.Users) != 1 || tag.Users[0].Name != "李四" {
+		t.Errorf("Unexpected tag: %+v", tag)
+	}
+}
+
+func TestServer_UserLookups(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddDepartment(&api.Department{ID: 2, Name: "研发部", ParentID: 1})
+	srv.AddUser(&api.User{UserID: "zhangsan", Name: "张三", Mobile: "13800000000", DepartmentIds: []int64{1, 2}})
+	srv.AddUser(&api.User{UserID: "lisi", Name: "李四", Email: "lisi@example.com", DepartmentIds: []int64{2}})
+	a := srv.NewAPI("ww-corp", "secret")
+
+	if userID, err := a.GetUserIDByMobile("13800000000"); err != nil || userID != "zhangsan" {
+		t.Errorf("Unexpected result: %q, %v", userID, err)
+	}
+	if userID, err := a.GetUserIDByEmail("lisi@example.com", api.EmailTypeCorp); err != nil || userID != "lisi" {
+		t.Errorf("Unexpected result: %q, %v", userID, err)
+	}
+
+	openID, err := a.ConvertToOpenID("lisi")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if userID, err := a.ConvertToUserID(openID); err != nil || userID != "lisi" {
+		t.Errorf("Unexpected result: %q, %v", userID, err)
+	}
+
+	var names []string
+	err = a.WalkUsers(context.Background(), func(user *api.User) error {
+		names = append(names, user.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(names, ",") != "李四,张三" {
+		t.Errorf("Unexpected users: %v", names)
+	}
+}
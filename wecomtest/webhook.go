@@ -0,0 +1,105 @@
+package wecomtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/shengbox/wechat-qy/base"
+)
+
+// robot 为群机器人的数据，按 webhook 地址中的 key 区分
+type robot struct {
+	messages []json.RawMessage
+	uploads  []*RobotUpload
+}
+
+// RobotUpload 为通过 webhook/upload_media 上传到群机器人的文件
+type RobotUpload struct {
+	MediaID  string
+	Type     string
+	Filename string
+	Data     []byte
+}
+
+// AddRobot 方法用于注册群机器人，未注册的 key 调用 webhook 接口时返回 93000
+func (s *Server) AddRobot(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.robots[key]; !ok {
+		s.robots[key] = &robot{}
+	}
+}
+
+// RobotMessages 方法返回群机器人通过 webhook/send 收到的所有消息的原始 JSON
+func (s *Server) RobotMessages(key string) []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.robots[key]; ok {
+		return append([]json.RawMessage(nil), r.messages...)
+	}
+	return nil
+}
+
+// RobotUploads 方法返回群机器人通过 webhook/upload_media 收到的所有文件
+func (s *Server) RobotUploads(key string) []*RobotUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.robots[key]; ok {
+		return append([]*RobotUpload(nil), r.uploads...)
+	}
+	return nil
+}
+
+// robotOf 方法返回 key 对应的群机器人，未注册时返回 93000 并返回 nil
+func (s *Server) robotOf(w http.ResponseWriter, r *http.Request) *robot {
+	rb, ok := s.robots[r.URL.Query().Get("key")]
+	if !ok {
+		writeError(w, ErrCodeInvalidWebhookURL, errmsg(ErrCodeInvalidWebhookURL))
+		return nil
+	}
+	return rb
+}
+
+func (s *Server) sendWebhook(w http.ResponseWriter, r *http.Request) {
+	rb := s.robotOf(w, r)
+	if rb == nil {
+		return
+	}
+
+	message := json.RawMessage{}
+	if !decode(w, r, &message) {
+		return
+	}
+	rb.messages = append(rb.messages, message)
+	writeJSON(w, nil)
+}
+
+func (s *Server) uploadWebhookMedia(w http.ResponseWriter, r *http.Request) {
+	rb := s.robotOf(w, r)
+	if rb == nil {
+		return
+	}
+
+	file, header, err := r.FormFile("media")
+	if err != nil {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, base.ErrCodeInvalidParameter, errmsg(base.ErrCodeInvalidParameter))
+		return
+	}
+
+	upload := &RobotUpload{
+		MediaID:  "media" + strconv.Itoa(len(rb.uploads)+1),
+		Type:     r.URL.Query().Get("type"),
+		Filename: header.Filename,
+		Data:     data,
+	}
+	rb.uploads = append(rb.uploads, upload)
+	writeJSON(w, map[string]interface{}{"type": upload.Type, "media_id": upload.MediaID, "created_at": "1380000000"})
+}